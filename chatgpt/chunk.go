@@ -0,0 +1,165 @@
+package chatgpt
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Extracted text longer than chunkSize characters is sent in several requests,
+// each small enough to leave room in the model's context window for the prompt
+// and reply. Consecutive chunks share chunkOverlap characters so material cut
+// at a boundary still appears whole in one of them.
+const (
+	chunkSize    = 24000
+	chunkOverlap = 1000
+)
+
+// pageMarker matches the "[Page N]" markers the PDF processor puts before each page
+var pageMarker = regexp.MustCompile(`\[Page \d+\]`)
+
+// splitText splits text into windows of at most size bytes that overlap by
+// about overlap bytes, breaking at whitespace where possible. A window that
+// starts partway through a page is prefixed with that page's marker so the
+// model can still cite its source.
+func splitText(text string, size, overlap int) []string {
+	if len(text) <= size {
+		return []string{text}
+	}
+
+	var chunks []string
+	for start := 0; ; {
+		end := start + size
+		if end >= len(text) {
+			chunks = append(chunks, withPageMarker(text, start))
+			return chunks
+		}
+		end = breakBefore(text, start, end)
+		chunks = append(chunks, withPageMarker(text[:end], start))
+
+		next := breakAfter(text, end-overlap, end)
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+}
+
+// breakBefore returns the last whitespace position in the second half of
+// text[start:end], or end itself (moved back to a rune boundary) if there is none
+func breakBefore(text string, start, end int) int {
+	mid := start + (end-start)/2
+	if i := strings.LastIndexAny(text[mid:end], " \n\t"); i != -1 {
+		return mid + i + 1
+	}
+	for end > start && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return end
+}
+
+// breakAfter returns the position just past the first whitespace in
+// text[from:limit], or from itself (moved forward to a rune boundary) if there is none
+func breakAfter(text string, from, limit int) int {
+	if from < 0 {
+		from = 0
+	}
+	if i := strings.IndexAny(text[from:limit], " \n\t"); i != -1 {
+		return from + i + 1
+	}
+	for from < limit && !utf8.RuneStart(text[from]) {
+		from++
+	}
+	return from
+}
+
+// withPageMarker returns text[start:], prefixed with the marker of the page
+// start falls in unless the window already begins with a marker
+func withPageMarker(text string, start int) string {
+	window := text[start:]
+	if loc := pageMarker.FindStringIndex(window); start == 0 || loc != nil && loc[0] == 0 {
+		return window
+	}
+	markers := pageMarker.FindAllString(text[:start], -1)
+	if len(markers) == 0 {
+		return window
+	}
+	return markers[len(markers)-1] + " " + window
+}
+
+// shareQuestions divides total questions between chunks in proportion to
+// their length, so the counts always add up to total
+func shareQuestions(chunks []string, total int) []int {
+	length := 0
+	for _, chunk := range chunks {
+		length += len(chunk)
+	}
+
+	counts := make([]int, len(chunks))
+	if length == 0 {
+		return counts
+	}
+
+	// Give each chunk its whole share, then hand out what is left in order
+	// of the largest remainders
+	assigned := 0
+	remainders := make([]int, len(chunks))
+	for i, chunk := range chunks {
+		counts[i] = total * len(chunk) / length
+		remainders[i] = total * len(chunk) % length
+		assigned += counts[i]
+	}
+	for ; assigned < total; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		counts[best]++
+		remainders[best] = -1
+	}
+	return counts
+}
+
+// generateChunked generates numQuestions questions from text, splitting it into
+// chunks when it is too long for one request. Each chunk gets its share of the
+// questions; the results are merged, near-identical questions are dropped and
+// token usage is summed.
+func generateChunked(ctx context.Context, text string, numQuestions int, generate func(ctx context.Context, chunk string, numQuestions int) ([]*GeneratedQuestion, *Usage, error)) ([]*GeneratedQuestion, *Usage, error) {
+	chunks := splitText(text, chunkSize, chunkOverlap)
+	if len(chunks) == 1 {
+		return generate(ctx, text, numQuestions)
+	}
+
+	var questions []*GeneratedQuestion
+	var usage *Usage
+	for i, count := range shareQuestions(chunks, numQuestions) {
+		if count == 0 {
+			continue
+		}
+		chunkQuestions, chunkUsage, err := generate(ctx, chunks[i], count)
+		usage = addUsage(usage, chunkUsage)
+		if err != nil {
+			return nil, usage, err
+		}
+		questions = append(questions, chunkQuestions...)
+	}
+	return dedupeQuestions(questions, nil), usage, nil
+}
+
+// addUsage returns the sum of two token counts, either of which may be nil
+func addUsage(total, usage *Usage) *Usage {
+	if usage == nil {
+		return total
+	}
+	if total == nil {
+		sum := *usage
+		return &sum
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	return total
+}
@@ -0,0 +1,115 @@
+package chatgpt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitTextChunkCount(t *testing.T) {
+	// 100 ten byte words, split into 100 byte windows that overlap by 20
+	var b strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "word%05d ", i)
+	}
+	text := b.String()
+
+	chunks := splitText(text, 100, 20)
+	if len(chunks) != 11 {
+		t.Fatalf("got %d chunks, want 11", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 100 {
+			t.Errorf("chunk %d is %d bytes, over the 100 byte budget", i, len(chunk))
+		}
+		if !strings.Contains(text, chunk) {
+			t.Errorf("chunk %d is not part of the text: %q", i, chunk)
+		}
+		if i > 0 && !strings.Contains(chunks[i-1], strings.Fields(chunk)[0]) {
+			t.Errorf("chunk %d does not overlap the one before it", i)
+		}
+	}
+	if !strings.HasPrefix(text, chunks[0]) || !strings.HasSuffix(text, chunks[len(chunks)-1]) {
+		t.Error("the chunks do not cover the start and end of the text")
+	}
+
+	if chunks := splitText("short text", 100, 20); len(chunks) != 1 || chunks[0] != "short text" {
+		t.Errorf("short text split into %q", chunks)
+	}
+}
+
+func TestShareQuestionsSumsToTotal(t *testing.T) {
+	chunks := []string{strings.Repeat("a", 100), strings.Repeat("b", 100), strings.Repeat("c", 50)}
+	for total := 0; total <= 20; total++ {
+		counts := shareQuestions(chunks, total)
+		sum := 0
+		for _, n := range counts {
+			sum += n
+		}
+		if sum != total {
+			t.Errorf("%d questions shared as %v, which sum to %d", total, counts, sum)
+		}
+		if counts[2] > counts[0] {
+			t.Errorf("%d questions shared as %v, giving the shortest chunk the most", total, counts)
+		}
+	}
+	if counts := shareQuestions(chunks, 10); counts[0] != 4 || counts[1] != 4 || counts[2] != 2 {
+		t.Errorf("10 questions shared as %v, want [4 4 2]", counts)
+	}
+}
+
+func TestGenerateChunkedMergesQuestions(t *testing.T) {
+	text := strings.Repeat("Cells divide by mitosis. ", 3*chunkSize/25)
+
+	var requested []int
+	generate := func(ctx context.Context, chunk string, numQuestions int) ([]*GeneratedQuestion, *Usage, error) {
+		requested = append(requested, numQuestions)
+		var questions []*GeneratedQuestion
+		for i := 0; i < numQuestions; i++ {
+			questions = append(questions, &GeneratedQuestion{Question: fmt.Sprintf("Chunk %d question %d", len(requested), i), CorrectAnswer: "x"})
+		}
+		return questions, &Usage{TotalTokens: 10}, nil
+	}
+
+	questions, usage, err := generateChunked(context.Background(), text, 12, generate)
+	if err != nil {
+		t.Fatalf("generateChunked: %v", err)
+	}
+	if len(requested) < 3 {
+		t.Fatalf("text of %d bytes sent in %d requests, want at least 3", len(text), len(requested))
+	}
+	if len(questions) != 12 {
+		t.Errorf("merged %d questions from requests for %v, want 12", len(questions), requested)
+	}
+	if usage == nil || usage.TotalTokens != 10*len(requested) {
+		t.Errorf("usage = %+v, want the sum of %d requests", usage, len(requested))
+	}
+}
+
+func TestSplitTextKeepsPageMarkers(t *testing.T) {
+	var b strings.Builder
+	for page := 1; page <= 3; page++ {
+		fmt.Fprintf(&b, "[Page %d]\n", page)
+		for i := 0; i < 10; i++ {
+			fmt.Fprintf(&b, "word%05d ", i)
+		}
+		b.WriteString("\n\n")
+	}
+
+	chunks := splitText(b.String(), 100, 20)
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the pages split up", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if !strings.HasPrefix(chunk, "[Page ") {
+			t.Errorf("chunk %d does not start with its page marker: %q", i, chunk)
+		}
+		if strings.Count(chunk, "[Page ") > 2 {
+			t.Errorf("chunk %d repeats a page marker: %q", i, chunk)
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.Contains(last, "[Page 3]") {
+		t.Errorf("last chunk %q is not marked as page 3", last)
+	}
+}
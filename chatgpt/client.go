@@ -1,37 +1,67 @@
 package chatgpt
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Client represents the ChatGPT API client
 type Client struct {
 	apiKey     string
+	model      string
 	httpClient *http.Client
 	baseURL    string
+	retryDelay time.Duration // Wait before the first retry, doubled for each further one
 }
 
-// NewClient creates a new ChatGPT client
-func NewClient(apiKey string) *Client {
+// DefaultModel is the chat model used when none is configured
+const DefaultModel = "gpt-3.5-turbo"
+
+// NewClient creates a new ChatGPT client using the given chat model, or
+// DefaultModel when model is empty
+func NewClient(apiKey, model string) *Client {
+	if model == "" {
+		model = DefaultModel
+	}
 	return &Client{
 		apiKey:     apiKey,
+		model:      model,
 		httpClient: &http.Client{Timeout: 60 * time.Second},
 		baseURL:    "https://api.openai.com/v1",
+		retryDelay: time.Second,
 	}
 }
 
+// Model returns the chat model the client sends requests to
+func (c *Client) Model() string {
+	return c.model
+}
+
+// Enabled reports whether the client has an API key and can make requests
+func (c *Client) Enabled() bool {
+	return c.apiKey != ""
+}
+
 // ChatRequest represents a request to the ChatGPT API
 type ChatRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures a streamed chat completion
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // Message represents a chat message
@@ -54,11 +84,15 @@ type ChatResponse struct {
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage represents the token usage reported by the API for a request
+type Usage struct {
+	Model            string `json:"-"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
 }
 
 // GeneratedQuestion represents a question generated by ChatGPT
@@ -68,22 +102,105 @@ type GeneratedQuestion struct {
 	Options       []string `json:"options,omitempty"`
 	CorrectAnswer string   `json:"correct_answer"`
 	Explanation   string   `json:"explanation"`
+	SourceRef     string   `json:"source_ref,omitempty"` // Pages the question is based on, e.g. "p. 3"
+	Difficulty    string   `json:"difficulty,omitempty"` // "easy", "medium" or "hard"
 }
 
-// GenerateQuestions generates test questions from the provided text
-func (c *Client) GenerateQuestions(text string, numQuestions int, questionTypes []string) ([]*GeneratedQuestion, error) {
+// GenerateQuestions generates test questions from the provided text.
+// difficulty ("easy", "medium" or "hard") asks for questions of that level and
+// is stored on each of them; when empty the model rates every question itself.
+// Text too long for one request is split into chunks that each get a share of
+// the questions. The returned usage is nil when the API response did not
+// include token counts.
+func (c *Client) GenerateQuestions(text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error) {
+	return c.GenerateQuestionsContext(context.Background(), text, numQuestions, questionTypes, difficulty)
+}
+
+// GenerateQuestionsContext generates questions like GenerateQuestions, giving
+// up as soon as ctx is cancelled or its deadline passes
+func (c *Client) GenerateQuestionsContext(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error) {
 	if c.apiKey == "" {
-		return nil, fmt.Errorf("API key is required")
+		return nil, nil, fmt.Errorf("API key is required")
 	}
 
-	prompt := c.buildPrompt(text, numQuestions, questionTypes)
+	return generateChunked(ctx, text, numQuestions, func(ctx context.Context, chunk string, numQuestions int) ([]*GeneratedQuestion, *Usage, error) {
+		prompt := buildPrompt(chunk, numQuestions, questionTypes, difficulty)
+		questions, usage, err := c.requestQuestions(ctx, prompt)
+		if err != nil {
+			return nil, usage, err
+		}
 
-	request := ChatRequest{
-		Model: "gpt-3.5-turbo",
+		if difficulty != "" {
+			for _, q := range questions {
+				q.Difficulty = difficulty
+			}
+		}
+		return questions, usage, nil
+	})
+}
+
+// GenerateSimilarQuestions generates new questions covering the same topics as
+// existing ones, for when the original source material is no longer available.
+// Generated questions that repeat an existing question are dropped.
+func (c *Client) GenerateSimilarQuestions(ctx context.Context, existing []string, numQuestions int, questionTypes []string) ([]*GeneratedQuestion, *Usage, error) {
+	if c.apiKey == "" {
+		return nil, nil, fmt.Errorf("API key is required")
+	}
+
+	if len(existing) == 0 {
+		return nil, nil, fmt.Errorf("at least one existing question is required")
+	}
+
+	prompt := buildSimilarPrompt(existing, numQuestions, questionTypes)
+	questions, usage, err := c.requestQuestions(ctx, prompt)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	return dedupeQuestions(questions, existing), usage, nil
+}
+
+// requestQuestions sends a question generation prompt and parses the reply
+func (c *Client) requestQuestions(ctx context.Context, prompt string) ([]*GeneratedQuestion, *Usage, error) {
+	response, err := c.makeRequest(ctx, c.questionRequest(prompt))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+
+	usage := response.Usage
+	if usage != nil {
+		usage.Model = response.Model
+	}
+
+	if len(response.Choices) == 0 {
+		return nil, usage, fmt.Errorf("no response from ChatGPT")
+	}
+
+	// Check if the response was truncated
+	if response.Choices[0].FinishReason == "length" {
+		fmt.Printf("Warning: ChatGPT response was truncated due to length limits\n")
+	}
+
+	questions, err := parseQuestions(response.Choices[0].Message.Content)
+	if err != nil {
+		return nil, usage, fmt.Errorf("failed to parse questions: %w", err)
+	}
+
+	return questions, usage, nil
+}
+
+// systemPrompt sets up the model for question generation, ahead of the prompt
+// built by buildPrompt or buildSimilarPrompt
+const systemPrompt = "You are an expert educator who creates high-quality test questions for PhD students. Always respond with valid JSON format. Ensure your response is complete and not truncated."
+
+// questionRequest builds the chat request for a question generation prompt
+func (c *Client) questionRequest(prompt string) ChatRequest {
+	return ChatRequest{
+		Model: c.model,
 		Messages: []Message{
 			{
 				Role:    "system",
-				Content: "You are an expert educator who creates high-quality test questions for PhD students. Always respond with valid JSON format. Ensure your response is complete and not truncated.",
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
@@ -93,40 +210,68 @@ func (c *Client) GenerateQuestions(text string, numQuestions int, questionTypes
 		MaxTokens:   3000,
 		Temperature: 0.7,
 	}
+}
 
-	response, err := c.makeRequest(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
+// buildPrompt creates the prompt for question generation. A non-empty
+// difficulty asks for every question to be of that level.
+func buildPrompt(text string, numQuestions int, questionTypes []string, difficulty string) string {
+	typesStr := strings.Join(questionTypes, ", ")
 
-	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no response from ChatGPT")
+	level := ""
+	if difficulty != "" {
+		level = fmt.Sprintf(" Make every question %s difficulty.", difficulty)
 	}
 
-	// Check if the response was truncated
-	if response.Choices[0].FinishReason == "length" {
-		fmt.Printf("Warning: ChatGPT response was truncated due to length limits\n")
-	}
+	prompt := fmt.Sprintf(`Based on the following text, generate %d test questions. Use these question types: %s.%s
 
-	questions, err := c.parseQuestions(response.Choices[0].Message.Content)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse questions: %w", err)
-	}
+For multiple choice questions, provide 4 options (A, B, C, D).
+For multi_select questions, provide 4 or 5 options and set correct_answer to every correct letter as a sorted comma list, such as "A,C".
+For true/false questions, the answer should be "true" or "false".
+For short answer questions, provide a concise correct answer, separating equally valid phrasings with | (e.g. "USA|United States").
 
-	return questions, nil
+Always include an explanation for each question, and rate its difficulty as "easy", "medium" or "hard".
+
+The text is split into pages marked [Page N]. Set source_ref to the page or pages each question is based on, such as "p. 3" or "pp. 3-4".
+
+Respond with a JSON array in this exact format:
+[
+  {
+    "question": "Question text here?",
+    "type": "multiple_choice",
+    "options": ["Option 1", "Option 2", "Option 3", "Option 4"],
+    "correct_answer": "A",
+    "explanation": "Explanation here",
+    "source_ref": "p. 1",
+    "difficulty": "medium"
+  }
+]
+
+Text to analyze:
+%s`, numQuestions, typesStr, level, text)
+
+	return prompt
 }
 
-// buildPrompt creates the prompt for question generation
-func (c *Client) buildPrompt(text string, numQuestions int, questionTypes []string) string {
+// buildSimilarPrompt creates the prompt for generating questions on the same
+// topics as a set of existing questions
+func buildSimilarPrompt(existing []string, numQuestions int, questionTypes []string) string {
 	typesStr := strings.Join(questionTypes, ", ")
 
-	prompt := fmt.Sprintf(`Based on the following text, generate %d test questions. Use these question types: %s.
+	var list strings.Builder
+	for i, question := range existing {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, question)
+	}
+
+	prompt := fmt.Sprintf(`The following questions come from an existing test. Generate %d new test questions covering the same topics. Use these question types: %s.
+
+Do not repeat or lightly reword any of the existing questions; ask about the same material from a different angle.
 
 For multiple choice questions, provide 4 options (A, B, C, D).
+For multi_select questions, provide 4 or 5 options and set correct_answer to every correct letter as a sorted comma list, such as "A,C".
 For true/false questions, the answer should be "true" or "false".
-For short answer questions, provide a concise correct answer.
+For short answer questions, provide a concise correct answer, separating equally valid phrasings with | (e.g. "USA|United States").
 
-Always include an explanation for each question.
+Always include an explanation for each question, and rate its difficulty as "easy", "medium" or "hard".
 
 Respond with a JSON array in this exact format:
 [
@@ -135,34 +280,58 @@ Respond with a JSON array in this exact format:
     "type": "multiple_choice",
     "options": ["Option 1", "Option 2", "Option 3", "Option 4"],
     "correct_answer": "A",
-    "explanation": "Explanation here"
+    "explanation": "Explanation here",
+    "difficulty": "medium"
   }
 ]
 
-Text to analyze:
-%s`, numQuestions, typesStr, text)
+Existing questions:
+%s`, numQuestions, typesStr, list.String())
 
 	return prompt
 }
 
+// dedupeQuestions drops generated questions whose text matches an existing
+// question or an earlier generated one, ignoring case, spacing and punctuation
+func dedupeQuestions(questions []*GeneratedQuestion, existing []string) []*GeneratedQuestion {
+	seen := make(map[string]bool)
+	for _, question := range existing {
+		seen[questionKey(question)] = true
+	}
+
+	var unique []*GeneratedQuestion
+	for _, q := range questions {
+		key := questionKey(q.Question)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, q)
+	}
+	return unique
+}
+
+// questionKey reduces question text to lowercase letters and digits for comparison
+func questionKey(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
 // makeRequest makes an HTTP request to the ChatGPT API
-func (c *Client) makeRequest(request ChatRequest) (*ChatResponse, error) {
+func (c *Client) makeRequest(ctx context.Context, request ChatRequest) (*ChatResponse, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	resp, err := c.postCompletion(ctx, c.httpClient, jsonData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -171,10 +340,6 @@ func (c *Client) makeRequest(request ChatRequest) (*ChatResponse, error) {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var response ChatResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
@@ -183,49 +348,115 @@ func (c *Client) makeRequest(request ChatRequest) (*ChatResponse, error) {
 	return &response, nil
 }
 
-// parseQuestions parses the JSON response from ChatGPT into questions
-func (c *Client) parseQuestions(content string) ([]*GeneratedQuestion, error) {
-	// Clean the content to extract JSON
-	content = strings.TrimSpace(content)
+// parseQuestions parses the questions in a model reply. Markdown fences and
+// prose around the JSON array are ignored, and entries that are not valid JSON
+// or lack required fields are skipped instead of failing the whole reply.
+func parseQuestions(content string) ([]*GeneratedQuestion, error) {
+	var splitter objectSplitter
+	var questions []*GeneratedQuestion
+	skipped := 0
+	for i, object := range splitter.feed(extractJSONArray(content)) {
+		question, err := decodeQuestion(i+1, object)
+		if err != nil {
+			skipped++
+			continue
+		}
+		questions = append(questions, question)
+	}
 
-	// Debug: Print the raw content to understand what we're receiving
-	fmt.Printf("Raw ChatGPT response:\n%s\n\n", content)
+	if len(questions) == 0 {
+		if skipped > 0 {
+			return nil, fmt.Errorf("none of the %d questions in the response were valid", skipped)
+		}
+		return nil, fmt.Errorf("no questions found in response")
+	}
+	return questions, nil
+}
 
-	// Find JSON array in the response
-	startIdx := strings.Index(content, "[")
-	endIdx := strings.LastIndex(content, "]")
+// extractJSONArray trims a reply down to its JSON array, dropping ```json
+// fences and any prose before or after it. A reply cut off before the closing
+// bracket keeps everything from the opening one; its last bracket may close
+// the options of a complete question rather than the array.
+func extractJSONArray(content string) string {
+	start := strings.Index(content, "[")
+	if start == -1 {
+		return content
+	}
+	content = content[start:]
+	if end := strings.LastIndex(content, "]"); end != -1 && end > strings.LastIndex(content, "}") {
+		content = content[:end+1]
+	}
+	return content
+}
 
-	if startIdx == -1 || endIdx == -1 || startIdx >= endIdx {
-		return nil, fmt.Errorf("no valid JSON array found in response. Content: %s", content)
+// decodeQuestion decodes and validates the nth question object of a reply
+func decodeQuestion(n int, object string) (*GeneratedQuestion, error) {
+	var question GeneratedQuestion
+	if err := json.Unmarshal([]byte(object), &question); err != nil {
+		return nil, fmt.Errorf("failed to parse question %d: %w", n, err)
+	}
+	if err := validateQuestion(n, &question); err != nil {
+		return nil, err
 	}
+	return &question, nil
+}
 
-	jsonStr := content[startIdx : endIdx+1]
-	fmt.Printf("Extracted JSON:\n%s\n\n", jsonStr)
+// questionTypes are the question types a generated question can have
+var questionTypes = map[string]bool{
+	"multiple_choice": true,
+	"multi_select":    true,
+	"true_false":      true,
+	"short_answer":    true,
+	"fill_blank":      true,
+}
 
-	// Check if the JSON string is empty or incomplete
-	if len(jsonStr) <= 2 {
-		return nil, fmt.Errorf("extracted JSON is too short: %s", jsonStr)
+// validateQuestion checks that the nth generated question has its text, a
+// known type and its answer, and options to choose from when it is multiple
+// choice or select all. The answer of a select all question must list letters
+// of its options. A missing type defaults to short answer and a difficulty
+// that is not easy, medium or hard is dropped.
+func validateQuestion(n int, q *GeneratedQuestion) error {
+	if strings.TrimSpace(q.Question) == "" {
+		return fmt.Errorf("question %d is missing question text", n)
 	}
-
-	var questions []*GeneratedQuestion
-	if err := json.Unmarshal([]byte(jsonStr), &questions); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w. JSON content: %s", err, jsonStr)
+	if strings.TrimSpace(q.CorrectAnswer) == "" {
+		return fmt.Errorf("question %d is missing correct answer", n)
+	}
+	if q.Type == "" {
+		q.Type = "short_answer" // Default type
+	}
+	if !questionTypes[q.Type] {
+		return fmt.Errorf("question %d has unknown type %q", n, q.Type)
 	}
+	if (q.Type == "multiple_choice" || q.Type == "multi_select") && len(q.Options) < 2 {
+		return fmt.Errorf("question %d has too few options", n)
+	}
+	if q.Type == "multi_select" && !validSelectionLetters(q.Options, q.CorrectAnswer) {
+		return fmt.Errorf("question %d answer %q is not a list of option letters", n, q.CorrectAnswer)
+	}
+	switch q.Difficulty = strings.ToLower(strings.TrimSpace(q.Difficulty)); q.Difficulty {
+	case "easy", "medium", "hard":
+	default:
+		q.Difficulty = ""
+	}
+	return nil
+}
 
-	// Validate questions
-	for i, q := range questions {
-		if q.Question == "" {
-			return nil, fmt.Errorf("question %d is missing question text", i+1)
+// validSelectionLetters reports whether answer is a comma separated list of
+// letters that each name one of the options, e.g. "A,C"
+func validSelectionLetters(options []string, answer string) bool {
+	found := false
+	for _, part := range strings.Split(answer, ",") {
+		letter := strings.ToUpper(strings.TrimSpace(part))
+		if letter == "" {
+			continue
 		}
-		if q.CorrectAnswer == "" {
-			return nil, fmt.Errorf("question %d is missing correct answer", i+1)
-		}
-		if q.Type == "" {
-			q.Type = "short_answer" // Default type
+		if len(letter) != 1 || letter[0] < 'A' || int(letter[0]-'A') >= len(options) {
+			return false
 		}
+		found = true
 	}
-
-	return questions, nil
+	return found
 }
 
 // TestConnection tests the connection to ChatGPT API
@@ -235,7 +466,7 @@ func (c *Client) TestConnection() error {
 	}
 
 	request := ChatRequest{
-		Model: "gpt-3.5-turbo",
+		Model: c.model,
 		Messages: []Message{
 			{
 				Role:    "user",
@@ -245,6 +476,6 @@ func (c *Client) TestConnection() error {
 		MaxTokens: 50,
 	}
 
-	_, err := c.makeRequest(request)
+	_, err := c.makeRequest(context.Background(), request)
 	return err
 }
@@ -0,0 +1,172 @@
+package chatgpt
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseQuestionsFencedJSON(t *testing.T) {
+	content := "```json\n" + `[
+  {"question": "Capital of France?", "type": "multiple_choice", "options": ["Paris", "Rome"], "correct_answer": "A", "explanation": "It is Paris."},
+  {"question": "The sky is blue.", "type": "true_false", "correct_answer": "true", "explanation": ""}
+]` + "\n```"
+
+	questions, err := parseQuestions(content)
+	if err != nil {
+		t.Fatalf("parseQuestions: %v", err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(questions))
+	}
+	if questions[0].Question != "Capital of France?" || questions[0].CorrectAnswer != "A" || len(questions[0].Options) != 2 {
+		t.Errorf("first question = %+v", questions[0])
+	}
+	if questions[1].Type != "true_false" {
+		t.Errorf("second question type = %q, want true_false", questions[1].Type)
+	}
+}
+
+func TestParseQuestionsLeadingProse(t *testing.T) {
+	content := `Sure! Here are the questions you asked for:
+
+[{"question": "What is 2+2?", "correct_answer": "4", "explanation": "Addition."}]
+
+Let me know if you need more.`
+
+	questions, err := parseQuestions(content)
+	if err != nil {
+		t.Fatalf("parseQuestions: %v", err)
+	}
+	if len(questions) != 1 || questions[0].Question != "What is 2+2?" {
+		t.Fatalf("got %+v", questions)
+	}
+	if questions[0].Type != "short_answer" {
+		t.Errorf("missing type defaulted to %q, want short_answer", questions[0].Type)
+	}
+}
+
+func TestParseQuestionsPartiallyBrokenArray(t *testing.T) {
+	content := `[
+  {"question": "Valid one", "type": "short_answer", "correct_answer": "yes"},
+  {"question": "Missing answer", "type": "short_answer"},
+  {"question": "Bad value", "correct_answer": 42},
+  {"question": "Unknown type", "type": "essay", "correct_answer": "x"},
+  {"question": "Select all with one option", "type": "multi_select", "options": ["A"], "correct_answer": "A"},
+  {"question": "Select all with a bad letter", "type": "multi_select", "options": ["One", "Two"], "correct_answer": "A,Z"},
+  {"question": "Valid select all", "type": "multi_select", "options": ["One", "Two", "Three"], "correct_answer": "a, c"},
+  {"question": "Cut off", "type": "short_ans`
+
+	questions, err := parseQuestions(content)
+	if err != nil {
+		t.Fatalf("parseQuestions: %v", err)
+	}
+	var texts []string
+	for _, q := range questions {
+		texts = append(texts, q.Question)
+	}
+	if len(texts) != 2 || texts[0] != "Valid one" || texts[1] != "Valid select all" {
+		t.Fatalf("kept %q, want the two valid questions", texts)
+	}
+}
+
+func TestParseQuestionsNoneValid(t *testing.T) {
+	if _, err := parseQuestions(`[{"question": "No answer"}]`); err == nil {
+		t.Error("expected an error when no question is valid")
+	}
+	if _, err := parseQuestions("I can't help with that."); err == nil {
+		t.Error("expected an error when the reply has no questions")
+	}
+}
+
+func TestValidateQuestionDifficulty(t *testing.T) {
+	q := &GeneratedQuestion{Question: "Q", CorrectAnswer: "A", Difficulty: " Hard "}
+	if err := validateQuestion(1, q); err != nil {
+		t.Fatalf("validateQuestion: %v", err)
+	}
+	if q.Difficulty != "hard" {
+		t.Errorf("difficulty = %q, want hard", q.Difficulty)
+	}
+
+	q = &GeneratedQuestion{Question: "Q", CorrectAnswer: "A", Difficulty: "impossible"}
+	if err := validateQuestion(1, q); err != nil {
+		t.Fatalf("validateQuestion: %v", err)
+	}
+	if q.Difficulty != "" {
+		t.Errorf("unknown difficulty kept as %q", q.Difficulty)
+	}
+}
+
+func TestGenerateQuestionsDifficulty(t *testing.T) {
+	var body string
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		io.WriteString(w, completionReply)
+	})
+
+	questions, _, err := c.GenerateQuestions("Arithmetic", 1, nil, "hard")
+	if err != nil {
+		t.Fatalf("GenerateQuestions: %v", err)
+	}
+	if !strings.Contains(body, "Make every question hard difficulty.") {
+		t.Errorf("request does not ask for hard questions:\n%s", body)
+	}
+	if len(questions) != 1 || questions[0].Difficulty != "hard" {
+		t.Errorf("questions %+v, want one rated hard", questions)
+	}
+}
+
+func TestNewClientModel(t *testing.T) {
+	for _, tt := range []struct{ model, want string }{
+		{"gpt-4o-mini", "gpt-4o-mini"},
+		{"", DefaultModel},
+	} {
+		var body string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			io.WriteString(w, completionReply)
+		}))
+		defer server.Close()
+
+		c := NewClient("test-key", tt.model)
+		c.baseURL = server.URL
+		if got := c.Model(); got != tt.want {
+			t.Errorf("NewClient(%q) uses model %q, want %q", tt.model, got, tt.want)
+		}
+		if _, _, err := c.GenerateQuestions("Arithmetic", 1, nil, ""); err != nil {
+			t.Fatalf("GenerateQuestions: %v", err)
+		}
+		if !strings.Contains(body, `"model":"`+tt.want+`"`) {
+			t.Errorf("request does not name model %s:\n%s", tt.want, body)
+		}
+	}
+}
+
+func TestGenerateQuestionsUsage(t *testing.T) {
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, completionReply)
+	})
+	_, usage, err := c.GenerateQuestions("Arithmetic", 1, nil, "")
+	if err != nil {
+		t.Fatalf("GenerateQuestions: %v", err)
+	}
+	if usage == nil || *usage != (Usage{Model: "gpt-test", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}) {
+		t.Errorf("usage = %+v, want the reported 10+5 tokens of gpt-test", usage)
+	}
+
+	// A reply without usage leaves it nil
+	c = newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Replace(completionReply, `"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}`, `"usage": null`, 1))
+	})
+	_, usage, err = c.GenerateQuestions("Arithmetic", 1, nil, "")
+	if err != nil {
+		t.Fatalf("GenerateQuestions without usage: %v", err)
+	}
+	if usage != nil {
+		t.Errorf("usage = %+v for a reply without it, want nil", usage)
+	}
+}
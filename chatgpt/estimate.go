@@ -0,0 +1,30 @@
+package chatgpt
+
+import "unicode/utf8"
+
+// tokensPerQuestion is roughly how many completion tokens one generated
+// question takes, counting its options, answer and explanation
+const tokensPerQuestion = 150
+
+// EstimateTokens roughly estimates how many tokens text takes, at about four
+// characters per token as for typical English text
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// EstimateUsage estimates the tokens a GenerateQuestions call would use before
+// it is made: the prompt of every chunk the text is split into, and a typical
+// reply length for each question
+func EstimateUsage(text string, numQuestions int, questionTypes []string, difficulty string) Usage {
+	var usage Usage
+	chunks := splitText(text, chunkSize, chunkOverlap)
+	for i, count := range shareQuestions(chunks, numQuestions) {
+		if count == 0 {
+			continue
+		}
+		usage.PromptTokens += EstimateTokens(systemPrompt) + EstimateTokens(buildPrompt(chunks[i], count, questionTypes, difficulty))
+		usage.CompletionTokens += count * tokensPerQuestion
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
@@ -0,0 +1,43 @@
+package chatgpt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"Cells are the basic unit of life.", 9},
+		{"héllo", 2}, // Counted in characters, not bytes
+		{strings.Repeat("a", 4000), 1000},
+	} {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateUsage(t *testing.T) {
+	text := strings.Repeat("Cells are the basic unit of life. ", 20)
+
+	usage := EstimateUsage(text, 3, []string{"short_answer"}, "")
+	if usage.CompletionTokens != 3*tokensPerQuestion {
+		t.Errorf("completion tokens = %d, want %d", usage.CompletionTokens, 3*tokensPerQuestion)
+	}
+	if usage.PromptTokens <= EstimateTokens(text) {
+		t.Errorf("prompt tokens = %d, want more than the %d of the text alone", usage.PromptTokens, EstimateTokens(text))
+	}
+	if usage.TotalTokens != usage.PromptTokens+usage.CompletionTokens {
+		t.Errorf("total tokens = %d, want %d", usage.TotalTokens, usage.PromptTokens+usage.CompletionTokens)
+	}
+
+	if none := EstimateUsage(text, 0, []string{"short_answer"}, ""); none.TotalTokens != 0 {
+		t.Errorf("no questions estimated at %d tokens, want 0", none.TotalTokens)
+	}
+}
@@ -0,0 +1,64 @@
+package chatgpt
+
+import "context"
+
+// QuestionGenerator is a backend that writes test questions with a language
+// model. Client talks to the OpenAI API and OllamaClient to a local Ollama server.
+type QuestionGenerator interface {
+	// Enabled reports whether the backend is configured and can make requests
+	Enabled() bool
+	// Model returns the model the backend sends requests to
+	Model() string
+	// GenerateQuestions generates questions from text. difficulty ("easy",
+	// "medium" or "hard") asks for questions of that level, or is empty to let
+	// the model rate each one. Usage is nil when the backend reports no token counts.
+	GenerateQuestions(text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error)
+	// GenerateQuestionsContext is GenerateQuestions bound to ctx, so the
+	// request is abandoned when ctx is cancelled or times out
+	GenerateQuestionsContext(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error)
+	// StreamQuestions generates questions like GenerateQuestions, sending each
+	// on the returned channel as soon as it is available
+	StreamQuestions(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) <-chan StreamEvent
+	// GenerateSimilarQuestions generates new questions on the same topics as
+	// existing ones, giving up when ctx is cancelled or times out
+	GenerateSimilarQuestions(ctx context.Context, existing []string, numQuestions int, questionTypes []string) ([]*GeneratedQuestion, *Usage, error)
+}
+
+var (
+	_ QuestionGenerator = (*Client)(nil)
+	_ QuestionGenerator = (*OllamaClient)(nil)
+)
+
+// StreamGenerated adapts a generation that only returns complete replies to a
+// stream like StreamQuestions: generate runs in the background and its
+// questions are sent one by one once it returns. Cancelling ctx stops sending.
+func StreamGenerated(ctx context.Context, generate func(ctx context.Context) ([]*GeneratedQuestion, *Usage, error)) <-chan StreamEvent {
+	events := make(chan StreamEvent)
+
+	go func() {
+		defer close(events)
+
+		send := func(event StreamEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		questions, usage, err := generate(ctx)
+		if err != nil {
+			send(StreamEvent{Usage: usage, Err: err, Done: true})
+			return
+		}
+		for _, question := range questions {
+			if !send(StreamEvent{Question: question}) {
+				return
+			}
+		}
+		send(StreamEvent{Usage: usage, Done: true})
+	}()
+
+	return events
+}
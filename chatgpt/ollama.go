@@ -0,0 +1,157 @@
+package chatgpt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Defaults for a local Ollama server
+const (
+	DefaultOllamaURL   = "http://localhost:11434"
+	DefaultOllamaModel = "llama3"
+)
+
+// OllamaClient generates questions with a model served by Ollama, for use
+// offline or without an OpenAI account
+type OllamaClient struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// ollamaRequest is the body of a request to Ollama's /api/generate endpoint
+type ollamaRequest struct {
+	Model  string `json:"model"`
+	System string `json:"system,omitempty"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+// ollamaResponse is a complete, non-streamed reply from /api/generate
+type ollamaResponse struct {
+	Response string `json:"response"`
+}
+
+// NewOllamaClient creates a client for the Ollama server at baseURL using the
+// given model. Empty values fall back to DefaultOllamaURL and DefaultOllamaModel.
+func NewOllamaClient(baseURL, model string) *OllamaClient {
+	if baseURL == "" {
+		baseURL = DefaultOllamaURL
+	}
+	if model == "" {
+		model = DefaultOllamaModel
+	}
+	return &OllamaClient{
+		baseURL: baseURL,
+		model:   model,
+		// Local models can take several minutes to write a full test
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Enabled reports whether the client can make requests. A local server needs
+// no key, so this is always true; an unreachable server fails the request.
+func (o *OllamaClient) Enabled() bool {
+	return true
+}
+
+// Model returns the Ollama model the client sends requests to
+func (o *OllamaClient) Model() string {
+	return o.model
+}
+
+// GenerateQuestions generates test questions from the provided text, in chunks
+// when it is too long for one request. Usage is always nil: local generation
+// has no API cost to track.
+func (o *OllamaClient) GenerateQuestions(text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error) {
+	return o.GenerateQuestionsContext(context.Background(), text, numQuestions, questionTypes, difficulty)
+}
+
+// StreamQuestions generates questions like GenerateQuestions. The reply is
+// requested in one piece and its questions are then sent one by one.
+func (o *OllamaClient) StreamQuestions(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) <-chan StreamEvent {
+	return StreamGenerated(ctx, func(ctx context.Context) ([]*GeneratedQuestion, *Usage, error) {
+		return o.GenerateQuestionsContext(ctx, text, numQuestions, questionTypes, difficulty)
+	})
+}
+
+// GenerateSimilarQuestions generates new questions covering the same topics as
+// existing ones. Generated questions that repeat an existing question are dropped.
+func (o *OllamaClient) GenerateSimilarQuestions(ctx context.Context, existing []string, numQuestions int, questionTypes []string) ([]*GeneratedQuestion, *Usage, error) {
+	if len(existing) == 0 {
+		return nil, nil, fmt.Errorf("at least one existing question is required")
+	}
+
+	questions, err := o.requestQuestions(ctx, buildSimilarPrompt(existing, numQuestions, questionTypes))
+	if err != nil {
+		return nil, nil, err
+	}
+	return dedupeQuestions(questions, existing), nil, nil
+}
+
+// GenerateQuestionsContext generates questions like GenerateQuestions, giving
+// up as soon as ctx is cancelled or its deadline passes
+func (o *OllamaClient) GenerateQuestionsContext(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) ([]*GeneratedQuestion, *Usage, error) {
+	return generateChunked(ctx, text, numQuestions, func(ctx context.Context, chunk string, numQuestions int) ([]*GeneratedQuestion, *Usage, error) {
+		questions, err := o.requestQuestions(ctx, buildPrompt(chunk, numQuestions, questionTypes, difficulty))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if difficulty != "" {
+			for _, q := range questions {
+				q.Difficulty = difficulty
+			}
+		}
+		return questions, nil, nil
+	})
+}
+
+// requestQuestions sends a question generation prompt and parses the reply
+func (o *OllamaClient) requestQuestions(ctx context.Context, prompt string) ([]*GeneratedQuestion, error) {
+	jsonData, err := json.Marshal(ollamaRequest{
+		Model:  o.model,
+		System: systemPrompt,
+		Prompt: prompt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Ollama at %s: %w", o.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response ollamaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	questions, err := parseQuestions(response.Response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse questions: %w", err)
+	}
+	return questions, nil
+}
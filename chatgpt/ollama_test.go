@@ -0,0 +1,55 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaGenerateQuestions(t *testing.T) {
+	var request ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/generate" {
+			t.Errorf("request to %s %s, want POST /api/generate", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		json.NewEncoder(w).Encode(ollamaResponse{
+			Response: `[{"question": "What is 2+2?", "type": "short_answer", "correct_answer": "4"}]`,
+		})
+	}))
+	defer server.Close()
+
+	o := NewOllamaClient(server.URL, "")
+	questions, usage, err := o.GenerateQuestions("Arithmetic", 1, []string{"short_answer"}, "easy")
+	if err != nil {
+		t.Fatalf("GenerateQuestions: %v", err)
+	}
+	if request.Model != DefaultOllamaModel || request.Stream {
+		t.Errorf("requested model %q with stream %v, want %s without streaming", request.Model, request.Stream, DefaultOllamaModel)
+	}
+	if !strings.Contains(request.Prompt, "Arithmetic") || !strings.Contains(request.Prompt, "easy difficulty") {
+		t.Errorf("prompt does not hold the text and difficulty:\n%s", request.Prompt)
+	}
+	if len(questions) != 1 || questions[0].Question != "What is 2+2?" || questions[0].Difficulty != "easy" {
+		t.Errorf("questions %+v, want What is 2+2? rated easy", questions)
+	}
+	if usage != nil {
+		t.Errorf("usage %+v, want nil for a local model", usage)
+	}
+}
+
+func TestOllamaRequestFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, _, err := NewOllamaClient(server.URL, "missing").GenerateQuestions("Arithmetic", 1, nil, "")
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("error = %v, want the failed status", err)
+	}
+}
@@ -0,0 +1,82 @@
+package chatgpt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// maxAttempts is how many times a chat completion request is sent before
+// giving up on rate limits, server errors and timeouts
+const maxAttempts = 3
+
+// postCompletion sends a chat completion request, retrying with exponential
+// backoff and jitter when the API answers 429 or 5xx or the request times out.
+// Other failures are returned at once, and no retry waits past ctx's deadline.
+// On success the response body is left open for the caller to read and close.
+func (c *Client) postCompletion(ctx context.Context, client *http.Client, jsonData []byte) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		var retryable bool
+		resp, err := client.Do(req)
+		switch {
+		case err != nil:
+			retryable = isTimeout(err) && ctx.Err() == nil
+			err = fmt.Errorf("failed to make HTTP request: %w", err)
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		default:
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+			err = fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		}
+
+		if !retryable {
+			return nil, err
+		}
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		delay := c.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, fmt.Errorf("no time left to retry after %d attempts: %w", attempt, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped retrying after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// backoff returns how long to wait before retrying after the given attempt:
+// the base delay doubled for each attempt so far, plus up to half of that again
+// so clients hitting the same rate limit do not retry in lockstep
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryDelay << (attempt - 1)
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// isTimeout reports whether a request failed because it timed out
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
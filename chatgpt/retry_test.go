@@ -0,0 +1,118 @@
+package chatgpt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// completionReply is a chat completion whose message holds one question
+const completionReply = `{"model": "gpt-test", "choices": [{"message": {"role": "assistant", "content": ` +
+	`"[{\"question\": \"What is 2+2?\", \"type\": \"short_answer\", \"correct_answer\": \"4\"}]"}, "finish_reason": "stop"}],` +
+	`"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`
+
+// newStubClient returns a client that sends its requests to handler, retrying
+// without a noticeable wait
+func newStubClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient("test-key", "")
+	c.baseURL = server.URL
+	c.retryDelay = time.Millisecond
+	return c
+}
+
+func TestPostCompletionRetries(t *testing.T) {
+	var requests atomic.Int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case 2:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		default:
+			var request ChatRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+				http.Error(w, "bad request body", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(completionReply))
+		}
+	})
+
+	questions, usage, err := c.GenerateQuestions("Arithmetic", 1, []string{"short_answer"}, "")
+	if err != nil {
+		t.Fatalf("GenerateQuestions: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("%d requests sent, want 3", n)
+	}
+	if len(questions) != 1 || questions[0].CorrectAnswer != "4" {
+		t.Errorf("questions = %+v", questions)
+	}
+	if usage == nil || usage.TotalTokens != 15 || usage.Model != "gpt-test" {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestPostCompletionGivesUp(t *testing.T) {
+	var requests atomic.Int32
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "down", http.StatusInternalServerError)
+	})
+	if _, _, err := c.GenerateQuestions("Arithmetic", 1, nil, ""); err == nil {
+		t.Fatal("GenerateQuestions succeeded against a failing server")
+	}
+	if n := requests.Load(); n != maxAttempts {
+		t.Errorf("%d requests sent, want %d", n, maxAttempts)
+	}
+
+	// Client errors are not retried
+	requests.Store(0)
+	c = newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	})
+	if _, _, err := c.GenerateQuestions("Arithmetic", 1, nil, ""); err == nil {
+		t.Fatal("GenerateQuestions succeeded with a rejected key")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests sent for a 401, want 1", n)
+	}
+}
+
+func TestGenerateQuestionsContextCancel(t *testing.T) {
+	c := newStubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client goes away, which the server only notices once
+		// the request body has been read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, err := c.GenerateQuestionsContext(ctx, "Arithmetic", 1, nil, "")
+	if err == nil {
+		t.Fatal("GenerateQuestionsContext succeeded after being cancelled")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want it to wrap context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled call took %s to return", elapsed)
+	}
+}
@@ -0,0 +1,226 @@
+package chatgpt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StreamEvent is delivered for each question of a streamed generation as soon
+// as it is complete, and once more with Done set when the stream ends
+type StreamEvent struct {
+	Question *GeneratedQuestion
+	Usage    *Usage // Set on the final event when the API reported token counts
+	Err      error
+	Done     bool
+}
+
+// streamChunk is one server-sent event of a streamed chat completion
+type streamChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// StreamQuestions generates questions like GenerateQuestions, but sends each
+// question on the returned channel as soon as the model has written it. Text
+// too long for one request is streamed chunk by chunk, dropping questions that
+// repeat one already sent. Cancelling ctx stops the request; the channel is
+// closed when the stream ends.
+func (c *Client) StreamQuestions(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) <-chan StreamEvent {
+	events := make(chan StreamEvent)
+
+	chunks := splitText(text, chunkSize, chunkOverlap)
+	var requests []ChatRequest
+	for i, count := range shareQuestions(chunks, numQuestions) {
+		if count == 0 {
+			continue
+		}
+		request := c.questionRequest(buildPrompt(chunks[i], count, questionTypes, difficulty))
+		request.Stream = true
+		request.StreamOptions = &StreamOptions{IncludeUsage: true}
+		requests = append(requests, request)
+	}
+
+	go c.streamQuestions(ctx, requests, difficulty, events)
+	return events
+}
+
+// streamQuestions sends each request in turn and splits the streamed content
+// into questions, storing the requested difficulty on each when one was given
+func (c *Client) streamQuestions(ctx context.Context, requests []ChatRequest, difficulty string, events chan<- StreamEvent) {
+	defer close(events)
+
+	send := func(event StreamEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if c.apiKey == "" {
+		send(StreamEvent{Err: fmt.Errorf("API key is required"), Done: true})
+		return
+	}
+
+	var usage *Usage
+	seen := make(map[string]bool)
+	count, skipped := 0, 0
+	for _, request := range requests {
+		requestUsage, err := c.streamRequest(ctx, request, func(object string) bool {
+			// A malformed question is skipped so the rest of the stream is kept
+			question, err := decodeQuestion(count+skipped+1, object)
+			if err != nil {
+				skipped++
+				return true
+			}
+			// Overlapping chunks can produce the same question twice
+			key := questionKey(question.Question)
+			if seen[key] {
+				return true
+			}
+			seen[key] = true
+			count++
+			if difficulty != "" {
+				question.Difficulty = difficulty
+			}
+			return send(StreamEvent{Question: question})
+		})
+		usage = addUsage(usage, requestUsage)
+		if err != nil {
+			send(StreamEvent{Usage: usage, Err: err, Done: true})
+			return
+		}
+	}
+
+	if count == 0 {
+		err := fmt.Errorf("no questions found in response")
+		if skipped > 0 {
+			err = fmt.Errorf("none of the %d questions in the response were valid", skipped)
+		}
+		send(StreamEvent{Usage: usage, Err: err, Done: true})
+		return
+	}
+
+	send(StreamEvent{Usage: usage, Done: true})
+}
+
+// streamRequest streams one chat completion, passing each JSON object in its
+// content to emit as soon as it is complete. It stops early with ctx's error
+// when emit returns false.
+func (c *Client) streamRequest(ctx context.Context, request ChatRequest, emit func(object string) bool) (*Usage, error) {
+	body, err := c.openStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var usage *Usage
+	var splitter objectSplitter
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return usage, fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+			usage.Model = chunk.Model
+		}
+
+		for _, choice := range chunk.Choices {
+			for _, object := range splitter.feed(choice.Delta.Content) {
+				if !emit(object) {
+					return usage, ctx.Err()
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return usage, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return usage, nil
+}
+
+// openStream starts a streamed chat completion and returns its event stream
+func (c *Client) openStream(ctx context.Context, request ChatRequest) (io.ReadCloser, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// The client timeout covers reading the whole body, which a long stream
+	// can exceed; the stream is stopped through ctx instead
+	client := *c.httpClient
+	client.Timeout = 0
+
+	resp, err := c.postCompletion(ctx, &client, jsonData)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// objectSplitter collects streamed text and returns each top-level JSON
+// object once its closing brace has arrived
+type objectSplitter struct {
+	buf      strings.Builder
+	depth    int
+	inString bool
+	escaped  bool
+}
+
+// feed adds streamed text and returns the objects it completed
+func (s *objectSplitter) feed(text string) []string {
+	var objects []string
+	for _, r := range text {
+		if s.depth > 0 {
+			s.buf.WriteRune(r)
+		}
+
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case s.inString:
+			if r == '\\' {
+				s.escaped = true
+			} else if r == '"' {
+				s.inString = false
+			}
+		case r == '"' && s.depth > 0:
+			s.inString = true
+		case r == '{':
+			if s.depth == 0 {
+				s.buf.Reset()
+				s.buf.WriteRune(r)
+			}
+			s.depth++
+		case r == '}' && s.depth > 0:
+			s.depth--
+			if s.depth == 0 {
+				objects = append(objects, s.buf.String())
+			}
+		}
+	}
+	return objects
+}
@@ -1,9 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -12,6 +18,7 @@ import (
 // DB represents the database connection
 type DB struct {
 	*sql.DB
+	Path string // File the database was opened from
 }
 
 // Test represents a practice test
@@ -19,6 +26,8 @@ type Test struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	SourcePath  string    `json:"source_path"` // PDF the test was generated from, empty for custom tests
+	TimeLimit   int       `json:"time_limit"`  // in seconds, 0 for no limit
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -28,10 +37,12 @@ type Question struct {
 	ID            int      `json:"id"`
 	TestID        int      `json:"test_id"`
 	QuestionText  string   `json:"question_text"`
-	QuestionType  string   `json:"question_type"` // "multiple_choice", "true_false", "short_answer"
-	Options       []string `json:"options"`        // For multiple choice questions
+	QuestionType  string   `json:"question_type"` // "multiple_choice", "true_false", "short_answer", "multi_select", "fill_blank"
+	Options       []string `json:"options"`        // For multiple choice and multi select questions
 	CorrectAnswer string   `json:"correct_answer"`
 	Explanation   string   `json:"explanation"`
+	SourceRef     string   `json:"source_ref"` // Source pages the question was generated from, e.g. "p. 3"
+	Difficulty    string   `json:"difficulty"` // "easy", "medium", "hard" or empty when not rated
 	CreatedAt     time.Time `json:"created_at"`
 }
 
@@ -39,25 +50,57 @@ type Question struct {
 type TestResult struct {
 	ID          int       `json:"id"`
 	TestID      int       `json:"test_id"`
-	Score       float64   `json:"score"`
+	Score       float64   `json:"score"` // Percentage of correct answers, 0-100
 	TotalQuestions int    `json:"total_questions"`
 	CorrectAnswers int    `json:"correct_answers"`
 	TimeTaken   int       `json:"time_taken"` // in seconds
 	CompletedAt time.Time `json:"completed_at"`
+	Note        string    `json:"note"`
 }
 
 // QuestionAnswer represents a user's answer to a question
 type QuestionAnswer struct {
-	ID           int    `json:"id"`
-	ResultID     int    `json:"result_id"`
-	QuestionID   int    `json:"question_id"`
-	UserAnswer   string `json:"user_answer"`
-	IsCorrect    bool   `json:"is_correct"`
+	ID         int       `json:"id"`
+	ResultID   int       `json:"result_id"`
+	QuestionID int       `json:"question_id"`
+	UserAnswer string    `json:"user_answer"`
+	IsCorrect  bool      `json:"is_correct"`
+	AnsweredAt time.Time `json:"answered_at"` // Completion time of the attempt the answer belongs to
+}
+
+// AttemptAnswer is the answer to one question of a finished attempt, as
+// recorded by RecordAttempt
+type AttemptAnswer struct {
+	QuestionID int
+	UserAnswer string // In stored option order
+	IsCorrect  bool
+	Answered   bool // Whether the question was answered, so it is rescheduled for review
+	TimeSpent  int  // Seconds spent on the question
+}
+
+// UsageTotals represents accumulated API token usage
+type UsageTotals struct {
+	Generations      int `json:"generations"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// TestAttempt is the saved progress of an unfinished attempt at a test
+type TestAttempt struct {
+	TestID          int            `json:"test_id"`
+	CurrentQuestion int            `json:"current_question"` // Index of the question the attempt was on
+	QuestionIDs     []int          `json:"question_ids"`     // Questions of the attempt in the order taken; empty if saved before they were recorded
+	Answers         map[int]string `json:"answers"`          // By question ID, in stored option order
+	StartedAt       time.Time      `json:"started_at"`
+	UpdatedAt       time.Time      `json:"updated_at"` // When the progress was last saved
 }
 
 // NewDB creates a new database connection and initializes tables
 func NewDB(dbPath string) (*DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	// Foreign keys are enforced per connection, so enable them through the DSN
+	// for every pooled connection rather than with a one-off PRAGMA
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -66,7 +109,7 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	dbWrapper := &DB{db}
+	dbWrapper := &DB{DB: db, Path: dbPath}
 	if err := dbWrapper.createTables(); err != nil {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
@@ -81,20 +124,12 @@ func (db *DB) createTables() error {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			name TEXT NOT NULL,
 			description TEXT,
+			source_path TEXT,
+			time_limit INTEGER NOT NULL DEFAULT 0, -- in seconds, 0 for no limit
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
-		`CREATE TABLE IF NOT EXISTS questions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			test_id INTEGER NOT NULL,
-			question_text TEXT NOT NULL,
-			question_type TEXT NOT NULL CHECK(question_type IN ('multiple_choice', 'true_false', 'short_answer')),
-			options TEXT, -- JSON array for multiple choice options
-			correct_answer TEXT NOT NULL,
-			explanation TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
-		)`,
+		questionsTableSQL("questions"),
 		`CREATE TABLE IF NOT EXISTS test_results (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			test_id INTEGER NOT NULL,
@@ -103,6 +138,7 @@ func (db *DB) createTables() error {
 			correct_answers INTEGER NOT NULL,
 			time_taken INTEGER NOT NULL, -- in seconds
 			completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			note TEXT,
 			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
 		)`,
 		`CREATE TABLE IF NOT EXISTS question_answers (
@@ -111,9 +147,65 @@ func (db *DB) createTables() error {
 			question_id INTEGER NOT NULL,
 			user_answer TEXT NOT NULL,
 			is_correct BOOLEAN NOT NULL,
+			time_spent INTEGER NOT NULL DEFAULT 0, -- in seconds, over every visit to the question
 			FOREIGN KEY (result_id) REFERENCES test_results(id) ON DELETE CASCADE,
 			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS usage (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			model TEXT,
+			prompt_tokens INTEGER NOT NULL,
+			completion_tokens INTEGER NOT NULL,
+			total_tokens INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS study_list (
+			question_id INTEGER PRIMARY KEY,
+			added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS test_attempts (
+			test_id INTEGER PRIMARY KEY,
+			current_question INTEGER NOT NULL,
+			question_ids TEXT NOT NULL DEFAULT '[]', -- JSON array of the attempt's question IDs, in order
+			answers TEXT NOT NULL, -- JSON object of question ID to answer
+			started_at DATETIME NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE -- Trimmed and lowercased, see normalizeTag
+		)`,
+		`CREATE TABLE IF NOT EXISTS test_tags (
+			test_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (test_id, tag_id),
+			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS review (
+			question_id INTEGER PRIMARY KEY,
+			ease REAL NOT NULL,
+			interval_days INTEGER NOT NULL,
+			repetitions INTEGER NOT NULL, -- Correct answers in a row
+			due_date TEXT NOT NULL, -- YYYY-MM-DD in local time
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS text_cache (
+			path TEXT NOT NULL,
+			page_start INTEGER NOT NULL, -- 0 with page_end 0 for the whole document
+			page_end INTEGER NOT NULL,
+			mod_time INTEGER NOT NULL, -- Unix nanoseconds
+			size INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (path, page_start, page_end)
+		)`,
 	}
 
 	for _, query := range queries {
@@ -122,13 +214,192 @@ func (db *DB) createTables() error {
 		}
 	}
 
+	// Bring databases created with an older schema up to date
+	if err := db.migrate(); err != nil {
+		return err
+	}
+
+	if err := db.upgradeQuestionTypes(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table if it is not already present
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	columns, err := db.tableColumns(table)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range columns {
+		if name == column {
+			return nil
+		}
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s to %s: %w", column, table, err)
+	}
+	return nil
+}
+
+// tableColumns returns the column names of a table
+func (db *DB) tableColumns(table string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// Difficulties lists the difficulty levels a question can be rated with, easiest first
+var Difficulties = []string{"easy", "medium", "hard"}
+
+// QuestionTypes lists the question types accepted by the questions table
+var QuestionTypes = []string{"multiple_choice", "true_false", "short_answer", "multi_select", "fill_blank"}
+
+// questionsTableSQL returns the statement creating the questions table under the given name
+func questionsTableSQL(name string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			test_id INTEGER NOT NULL,
+			question_text TEXT NOT NULL,
+			question_type TEXT NOT NULL CHECK(question_type IN ('%s')),
+			options TEXT, -- JSON array for multiple choice options
+			correct_answer TEXT NOT NULL,
+			explanation TEXT,
+			source_ref TEXT, -- Source pages of generated questions, e.g. "p. 3"
+			difficulty TEXT, -- "easy", "medium" or "hard", NULL when not rated
+			position INTEGER NOT NULL DEFAULT 0, -- Display order within the test
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
+		)`, name, strings.Join(QuestionTypes, "', '"))
+}
+
+// upgradeQuestionTypes rebuilds the questions table when its CHECK constraint
+// predates a question type. SQLite cannot alter constraints in place, so the
+// rows are copied into a table with the current schema. Foreign keys are turned
+// off on the connection doing the rebuild so dropping the old table does not
+// cascade to answers and starred questions.
+func (db *DB) upgradeQuestionTypes() error {
+	var tableSQL string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'questions'`).Scan(&tableSQL)
+	if err != nil {
+		return fmt.Errorf("failed to read questions schema: %w", err)
+	}
+
+	upToDate := true
+	for _, qType := range QuestionTypes {
+		if !strings.Contains(tableSQL, "'"+qType+"'") {
+			upToDate = false
+			break
+		}
+	}
+	if upToDate {
+		return nil
+	}
+
+	columns, err := db.tableColumns("questions")
+	if err != nil {
+		return err
+	}
+	columnList := strings.Join(columns, ", ")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// The pragma has no effect inside a transaction, so set it first
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		questionsTableSQL("questions_new"),
+		fmt.Sprintf("INSERT INTO questions_new (%s) SELECT %s FROM questions", columnList, columnList),
+		"DROP TABLE questions",
+		"ALTER TABLE questions_new RENAME TO questions",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("failed to upgrade questions table: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-// CreateTest creates a new test
-func (db *DB) CreateTest(name, description string) (*Test, error) {
-	query := `INSERT INTO tests (name, description) VALUES (?, ?)`
-	result, err := db.Exec(query, name, description)
+// Errors returned when a test name can't be used
+var (
+	ErrEmptyName     = errors.New("test name cannot be empty")
+	ErrDuplicateName = errors.New("a test with this name already exists")
+)
+
+// CheckTestName reports whether a new test could be named name, returning
+// ErrEmptyName or ErrDuplicateName when it could not
+func (db *DB) CheckTestName(name string) error {
+	_, err := db.checkTestName(name, 0)
+	return err
+}
+
+// checkTestName trims name and checks that it is not empty and that no other
+// test has it, ignoring case. excludeID is the test being renamed, or 0.
+func (db *DB) checkTestName(name string, excludeID int) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrEmptyName
+	}
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM tests WHERE name = ? COLLATE NOCASE AND id != ?`, name, excludeID).Scan(&count)
+	if err != nil {
+		return "", fmt.Errorf("failed to check test name: %w", err)
+	}
+	if count > 0 {
+		return "", fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	return name, nil
+}
+
+// CreateTest creates a new test. sourcePath records the PDF the test was
+// generated from and is empty for custom tests. timeLimit is in seconds, 0 for no limit.
+// The name must be unique; ErrEmptyName or ErrDuplicateName is returned otherwise.
+func (db *DB) CreateTest(name, description, sourcePath string, timeLimit int) (*Test, error) {
+	name, err := db.checkTestName(name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO tests (name, description, source_path, time_limit) VALUES (?, ?, ?, ?)`
+	result, err := db.Exec(query, name, description, sourcePath, timeLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create test: %w", err)
 	}
@@ -141,13 +412,16 @@ func (db *DB) CreateTest(name, description string) (*Test, error) {
 	return db.GetTest(int(id))
 }
 
+// testColumns is the column list used when selecting tests
+const testColumns = `id, name, COALESCE(description, ''), COALESCE(source_path, ''), time_limit, created_at, updated_at`
+
 // GetTest retrieves a test by ID
 func (db *DB) GetTest(id int) (*Test, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM tests WHERE id = ?`
+	query := `SELECT ` + testColumns + ` FROM tests WHERE id = ?`
 	row := db.QueryRow(query, id)
 
 	var test Test
-	err := row.Scan(&test.ID, &test.Name, &test.Description, &test.CreatedAt, &test.UpdatedAt)
+	err := row.Scan(&test.ID, &test.Name, &test.Description, &test.SourcePath, &test.TimeLimit, &test.CreatedAt, &test.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test: %w", err)
 	}
@@ -157,7 +431,7 @@ func (db *DB) GetTest(id int) (*Test, error) {
 
 // GetAllTests retrieves all tests
 func (db *DB) GetAllTests() ([]*Test, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM tests ORDER BY created_at DESC`
+	query := `SELECT ` + testColumns + ` FROM tests ORDER BY created_at DESC`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tests: %w", err)
@@ -167,7 +441,7 @@ func (db *DB) GetAllTests() ([]*Test, error) {
 	var tests []*Test
 	for rows.Next() {
 		var test Test
-		err := rows.Scan(&test.ID, &test.Name, &test.Description, &test.CreatedAt, &test.UpdatedAt)
+		err := rows.Scan(&test.ID, &test.Name, &test.Description, &test.SourcePath, &test.TimeLimit, &test.CreatedAt, &test.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan test: %w", err)
 		}
@@ -177,21 +451,43 @@ func (db *DB) GetAllTests() ([]*Test, error) {
 	return tests, nil
 }
 
-// CreateQuestion creates a new question for a test
-func (db *DB) CreateQuestion(testID int, questionText, questionType, correctAnswer, explanation string, options []string) (*Question, error) {
-	// Convert options to JSON string if provided
-	var optionsJSON string
-	if len(options) > 0 {
-		// Simple JSON encoding for options
-		optionsJSON = "[\"" + options[0]
-		for i := 1; i < len(options); i++ {
-			optionsJSON += "\",\"" + options[i]
-		}
-		optionsJSON += "\"]"
+// UpdateTest renames a test and replaces its description
+func (db *DB) UpdateTest(id int, name, description string) (*Test, error) {
+	name, err := db.checkTestName(name, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE tests SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := db.Exec(query, name, description, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update test: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("test %d not found", id)
+	}
+
+	return db.GetTest(id)
+}
+
+// CreateQuestion creates a new question for a test, after its existing
+// questions. sourceRef records the source pages of a generated question and is
+// empty for other questions. difficulty is one of Difficulties, or empty when
+// the question is not rated.
+func (db *DB) CreateQuestion(testID int, questionText, questionType, correctAnswer, explanation string, options []string, sourceRef, difficulty string) (*Question, error) {
+	optionsJSON, err := encodeOptions(options)
+	if err != nil {
+		return nil, err
 	}
 
-	query := `INSERT INTO questions (test_id, question_text, question_type, options, correct_answer, explanation) VALUES (?, ?, ?, ?, ?, ?)`
-	result, err := db.Exec(query, testID, questionText, questionType, optionsJSON, correctAnswer, explanation)
+	query := `INSERT INTO questions (test_id, question_text, question_type, options, correct_answer, explanation, source_ref, difficulty, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM questions WHERE test_id = ?))`
+	result, err := db.Exec(query, testID, questionText, questionType, optionsJSON, correctAnswer, explanation, sourceRef, difficulty, testID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create question: %w", err)
 	}
@@ -204,14 +500,28 @@ func (db *DB) CreateQuestion(testID int, questionText, questionType, correctAnsw
 	return db.GetQuestion(int(id))
 }
 
+// encodeOptions converts question options to the JSON string stored in the
+// database. Questions without options store an empty string.
+func encodeOptions(options []string) (string, error) {
+	if len(options) == 0 {
+		return "", nil
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode options: %w", err)
+	}
+	return string(optionsJSON), nil
+}
+
 // GetQuestion retrieves a question by ID
 func (db *DB) GetQuestion(id int) (*Question, error) {
-	query := `SELECT id, test_id, question_text, question_type, options, correct_answer, explanation, created_at FROM questions WHERE id = ?`
+	query := `SELECT id, test_id, question_text, question_type, options, correct_answer, explanation, COALESCE(source_ref, ''), COALESCE(difficulty, ''), created_at FROM questions WHERE id = ?`
 	row := db.QueryRow(query, id)
 
 	var question Question
 	var optionsJSON string
-	err := row.Scan(&question.ID, &question.TestID, &question.QuestionText, &question.QuestionType, &optionsJSON, &question.CorrectAnswer, &question.Explanation, &question.CreatedAt)
+	err := row.Scan(&question.ID, &question.TestID, &question.QuestionText, &question.QuestionType, &optionsJSON, &question.CorrectAnswer, &question.Explanation, &question.SourceRef, &question.Difficulty, &question.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get question: %w", err)
 	}
@@ -227,10 +537,32 @@ func (db *DB) GetQuestion(id int) (*Question, error) {
 	return &question, nil
 }
 
+// CountQuestions returns the number of questions in a test without loading them
+func (db *DB) CountQuestions(testID int) (int, error) {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM questions WHERE test_id = ?`, testID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count questions: %w", err)
+	}
+	return count, nil
+}
+
 // GetQuestionsByTestID retrieves all questions for a test
 func (db *DB) GetQuestionsByTestID(testID int) ([]*Question, error) {
-	query := `SELECT id, test_id, question_text, question_type, options, correct_answer, explanation, created_at FROM questions WHERE test_id = ? ORDER BY id`
-	rows, err := db.Query(query, testID)
+	query := `SELECT id, test_id, question_text, question_type, options, correct_answer, explanation, COALESCE(source_ref, ''), COALESCE(difficulty, ''), created_at FROM questions WHERE test_id = ? ORDER BY position, id`
+	return db.queryQuestions(query, testID)
+}
+
+// GetQuestionsByDifficulty retrieves the questions of a test rated with the
+// given difficulty level, e.g. "hard"
+func (db *DB) GetQuestionsByDifficulty(testID int, level string) ([]*Question, error) {
+	query := `SELECT id, test_id, question_text, question_type, options, correct_answer, explanation, COALESCE(source_ref, ''), COALESCE(difficulty, ''), created_at FROM questions WHERE test_id = ? AND difficulty = ? ORDER BY position, id`
+	return db.queryQuestions(query, testID, level)
+}
+
+// queryQuestions runs a query selecting question columns in the order used by
+// GetQuestionsByTestID and returns the questions it finds
+func (db *DB) queryQuestions(query string, args ...interface{}) ([]*Question, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get questions: %w", err)
 	}
@@ -240,7 +572,7 @@ func (db *DB) GetQuestionsByTestID(testID int) ([]*Question, error) {
 	for rows.Next() {
 		var question Question
 		var optionsJSON string
-		err := rows.Scan(&question.ID, &question.TestID, &question.QuestionText, &question.QuestionType, &optionsJSON, &question.CorrectAnswer, &question.Explanation, &question.CreatedAt)
+		err := rows.Scan(&question.ID, &question.TestID, &question.QuestionText, &question.QuestionType, &optionsJSON, &question.CorrectAnswer, &question.Explanation, &question.SourceRef, &question.Difficulty, &question.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan question: %w", err)
 		}
@@ -259,6 +591,31 @@ func (db *DB) GetQuestionsByTestID(testID int) ([]*Question, error) {
 	return questions, nil
 }
 
+// UpdateQuestion replaces the text, type, answer, explanation, options and
+// difficulty of an existing question and returns the updated question
+func (db *DB) UpdateQuestion(id int, text, qType, correctAnswer, explanation string, options []string, difficulty string) (*Question, error) {
+	optionsJSON, err := encodeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE questions SET question_text = ?, question_type = ?, options = ?, correct_answer = ?, explanation = ?, difficulty = ? WHERE id = ?`
+	result, err := db.Exec(query, text, qType, optionsJSON, correctAnswer, explanation, difficulty, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update question: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("question %d not found", id)
+	}
+
+	return db.GetQuestion(id)
+}
+
 // SaveTestResult saves a test result
 func (db *DB) SaveTestResult(testID int, score float64, totalQuestions, correctAnswers, timeTaken int) (*TestResult, error) {
 	query := `INSERT INTO test_results (test_id, score, total_questions, correct_answers, time_taken) VALUES (?, ?, ?, ?, ?)`
@@ -285,7 +642,7 @@ func (db *DB) SaveTestResult(testID int, score float64, totalQuestions, correctA
 
 // GetTestResults retrieves all results for a test
 func (db *DB) GetTestResults(testID int) ([]*TestResult, error) {
-	query := `SELECT id, test_id, score, total_questions, correct_answers, time_taken, completed_at FROM test_results WHERE test_id = ? ORDER BY completed_at DESC`
+	query := `SELECT id, test_id, score, total_questions, correct_answers, time_taken, completed_at, COALESCE(note, '') FROM test_results WHERE test_id = ? ORDER BY completed_at DESC`
 	rows, err := db.Query(query, testID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test results: %w", err)
@@ -295,7 +652,7 @@ func (db *DB) GetTestResults(testID int) ([]*TestResult, error) {
 	var results []*TestResult
 	for rows.Next() {
 		var result TestResult
-		err := rows.Scan(&result.ID, &result.TestID, &result.Score, &result.TotalQuestions, &result.CorrectAnswers, &result.TimeTaken, &result.CompletedAt)
+		err := rows.Scan(&result.ID, &result.TestID, &result.Score, &result.TotalQuestions, &result.CorrectAnswers, &result.TimeTaken, &result.CompletedAt, &result.Note)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan test result: %w", err)
 		}
@@ -305,16 +662,49 @@ func (db *DB) GetTestResults(testID int) ([]*TestResult, error) {
 	return results, nil
 }
 
+// ExportResultsCSV writes all results of a test to w as CSV: a header row,
+// then one row per result, newest first
+func (db *DB) ExportResultsCSV(testID int, w io.Writer) error {
+	results, err := db.GetTestResults(testID)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"score", "total", "correct", "time_taken", "completed_at"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, result := range results {
+		record := []string{
+			strconv.FormatFloat(result.Score, 'f', 1, 64),
+			strconv.Itoa(result.TotalQuestions),
+			strconv.Itoa(result.CorrectAnswers),
+			strconv.Itoa(result.TimeTaken),
+			result.CompletedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
 // TestResultWithName represents a test result with test name
 type TestResultWithName struct {
 	ID             int       `json:"id"`
 	TestID         int       `json:"test_id"`
 	TestName       string    `json:"test_name"`
-	Score          float64   `json:"score"`
+	Score          float64   `json:"score"` // Percentage of correct answers, 0-100
 	TotalQuestions int       `json:"total_questions"`
 	CorrectAnswers int       `json:"correct_answers"`
 	TimeTaken      int       `json:"time_taken"`
 	CompletedAt    time.Time `json:"completed_at"`
+	Note           string    `json:"note"`
 }
 
 // QuestionAnswerDetail represents a detailed question answer
@@ -323,16 +713,19 @@ type QuestionAnswerDetail struct {
 	ResultID      int    `json:"result_id"`
 	QuestionID    int    `json:"question_id"`
 	QuestionText  string `json:"question_text"`
+	QuestionType  string `json:"question_type"`
 	UserAnswer    string `json:"user_answer"`
 	CorrectAnswer string `json:"correct_answer"`
 	IsCorrect     bool   `json:"is_correct"`
 	Explanation   string `json:"explanation"`
+	SourceRef     string `json:"source_ref"`
+	TimeSpent     int    `json:"time_spent"` // in seconds
 }
 
 // GetAllTestResults returns all test results with test names
 func (db *DB) GetAllTestResults() ([]*TestResultWithName, error) {
 	rows, err := db.Query(`
-		SELECT tr.id, tr.test_id, t.name, tr.score, tr.total_questions, tr.correct_answers, tr.time_taken, tr.completed_at
+		SELECT tr.id, tr.test_id, t.name, tr.score, tr.total_questions, tr.correct_answers, tr.time_taken, tr.completed_at, COALESCE(tr.note, '')
 		FROM test_results tr
 		JOIN tests t ON tr.test_id = t.id
 		ORDER BY tr.completed_at DESC
@@ -345,7 +738,7 @@ func (db *DB) GetAllTestResults() ([]*TestResultWithName, error) {
 	var results []*TestResultWithName
 	for rows.Next() {
 		result := &TestResultWithName{}
-		err := rows.Scan(&result.ID, &result.TestID, &result.TestName, &result.Score, &result.TotalQuestions, &result.CorrectAnswers, &result.TimeTaken, &result.CompletedAt)
+		err := rows.Scan(&result.ID, &result.TestID, &result.TestName, &result.Score, &result.TotalQuestions, &result.CorrectAnswers, &result.TimeTaken, &result.CompletedAt, &result.Note)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan test result: %w", err)
 		}
@@ -354,10 +747,28 @@ func (db *DB) GetAllTestResults() ([]*TestResultWithName, error) {
 	return results, nil
 }
 
+// UpdateResultNote sets the free-text note of a test result. An empty note clears it.
+func (db *DB) UpdateResultNote(resultID int, note string) error {
+	result, err := db.Exec(`UPDATE test_results SET note = ? WHERE id = ?`, note, resultID)
+	if err != nil {
+		return fmt.Errorf("failed to update result note: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("test result %d not found", resultID)
+	}
+
+	return nil
+}
+
 // GetTestResultAnswers returns detailed answers for a test result
 func (db *DB) GetTestResultAnswers(resultID int) ([]*QuestionAnswerDetail, error) {
 	rows, err := db.Query(`
-		SELECT qa.id, qa.result_id, qa.question_id, q.question_text, qa.user_answer, q.correct_answer, qa.is_correct, q.explanation
+		SELECT qa.id, qa.result_id, qa.question_id, q.question_text, q.question_type, qa.user_answer, q.correct_answer, qa.is_correct, q.explanation, COALESCE(q.source_ref, ''), qa.time_spent
 		FROM question_answers qa
 		JOIN questions q ON qa.question_id = q.id
 		WHERE qa.result_id = ?
@@ -371,7 +782,34 @@ func (db *DB) GetTestResultAnswers(resultID int) ([]*QuestionAnswerDetail, error
 	var answers []*QuestionAnswerDetail
 	for rows.Next() {
 		answer := &QuestionAnswerDetail{}
-		err := rows.Scan(&answer.ID, &answer.ResultID, &answer.QuestionID, &answer.QuestionText, &answer.UserAnswer, &answer.CorrectAnswer, &answer.IsCorrect, &answer.Explanation)
+		err := rows.Scan(&answer.ID, &answer.ResultID, &answer.QuestionID, &answer.QuestionText, &answer.QuestionType, &answer.UserAnswer, &answer.CorrectAnswer, &answer.IsCorrect, &answer.Explanation, &answer.SourceRef, &answer.TimeSpent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan question answer: %w", err)
+		}
+		answers = append(answers, answer)
+	}
+	return answers, nil
+}
+
+// GetAnswerHistory returns every recorded answer to a question across all
+// attempts of its test, oldest first
+func (db *DB) GetAnswerHistory(questionID int) ([]*QuestionAnswer, error) {
+	rows, err := db.Query(`
+		SELECT qa.id, qa.result_id, qa.question_id, qa.user_answer, qa.is_correct, tr.completed_at
+		FROM question_answers qa
+		JOIN test_results tr ON qa.result_id = tr.id
+		WHERE qa.question_id = ?
+		ORDER BY tr.completed_at, qa.id
+	`, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answer history: %w", err)
+	}
+	defer rows.Close()
+
+	var answers []*QuestionAnswer
+	for rows.Next() {
+		answer := &QuestionAnswer{}
+		err := rows.Scan(&answer.ID, &answer.ResultID, &answer.QuestionID, &answer.UserAnswer, &answer.IsCorrect, &answer.AnsweredAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan question answer: %w", err)
 		}
@@ -403,18 +841,102 @@ func (db *DB) DeleteTestResult(resultID int) error {
 	return tx.Commit()
 }
 
-// SaveQuestionAnswer saves a user's answer to a question
-func (db *DB) SaveQuestionAnswer(resultID, questionID int, userAnswer string, isCorrect bool) error {
+// DeleteResultsForTest deletes all results of a test and their answers, keeping
+// the test and its questions. It returns the number of results deleted.
+func (db *DB) DeleteResultsForTest(testID int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM question_answers WHERE result_id IN (SELECT id FROM test_results WHERE test_id = ?)`, testID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete question answers: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM test_results WHERE test_id = ?`, testID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete test results: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted results: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+// SaveQuestionAnswer saves a user's answer to a question, with the seconds
+// spent on it during the attempt
+func (db *DB) SaveQuestionAnswer(resultID, questionID int, userAnswer string, isCorrect bool, timeSpent int) error {
 	_, err := db.Exec(`
-		INSERT INTO question_answers (result_id, question_id, user_answer, is_correct)
-		VALUES (?, ?, ?, ?)
-	`, resultID, questionID, userAnswer, isCorrect)
+		INSERT INTO question_answers (result_id, question_id, user_answer, is_correct, time_spent)
+		VALUES (?, ?, ?, ?, ?)
+	`, resultID, questionID, userAnswer, isCorrect, timeSpent)
 	if err != nil {
 		return fmt.Errorf("failed to save question answer: %w", err)
 	}
 	return nil
 }
 
+// RecordAttempt saves a finished attempt at a test in one transaction: its
+// result, every answer, the review schedule of the answered questions and the
+// removal of the attempt's autosaved progress
+func (db *DB) RecordAttempt(testID int, score float64, correctAnswers, timeTaken int, answers []AttemptAnswer) (*TestResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO test_results (test_id, score, total_questions, correct_answers, time_taken) VALUES (?, ?, ?, ?, ?)`,
+		testID, score, len(answers), correctAnswers, timeTaken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save test result: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	for _, answer := range answers {
+		_, err = tx.Exec(`INSERT INTO question_answers (result_id, question_id, user_answer, is_correct, time_spent) VALUES (?, ?, ?, ?, ?)`,
+			id, answer.QuestionID, answer.UserAnswer, answer.IsCorrect, answer.TimeSpent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save question answer: %w", err)
+		}
+		if answer.Answered {
+			if err := recordReview(tx, answer.QuestionID, answer.IsCorrect); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err = tx.Exec(`DELETE FROM test_attempts WHERE test_id = ?`, testID); err != nil {
+		return nil, fmt.Errorf("failed to delete test attempt: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &TestResult{
+		ID:             int(id),
+		TestID:         testID,
+		Score:          score,
+		TotalQuestions: len(answers),
+		CorrectAnswers: correctAnswers,
+		TimeTaken:      timeTaken,
+		CompletedAt:    time.Now(),
+	}, nil
+}
+
 // DeleteTest deletes a test and all its associated data
 func (db *DB) DeleteTest(testID int) error {
 	// Start a transaction to ensure all deletions succeed or fail together
@@ -454,4 +976,605 @@ func (db *DB) DeleteTest(testID int) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// DeleteQuestion deletes a single question and its recorded answers
+func (db *DB) DeleteQuestion(questionID int) error {
+	// Start a transaction to ensure all deletions succeed or fail together
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	// Delete answers given to this question
+	_, err = tx.Exec(`DELETE FROM question_answers WHERE question_id = ?`, questionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete question answers: %w", err)
+	}
+	
+	// Delete the question itself
+	result, err := tx.Exec(`DELETE FROM questions WHERE id = ?`, questionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete question: %w", err)
+	}
+	
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("question %d not found", questionID)
+	}
+	
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return nil
+}
+
+// RecordUsage stores the token usage reported for a single generation
+func (db *DB) RecordUsage(model string, promptTokens, completionTokens, totalTokens int) error {
+	_, err := db.Exec(`
+		INSERT INTO usage (model, prompt_tokens, completion_tokens, total_tokens)
+		VALUES (?, ?, ?, ?)
+	`, model, promptTokens, completionTokens, totalTokens)
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
+
+// GetUsageTotals returns the accumulated token usage across all generations
+func (db *DB) GetUsageTotals() (*UsageTotals, error) {
+	var totals UsageTotals
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(total_tokens), 0)
+		FROM usage
+	`).Scan(&totals.Generations, &totals.PromptTokens, &totals.CompletionTokens, &totals.TotalTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage totals: %w", err)
+	}
+	return &totals, nil
+}
+
+// Stats summarizes all saved test results
+type Stats struct {
+	TestsTaken   int         `json:"tests_taken"`
+	AverageScore float64     `json:"average_score"` // Percentage, 0-100
+	TotalTime    int         `json:"total_time"`    // in seconds
+	Best         *TestStats  `json:"best"`          // Test with the highest average score, nil without results
+	Worst        *TestStats  `json:"worst"`         // Test with the lowest average score, nil without results
+	PerTest      []TestStats `json:"per_test"`      // Ordered by average score, highest first
+}
+
+// TestStats summarizes the saved results of a single test
+type TestStats struct {
+	TestID       int     `json:"test_id"`
+	TestName     string  `json:"test_name"`
+	Attempts     int     `json:"attempts"`
+	AverageScore float64 `json:"average_score"`
+	BestScore    float64 `json:"best_score"`
+}
+
+// GetStats aggregates all saved test results. An empty database gives zero
+// totals rather than an error.
+func (db *DB) GetStats() (Stats, error) {
+	var stats Stats
+	err := db.QueryRow(`
+		SELECT COUNT(*), COALESCE(AVG(score), 0), COALESCE(SUM(time_taken), 0)
+		FROM test_results
+	`).Scan(&stats.TestsTaken, &stats.AverageScore, &stats.TotalTime)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT t.id, t.name, COUNT(*), AVG(tr.score), MAX(tr.score)
+		FROM test_results tr
+		JOIN tests t ON tr.test_id = t.id
+		GROUP BY t.id
+		ORDER BY AVG(tr.score) DESC, t.name
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get per-test stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var test TestStats
+		if err := rows.Scan(&test.TestID, &test.TestName, &test.Attempts, &test.AverageScore, &test.BestScore); err != nil {
+			return Stats{}, fmt.Errorf("failed to scan test stats: %w", err)
+		}
+		stats.PerTest = append(stats.PerTest, test)
+	}
+
+	if len(stats.PerTest) > 0 {
+		stats.Best = &stats.PerTest[0]
+		stats.Worst = &stats.PerTest[len(stats.PerTest)-1]
+	}
+	return stats, nil
+}
+
+// GetSettings returns all stored settings as a key/value map
+func (db *DB) GetSettings() (map[string]string, error) {
+	rows, err := db.Query(`SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// SetSetting stores a setting, replacing any existing value
+func (db *DB) SetSetting(key, value string) error {
+	_, err := db.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to save setting: %w", err)
+	}
+	return nil
+}
+
+// ReplaceTestQuestions replaces all questions of a test while keeping the test
+// row and its result history. Per-question answers of earlier attempts refer to
+// the old questions and are removed with them, as is any unfinished attempt.
+func (db *DB) ReplaceTestQuestions(testID int, questions []*Question) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM test_attempts WHERE test_id = ?`, testID)
+	if err != nil {
+		return fmt.Errorf("failed to delete test attempt: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM question_answers WHERE question_id IN (SELECT id FROM questions WHERE test_id = ?)`, testID)
+	if err != nil {
+		return fmt.Errorf("failed to delete question answers: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM questions WHERE test_id = ?`, testID)
+	if err != nil {
+		return fmt.Errorf("failed to delete questions: %w", err)
+	}
+
+	for i, q := range questions {
+		optionsJSON, err := encodeOptions(q.Options)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO questions (test_id, question_text, question_type, options, correct_answer, explanation, source_ref, difficulty, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			testID, q.QuestionText, q.QuestionType, optionsJSON, q.CorrectAnswer, q.Explanation, q.SourceRef, q.Difficulty, i+1)
+		if err != nil {
+			return fmt.Errorf("failed to create question: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE tests SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, testID)
+	if err != nil {
+		return fmt.Errorf("failed to update test: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderQuestions puts the questions of a test in the order of orderedIDs,
+// which must list each of its questions exactly once
+func (db *DB) ReorderQuestions(testID int, orderedIDs []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM questions WHERE test_id = ?`, testID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count questions: %w", err)
+	}
+	if count != len(orderedIDs) {
+		return fmt.Errorf("expected %d question IDs, got %d", count, len(orderedIDs))
+	}
+
+	seen := make(map[int]bool, len(orderedIDs))
+	for i, id := range orderedIDs {
+		if seen[id] {
+			return fmt.Errorf("question %d is listed more than once", id)
+		}
+		seen[id] = true
+
+		result, err := tx.Exec(`UPDATE questions SET position = ? WHERE id = ? AND test_id = ?`, i+1, id, testID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder questions: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to check reordered question: %w", err)
+		} else if n == 0 {
+			return fmt.Errorf("question %d is not in test %d", id, testID)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CloneTest copies a test and all its questions into a new test named newName.
+// The copy starts with no results of its own.
+func (db *DB) CloneTest(testID int, newName string) (*Test, error) {
+	newName, err := db.checkTestName(newName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO tests (name, description, source_path, time_limit)
+		SELECT ?, description, source_path, time_limit FROM tests WHERE id = ?`, newName, testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone test: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to check cloned test: %w", err)
+	} else if n == 0 {
+		return nil, fmt.Errorf("test %d not found", testID)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO questions (test_id, question_text, question_type, options, correct_answer, explanation, source_ref, difficulty, position)
+		SELECT ?, question_text, question_type, options, correct_answer, explanation, source_ref, difficulty, position FROM questions WHERE test_id = ? ORDER BY position, id`, id, testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone questions: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return db.GetTest(int(id))
+}
+
+// normalizeTag trims and lowercases a tag so "Biology " and "biology" are the same tag
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// AddTag tags a test, creating the tag if it is new. Tagging a test with a tag
+// it already has does nothing.
+func (db *DB) AddTag(testID int, tag string) error {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return fmt.Errorf("tag cannot be empty")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO tags (name) VALUES (?)`, tag); err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+	_, err = tx.Exec(`INSERT OR IGNORE INTO test_tags (test_id, tag_id) SELECT ?, id FROM tags WHERE name = ?`, testID, tag)
+	if err != nil {
+		return fmt.Errorf("failed to tag test: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveTag removes a tag from a test. Removing a tag the test does not have does nothing.
+func (db *DB) RemoveTag(testID int, tag string) error {
+	_, err := db.Exec(`DELETE FROM test_tags WHERE test_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)`, testID, normalizeTag(tag))
+	if err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+	return nil
+}
+
+// GetTestsByTag retrieves the tests with the given tag, newest first
+func (db *DB) GetTestsByTag(tag string) ([]*Test, error) {
+	query := `SELECT ` + testColumns + ` FROM tests
+		WHERE id IN (SELECT test_id FROM test_tags JOIN tags ON tags.id = test_tags.tag_id WHERE tags.name = ?)
+		ORDER BY created_at DESC`
+	rows, err := db.Query(query, normalizeTag(tag))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tests by tag: %w", err)
+	}
+	defer rows.Close()
+
+	var tests []*Test
+	for rows.Next() {
+		var test Test
+		err := rows.Scan(&test.ID, &test.Name, &test.Description, &test.SourcePath, &test.TimeLimit, &test.CreatedAt, &test.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan test: %w", err)
+		}
+		tests = append(tests, &test)
+	}
+
+	return tests, nil
+}
+
+// GetTestTags returns the tags of every tagged test by test ID, each in alphabetical order
+func (db *DB) GetTestTags() (map[int][]string, error) {
+	rows, err := db.Query(`SELECT test_tags.test_id, tags.name FROM test_tags JOIN tags ON tags.id = test_tags.tag_id ORDER BY tags.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var testID int
+		var tag string
+		if err := rows.Scan(&testID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan test tag: %w", err)
+		}
+		tags[testID] = append(tags[testID], tag)
+	}
+	return tags, nil
+}
+
+// GetAllTags returns every tag in use by at least one test, in alphabetical order
+func (db *DB) GetAllTags() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT tags.name FROM tags JOIN test_tags ON test_tags.tag_id = tags.id ORDER BY tags.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// AddToStudyList stars a question for later study
+func (db *DB) AddToStudyList(questionID int) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO study_list (question_id) VALUES (?)`, questionID)
+	if err != nil {
+		return fmt.Errorf("failed to add question to study list: %w", err)
+	}
+	return nil
+}
+
+// RemoveFromStudyList removes a question from the study list
+func (db *DB) RemoveFromStudyList(questionID int) error {
+	_, err := db.Exec(`DELETE FROM study_list WHERE question_id = ?`, questionID)
+	if err != nil {
+		return fmt.Errorf("failed to remove question from study list: %w", err)
+	}
+	return nil
+}
+
+// GetStudyListIDs returns the IDs of all starred questions
+func (db *DB) GetStudyListIDs() (map[int]bool, error) {
+	rows, err := db.Query(`SELECT question_id FROM study_list`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study list: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan study list entry: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// GetStudyListQuestions returns all starred questions in the order they were starred
+func (db *DB) GetStudyListQuestions() ([]*Question, error) {
+	rows, err := db.Query(`
+		SELECT q.id, q.test_id, q.question_text, q.question_type, q.options, q.correct_answer, q.explanation, COALESCE(q.source_ref, ''), COALESCE(q.difficulty, ''), q.created_at
+		FROM study_list sl
+		JOIN questions q ON sl.question_id = q.id
+		ORDER BY sl.added_at, q.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study list questions: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []*Question
+	for rows.Next() {
+		var question Question
+		var optionsJSON string
+		err := rows.Scan(&question.ID, &question.TestID, &question.QuestionText, &question.QuestionType, &optionsJSON, &question.CorrectAnswer, &question.Explanation, &question.SourceRef, &question.Difficulty, &question.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan question: %w", err)
+		}
+
+		// Parse options JSON
+		if optionsJSON != "" {
+			if err := json.Unmarshal([]byte(optionsJSON), &question.Options); err != nil {
+				// Fallback to empty options if JSON parsing fails
+				question.Options = []string{}
+			}
+		}
+
+		questions = append(questions, &question)
+	}
+	return questions, nil
+}
+
+// SaveTestAttempt stores the progress of an unfinished attempt at a test,
+// replacing any attempt already saved for it. questionIDs are the questions
+// the attempt uses, in the order they are taken.
+func (db *DB) SaveTestAttempt(testID, currentQuestion int, questionIDs []int, answers map[int]string, startedAt time.Time) error {
+	if questionIDs == nil {
+		questionIDs = []int{}
+	}
+	questionIDsJSON, err := json.Marshal(questionIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode question IDs: %w", err)
+	}
+
+	answersJSON, err := json.Marshal(answers)
+	if err != nil {
+		return fmt.Errorf("failed to encode answers: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO test_attempts (test_id, current_question, question_ids, answers, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(test_id) DO UPDATE SET
+			current_question = excluded.current_question,
+			question_ids = excluded.question_ids,
+			answers = excluded.answers,
+			started_at = excluded.started_at,
+			updated_at = CURRENT_TIMESTAMP
+	`, testID, currentQuestion, string(questionIDsJSON), string(answersJSON), startedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save test attempt: %w", err)
+	}
+	return nil
+}
+
+// GetTestAttempt returns the saved progress of an unfinished attempt at a
+// test, or nil if there is none
+func (db *DB) GetTestAttempt(testID int) (*TestAttempt, error) {
+	attempt := &TestAttempt{TestID: testID}
+	var questionIDsJSON, answersJSON string
+	err := db.QueryRow(`
+		SELECT current_question, question_ids, answers, started_at, updated_at FROM test_attempts WHERE test_id = ?
+	`, testID).Scan(&attempt.CurrentQuestion, &questionIDsJSON, &answersJSON, &attempt.StartedAt, &attempt.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test attempt: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(questionIDsJSON), &attempt.QuestionIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode question IDs: %w", err)
+	}
+	if err := json.Unmarshal([]byte(answersJSON), &attempt.Answers); err != nil {
+		return nil, fmt.Errorf("failed to decode answers: %w", err)
+	}
+	return attempt, nil
+}
+
+// GetTestAttemptIDs returns the IDs of the tests with an unfinished attempt
+func (db *DB) GetTestAttemptIDs() (map[int]bool, error) {
+	rows, err := db.Query(`SELECT test_id FROM test_attempts`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test attempts: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan test attempt: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// DeleteTestAttempt removes the saved progress of a test, if any
+func (db *DB) DeleteTestAttempt(testID int) error {
+	_, err := db.Exec(`DELETE FROM test_attempts WHERE test_id = ?`, testID)
+	if err != nil {
+		return fmt.Errorf("failed to delete test attempt: %w", err)
+	}
+	return nil
+}
+
+// GetCachedText returns text extracted earlier from pages pageStart to pageEnd
+// of the file at path, if the file still has the given modification time and
+// size. Entries for an older version of the file are evicted.
+func (db *DB) GetCachedText(path string, pageStart, pageEnd int, modTime time.Time, size int64) (string, bool, error) {
+	if err := db.evictStaleText(path, modTime, size); err != nil {
+		return "", false, err
+	}
+
+	var text string
+	err := db.QueryRow(`
+		SELECT text FROM text_cache WHERE path = ? AND page_start = ? AND page_end = ?
+	`, path, pageStart, pageEnd).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get cached text: %w", err)
+	}
+	return text, true, nil
+}
+
+// SaveCachedText stores text extracted from pages pageStart to pageEnd of the
+// file at path, as it was at the given modification time and size
+func (db *DB) SaveCachedText(path string, pageStart, pageEnd int, modTime time.Time, size int64, text string) error {
+	if err := db.evictStaleText(path, modTime, size); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO text_cache (path, page_start, page_end, mod_time, size, text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(path, page_start, page_end) DO UPDATE SET
+			mod_time = excluded.mod_time,
+			size = excluded.size,
+			text = excluded.text,
+			created_at = CURRENT_TIMESTAMP
+	`, path, pageStart, pageEnd, modTime.UnixNano(), size, text)
+	if err != nil {
+		return fmt.Errorf("failed to save cached text: %w", err)
+	}
+	return nil
+}
+
+// evictStaleText removes cached text of the file at path that was extracted
+// from a version with a different modification time or size
+func (db *DB) evictStaleText(path string, modTime time.Time, size int64) error {
+	_, err := db.Exec(`
+		DELETE FROM text_cache WHERE path = ? AND (mod_time != ? OR size != ?)
+	`, path, modTime.UnixNano(), size)
+	if err != nil {
+		return fmt.Errorf("failed to evict stale cached text: %w", err)
+	}
+	return nil
+}
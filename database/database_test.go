@@ -0,0 +1,1099 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestDB opens a fresh database in a temporary directory
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createTestWithQuestions creates a test with one short answer question per text
+func createTestWithQuestions(t *testing.T, db *DB, name string, texts ...string) (*Test, []*Question) {
+	t.Helper()
+	test, err := db.CreateTest(name, "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateTest: %v", err)
+	}
+
+	var questions []*Question
+	for _, text := range texts {
+		q, err := db.CreateQuestion(test.ID, text, "short_answer", "answer", "", nil, "", "")
+		if err != nil {
+			t.Fatalf("CreateQuestion: %v", err)
+		}
+		questions = append(questions, q)
+	}
+	return test, questions
+}
+
+func TestSavedAnswersReload(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3")
+
+	result, err := db.SaveTestResult(test.ID, 66.7, 3, 2, 90)
+	if err != nil {
+		t.Fatalf("SaveTestResult: %v", err)
+	}
+
+	given := []struct {
+		answer    string
+		correct   bool
+		timeSpent int
+	}{
+		{"answer", true, 10},
+		{"wrong", false, 20},
+		{"answer", true, 30},
+	}
+	for i, g := range given {
+		if err := db.SaveQuestionAnswer(result.ID, questions[i].ID, g.answer, g.correct, g.timeSpent); err != nil {
+			t.Fatalf("SaveQuestionAnswer: %v", err)
+		}
+	}
+
+	answers, err := db.GetTestResultAnswers(result.ID)
+	if err != nil {
+		t.Fatalf("GetTestResultAnswers: %v", err)
+	}
+	if len(answers) != len(given) {
+		t.Fatalf("got %d answers, want %d", len(answers), len(given))
+	}
+	for i, answer := range answers {
+		g := given[i]
+		if answer.ResultID != result.ID || answer.QuestionID != questions[i].ID {
+			t.Errorf("answer %d belongs to result %d question %d", i, answer.ResultID, answer.QuestionID)
+		}
+		if answer.QuestionText != questions[i].QuestionText || answer.CorrectAnswer != "answer" {
+			t.Errorf("answer %d has question %q with answer %q", i, answer.QuestionText, answer.CorrectAnswer)
+		}
+		if answer.UserAnswer != g.answer || answer.IsCorrect != g.correct || answer.TimeSpent != g.timeSpent {
+			t.Errorf("answer %d = %q, %v, %ds; want %q, %v, %ds", i, answer.UserAnswer, answer.IsCorrect, answer.TimeSpent, g.answer, g.correct, g.timeSpent)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	db := newTestDB(t)
+	biology, _ := createTestWithQuestions(t, db, "Biology")
+	chemistry, _ := createTestWithQuestions(t, db, "Chemistry")
+
+	for _, tag := range []string{"Science", " science ", "exam"} {
+		if err := db.AddTag(biology.ID, tag); err != nil {
+			t.Fatalf("AddTag(%q): %v", tag, err)
+		}
+	}
+	if err := db.AddTag(chemistry.ID, "science"); err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	if err := db.AddTag(chemistry.ID, "  "); err == nil {
+		t.Error("AddTag accepted an empty tag")
+	}
+
+	tags, err := db.GetTestTags()
+	if err != nil {
+		t.Fatalf("GetTestTags: %v", err)
+	}
+	if got := tags[biology.ID]; len(got) != 2 || got[0] != "exam" || got[1] != "science" {
+		t.Errorf("biology tags = %q, want [exam science] with the duplicate ignored", got)
+	}
+
+	tagged, err := db.GetTestsByTag("EXAM")
+	if err != nil {
+		t.Fatalf("GetTestsByTag: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != biology.ID {
+		t.Errorf("tests tagged exam = %v, want only biology", tagged)
+	}
+	if tagged, _ := db.GetTestsByTag("science"); len(tagged) != 2 {
+		t.Errorf("%d tests tagged science, want 2", len(tagged))
+	}
+
+	if err := db.RemoveTag(biology.ID, "Science"); err != nil {
+		t.Fatalf("RemoveTag: %v", err)
+	}
+	if err := db.RemoveTag(biology.ID, "never added"); err != nil {
+		t.Fatalf("RemoveTag of a missing tag: %v", err)
+	}
+	tagged, err = db.GetTestsByTag("science")
+	if err != nil {
+		t.Fatalf("GetTestsByTag: %v", err)
+	}
+	if len(tagged) != 1 || tagged[0].ID != chemistry.ID {
+		t.Errorf("tests tagged science after removal = %v, want only chemistry", tagged)
+	}
+
+	all, err := db.GetAllTags()
+	if err != nil {
+		t.Fatalf("GetAllTags: %v", err)
+	}
+	if len(all) != 2 || all[0] != "exam" || all[1] != "science" {
+		t.Errorf("all tags = %q, want [exam science]", all)
+	}
+}
+
+func TestRecordAttempt(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3")
+	if err := db.SaveTestAttempt(test.ID, 1, nil, map[int]string{questions[0].ID: "answer"}, time.Now()); err != nil {
+		t.Fatalf("SaveTestAttempt: %v", err)
+	}
+
+	answers := []AttemptAnswer{
+		{QuestionID: questions[0].ID, UserAnswer: "answer", IsCorrect: true, Answered: true, TimeSpent: 5},
+		{QuestionID: questions[1].ID, UserAnswer: "wrong", Answered: true, TimeSpent: 8},
+		{QuestionID: questions[2].ID},
+	}
+	result, err := db.RecordAttempt(test.ID, 33.3, 1, 13, answers)
+	if err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+	if result.TotalQuestions != 3 {
+		t.Errorf("total questions = %d, want 3", result.TotalQuestions)
+	}
+
+	saved, err := db.GetTestResultAnswers(result.ID)
+	if err != nil {
+		t.Fatalf("GetTestResultAnswers: %v", err)
+	}
+	if len(saved) != 3 || saved[1].UserAnswer != "wrong" || saved[1].TimeSpent != 8 {
+		t.Errorf("saved answers = %+v", saved)
+	}
+	for i, want := range []bool{true, true, false} {
+		review, err := db.GetReview(questions[i].ID)
+		if err != nil {
+			t.Fatalf("GetReview: %v", err)
+		}
+		if (review != nil) != want {
+			t.Errorf("question %d has review %+v, want one only if it was answered", i, review)
+		}
+	}
+	if attempt, err := db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("autosaved attempt after recording = %+v, %v; want it deleted", attempt, err)
+	}
+}
+
+func TestRecordAttemptRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1")
+	if err := db.SaveTestAttempt(test.ID, 0, nil, map[int]string{}, time.Now()); err != nil {
+		t.Fatalf("SaveTestAttempt: %v", err)
+	}
+
+	// The second answer is to a question that does not exist
+	answers := []AttemptAnswer{
+		{QuestionID: questions[0].ID, UserAnswer: "answer", IsCorrect: true, Answered: true},
+		{QuestionID: questions[0].ID + 100, UserAnswer: "answer", IsCorrect: true, Answered: true},
+	}
+	if _, err := db.RecordAttempt(test.ID, 100, 2, 10, answers); err == nil {
+		t.Fatal("RecordAttempt saved an answer to a missing question")
+	}
+
+	if results, _ := db.GetTestResults(test.ID); len(results) != 0 {
+		t.Errorf("%d results kept after the failed save", len(results))
+	}
+	if review, _ := db.GetReview(questions[0].ID); review != nil {
+		t.Errorf("review kept after the failed save: %+v", review)
+	}
+	if attempt, _ := db.GetTestAttempt(test.ID); attempt == nil {
+		t.Error("autosaved attempt deleted by the failed save")
+	}
+}
+
+func TestUpdateQuestion(t *testing.T) {
+	db := newTestDB(t)
+	test, err := db.CreateTest("Geography", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateTest: %v", err)
+	}
+	q, err := db.CreateQuestion(test.ID, "Capital of Frnace?", "multiple_choice", "A", "", []string{"Paris", "Rome"}, "Page 2", "easy")
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+
+	updates := []struct {
+		field string
+		apply func(q Question) Question
+	}{
+		{"text", func(q Question) Question { q.QuestionText = "Capital of France?"; return q }},
+		{"type", func(q Question) Question { q.QuestionType = "multi_select"; return q }},
+		{"answer", func(q Question) Question { q.CorrectAnswer = "A,B"; return q }},
+		{"explanation", func(q Question) Question { q.Explanation = "Both are capitals."; return q }},
+		{"options", func(q Question) Question { q.Options = []string{"Paris", "Rome", "Madrid"}; return q }},
+		{"difficulty", func(q Question) Question { q.Difficulty = "hard"; return q }},
+	}
+	for _, u := range updates {
+		want := u.apply(*q)
+		updated, err := db.UpdateQuestion(q.ID, want.QuestionText, want.QuestionType, want.CorrectAnswer, want.Explanation, want.Options, want.Difficulty)
+		if err != nil {
+			t.Fatalf("UpdateQuestion(%s): %v", u.field, err)
+		}
+		reloaded, err := db.GetQuestion(q.ID)
+		if err != nil {
+			t.Fatalf("GetQuestion: %v", err)
+		}
+		for _, got := range []*Question{updated, reloaded} {
+			if got.QuestionText != want.QuestionText || got.QuestionType != want.QuestionType || got.CorrectAnswer != want.CorrectAnswer ||
+				got.Explanation != want.Explanation || strings.Join(got.Options, "|") != strings.Join(want.Options, "|") || got.Difficulty != want.Difficulty {
+				t.Errorf("after updating the %s: got %+v, want %+v", u.field, got, want)
+			}
+			if got.TestID != test.ID || got.SourceRef != "Page 2" {
+				t.Errorf("updating the %s changed the test or source: %+v", u.field, got)
+			}
+		}
+		q = reloaded
+	}
+}
+
+func TestUpdateQuestionNotFound(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := db.UpdateQuestion(42, "Q", "short_answer", "A", "", nil, ""); err == nil {
+		t.Error("UpdateQuestion of a missing question succeeded")
+	}
+}
+
+// countRows returns the number of rows in table matching where
+func countRows(t *testing.T, db *DB, table, where string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		t.Fatalf("counting %s: %v", table, err)
+	}
+	return n
+}
+
+func TestDeletingTestRowCascades(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	result, err := db.SaveTestResult(test.ID, 50, 2, 1, 30)
+	if err != nil {
+		t.Fatalf("SaveTestResult: %v", err)
+	}
+	for _, q := range questions {
+		if err := db.SaveQuestionAnswer(result.ID, q.ID, "answer", true, 5); err != nil {
+			t.Fatalf("SaveQuestionAnswer: %v", err)
+		}
+	}
+
+	// Delete only the test row and leave the rest to the foreign keys
+	if _, err := db.Exec(`DELETE FROM tests WHERE id = ?`, test.ID); err != nil {
+		t.Fatalf("deleting the test row: %v", err)
+	}
+
+	if n := countRows(t, db, "questions", "test_id = ?", test.ID); n != 0 {
+		t.Errorf("%d questions left after deleting their test", n)
+	}
+	if n := countRows(t, db, "test_results", "test_id = ?", test.ID); n != 0 {
+		t.Errorf("%d results left after deleting their test", n)
+	}
+	if n := countRows(t, db, "question_answers", "result_id = ?", result.ID); n != 0 {
+		t.Errorf("%d answers left after deleting their test", n)
+	}
+}
+
+func TestOptionsRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	test, _ := createTestWithQuestions(t, db, "Quotes")
+	options := []string{`She said "hi"`, "a,b", "café", `back\slash`}
+
+	created, err := db.CreateQuestion(test.ID, "Which?", "multiple_choice", "A", "", options, "", "")
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+	q, err := db.GetQuestion(created.ID)
+	if err != nil {
+		t.Fatalf("GetQuestion: %v", err)
+	}
+	if len(q.Options) != len(options) {
+		t.Fatalf("options came back as %q, want %q", q.Options, options)
+	}
+	for i := range options {
+		if q.Options[i] != options[i] {
+			t.Errorf("option %d came back as %q, want %q", i, q.Options[i], options[i])
+		}
+	}
+
+	noOptions, err := db.CreateQuestion(test.ID, "Why?", "short_answer", "because", "", nil, "", "")
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+	var stored string
+	if err := db.QueryRow(`SELECT options FROM questions WHERE id = ?`, noOptions.ID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != "" || len(noOptions.Options) != 0 {
+		t.Errorf("a question without options stored %q and loaded %q", stored, noOptions.Options)
+	}
+}
+
+func TestDeleteQuestion(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	result, err := db.SaveTestResult(test.ID, 100, 2, 2, 30)
+	if err != nil {
+		t.Fatalf("SaveTestResult: %v", err)
+	}
+	for _, q := range questions {
+		if err := db.SaveQuestionAnswer(result.ID, q.ID, "answer", true, 5); err != nil {
+			t.Fatalf("SaveQuestionAnswer: %v", err)
+		}
+	}
+
+	if err := db.DeleteQuestion(questions[0].ID); err != nil {
+		t.Fatalf("DeleteQuestion: %v", err)
+	}
+	if err := db.DeleteQuestion(questions[0].ID); err == nil {
+		t.Error("deleting the question again succeeded")
+	}
+
+	if _, err := db.GetTest(test.ID); err != nil {
+		t.Errorf("GetTest after deleting a question: %v", err)
+	}
+	remaining, err := db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionsByTestID: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != questions[1].ID || remaining[0].QuestionText != "Q2" {
+		t.Errorf("remaining questions = %+v, want only Q2", remaining)
+	}
+	if q, err := db.GetQuestion(questions[1].ID); err != nil || q.QuestionText != "Q2" {
+		t.Errorf("GetQuestion of the other question = %+v, %v", q, err)
+	}
+
+	answers, err := db.GetTestResultAnswers(result.ID)
+	if err != nil {
+		t.Fatalf("GetTestResultAnswers: %v", err)
+	}
+	if len(answers) != 1 || answers[0].QuestionID != questions[1].ID {
+		t.Errorf("answers after deleting a question = %+v, want only the other question's", answers)
+	}
+}
+
+func TestUpdateTest(t *testing.T) {
+	db := newTestDB(t)
+	test, _ := createTestWithQuestions(t, db, "Biolgy")
+	other, _ := createTestWithQuestions(t, db, "Chemistry")
+
+	// Backdate the test so the update is visible at second resolution
+	if _, err := db.Exec(`UPDATE tests SET updated_at = '2020-01-01 00:00:00' WHERE id = ?`, test.ID); err != nil {
+		t.Fatal(err)
+	}
+	before, err := db.GetTest(test.ID)
+	if err != nil {
+		t.Fatalf("GetTest: %v", err)
+	}
+
+	updated, err := db.UpdateTest(test.ID, "  Biology ", "Cells and genetics")
+	if err != nil {
+		t.Fatalf("UpdateTest: %v", err)
+	}
+	reloaded, err := db.GetTest(test.ID)
+	if err != nil {
+		t.Fatalf("GetTest: %v", err)
+	}
+	for _, got := range []*Test{updated, reloaded} {
+		if got.Name != "Biology" || got.Description != "Cells and genetics" {
+			t.Errorf("test after update = %q, %q", got.Name, got.Description)
+		}
+		if !got.UpdatedAt.After(before.UpdatedAt) {
+			t.Errorf("updated_at %s did not move past %s", got.UpdatedAt, before.UpdatedAt)
+		}
+		if !got.CreatedAt.Equal(before.CreatedAt) {
+			t.Errorf("created_at changed from %s to %s", before.CreatedAt, got.CreatedAt)
+		}
+	}
+
+	if _, err := db.UpdateTest(test.ID, "chemistry", ""); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("renaming to another test's name: %v, want ErrDuplicateName", err)
+	}
+	if _, err := db.UpdateTest(other.ID+100, "Physics", ""); err == nil {
+		t.Error("UpdateTest of a missing test succeeded")
+	}
+}
+
+func TestTextCache(t *testing.T) {
+	db := newTestDB(t)
+	path := "/notes/biology.pdf"
+	modTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, ok, err := db.GetCachedText(path, 0, 0, modTime, 100); err != nil || ok {
+		t.Fatalf("GetCachedText before saving = %v, %v; want a miss", ok, err)
+	}
+	if err := db.SaveCachedText(path, 0, 0, modTime, 100, "whole document"); err != nil {
+		t.Fatalf("SaveCachedText: %v", err)
+	}
+	if err := db.SaveCachedText(path, 2, 3, modTime, 100, "pages two and three"); err != nil {
+		t.Fatalf("SaveCachedText: %v", err)
+	}
+
+	// Hit for the same version and page range
+	text, ok, err := db.GetCachedText(path, 2, 3, modTime, 100)
+	if err != nil || !ok || text != "pages two and three" {
+		t.Fatalf("GetCachedText = %q, %v, %v; want a hit", text, ok, err)
+	}
+	if _, ok, _ := db.GetCachedText(path, 1, 3, modTime, 100); ok {
+		t.Error("hit for a page range that was never cached")
+	}
+
+	// A new modification time misses and evicts every range of the old version
+	if _, ok, err := db.GetCachedText(path, 0, 0, modTime.Add(time.Second), 100); err != nil || ok {
+		t.Fatalf("GetCachedText after the file changed = %v, %v; want a miss", ok, err)
+	}
+	if n := countRows(t, db, "text_cache", "path = ?", path); n != 0 {
+		t.Errorf("%d stale entries left for the changed file", n)
+	}
+
+	// So does a new size
+	if err := db.SaveCachedText(path, 0, 0, modTime, 100, "whole document"); err != nil {
+		t.Fatalf("SaveCachedText: %v", err)
+	}
+	if _, ok, _ := db.GetCachedText(path, 0, 0, modTime, 120); ok {
+		t.Error("hit after the file size changed")
+	}
+}
+
+func TestTestAttempts(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	started := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	if attempt, err := db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Fatalf("GetTestAttempt before saving = %+v, %v; want nil", attempt, err)
+	}
+
+	if err := db.SaveTestAttempt(test.ID, 0, nil, map[int]string{questions[0].ID: "answer"}, started); err != nil {
+		t.Fatalf("SaveTestAttempt: %v", err)
+	}
+	// Saving again replaces the attempt
+	answers := map[int]string{questions[0].ID: "answer", questions[1].ID: "A,C"}
+	order := []int{questions[1].ID, questions[0].ID}
+	if err := db.SaveTestAttempt(test.ID, 1, order, answers, started); err != nil {
+		t.Fatalf("SaveTestAttempt: %v", err)
+	}
+
+	attempt, err := db.GetTestAttempt(test.ID)
+	if err != nil || attempt == nil {
+		t.Fatalf("GetTestAttempt = %+v, %v", attempt, err)
+	}
+	if attempt.CurrentQuestion != 1 || len(attempt.Answers) != 2 || attempt.Answers[questions[1].ID] != "A,C" {
+		t.Errorf("resumed attempt = %+v", attempt)
+	}
+	if fmt.Sprint(attempt.QuestionIDs) != fmt.Sprint(order) {
+		t.Errorf("question IDs = %v, want %v", attempt.QuestionIDs, order)
+	}
+	if !attempt.StartedAt.Equal(started) {
+		t.Errorf("started at %s, want %s", attempt.StartedAt, started)
+	}
+	if ids, err := db.GetTestAttemptIDs(); err != nil || len(ids) != 1 || !ids[test.ID] {
+		t.Errorf("GetTestAttemptIDs = %v, %v", ids, err)
+	}
+
+	if err := db.DeleteTestAttempt(test.ID); err != nil {
+		t.Fatalf("DeleteTestAttempt: %v", err)
+	}
+	if attempt, err := db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("GetTestAttempt after clearing = %+v, %v; want nil", attempt, err)
+	}
+	if ids, _ := db.GetTestAttemptIDs(); len(ids) != 0 {
+		t.Errorf("tests with attempts after clearing: %v", ids)
+	}
+}
+
+// questionTexts lists the texts of a test's questions in display order
+func questionTexts(t *testing.T, db *DB, testID int) []string {
+	t.Helper()
+	questions, err := db.GetQuestionsByTestID(testID)
+	if err != nil {
+		t.Fatalf("GetQuestionsByTestID: %v", err)
+	}
+	var texts []string
+	for _, q := range questions {
+		texts = append(texts, q.QuestionText)
+	}
+	return texts
+}
+
+func TestReorderQuestions(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Order", "first", "second", "third")
+	other, otherQuestions := createTestWithQuestions(t, db, "Other", "elsewhere")
+
+	if err := db.ReorderQuestions(test.ID, []int{questions[2].ID, questions[0].ID, questions[1].ID}); err != nil {
+		t.Fatalf("ReorderQuestions: %v", err)
+	}
+	if got := strings.Join(questionTexts(t, db, test.ID), ","); got != "third,first,second" {
+		t.Errorf("order after reordering = %s", got)
+	}
+
+	for _, ids := range [][]int{
+		{questions[0].ID, questions[1].ID},
+		{questions[0].ID, questions[0].ID, questions[1].ID},
+		{questions[0].ID, questions[1].ID, otherQuestions[0].ID},
+	} {
+		if err := db.ReorderQuestions(test.ID, ids); err == nil {
+			t.Errorf("ReorderQuestions(%v) succeeded", ids)
+		}
+	}
+	if got := strings.Join(questionTexts(t, db, test.ID), ","); got != "third,first,second" {
+		t.Errorf("a rejected reorder changed the order to %s", got)
+	}
+	if got := strings.Join(questionTexts(t, db, other.ID), ","); got != "elsewhere" {
+		t.Errorf("the other test's questions = %s", got)
+	}
+}
+
+func TestNewQuestionGoesLast(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Order", "first", "second")
+
+	// Reordered positions are 1 and 2 whatever the IDs, so a new question
+	// must take the next position rather than one based on its ID
+	if err := db.ReorderQuestions(test.ID, []int{questions[1].ID, questions[0].ID}); err != nil {
+		t.Fatalf("ReorderQuestions: %v", err)
+	}
+	if _, err := db.CreateQuestion(test.ID, "third", "short_answer", "answer", "", nil, "", ""); err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+	if got := strings.Join(questionTexts(t, db, test.ID), ","); got != "second,first,third" {
+		t.Errorf("order after adding a question = %s", got)
+	}
+
+	var position int
+	if err := db.QueryRow(`SELECT MAX(position) FROM questions WHERE test_id = ?`, test.ID).Scan(&position); err != nil {
+		t.Fatal(err)
+	}
+	if position != 3 {
+		t.Errorf("new question position = %d, want 3", position)
+	}
+
+	// Positions are counted per test
+	other, _ := createTestWithQuestions(t, db, "Other", "only")
+	if err := db.QueryRow(`SELECT position FROM questions WHERE test_id = ?`, other.ID).Scan(&position); err != nil {
+		t.Fatal(err)
+	}
+	if position != 1 {
+		t.Errorf("first question of another test has position %d, want 1", position)
+	}
+}
+
+func TestExportResultsCSV(t *testing.T) {
+	db := newTestDB(t)
+	test, _ := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3", "Q4")
+	other, _ := createTestWithQuestions(t, db, "Other", "Q1")
+	first, err := db.SaveTestResult(test.ID, 75, 4, 3, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.SaveTestResult(test.ID, 100.0/3, 3, 1, 45)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.SaveTestResult(other.ID, 0, 1, 0, 5); err != nil {
+		t.Fatal(err)
+	}
+	for id, completed := range map[int]string{first.ID: "2026-03-01 09:30:00", second.ID: "2026-03-02 18:05:00"} {
+		if _, err := db.Exec(`UPDATE test_results SET completed_at = ? WHERE id = ?`, completed, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportResultsCSV(test.ID, &buf); err != nil {
+		t.Fatalf("ExportResultsCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("the export does not parse as CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"score", "total", "correct", "time_taken", "completed_at"},
+		{"33.3", "3", "1", "45", "2026-03-02T18:05:00Z"},
+		{"75.0", "4", "3", "120", "2026-03-01T09:30:00Z"},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("exported rows\n%q\nwant\n%q", records, want)
+	}
+
+	buf.Reset()
+	if err := db.ExportResultsCSV(9999, &buf); err != nil {
+		t.Fatalf("ExportResultsCSV without results: %v", err)
+	}
+	if got := buf.String(); got != "score,total,correct,time_taken,completed_at\n" {
+		t.Errorf("export without results = %q, want only the header", got)
+	}
+}
+
+func TestCountQuestions(t *testing.T) {
+	db := newTestDB(t)
+	test, _ := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3")
+	empty, _ := createTestWithQuestions(t, db, "Empty")
+
+	for _, tt := range []struct {
+		name   string
+		testID int
+		want   int
+	}{
+		{"with questions", test.ID, 3},
+		{"without questions", empty.ID, 0},
+		{"missing test", test.ID + 100, 0},
+	} {
+		got, err := db.CountQuestions(tt.testID)
+		if err != nil {
+			t.Fatalf("%s: CountQuestions: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: CountQuestions = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUsageTotals(t *testing.T) {
+	db := newTestDB(t)
+	totals, err := db.GetUsageTotals()
+	if err != nil {
+		t.Fatalf("GetUsageTotals: %v", err)
+	}
+	if *totals != (UsageTotals{}) {
+		t.Errorf("totals without usage = %+v, want zeros", *totals)
+	}
+
+	if err := db.RecordUsage("gpt-test", 100, 50, 150); err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+	if err := db.RecordUsage("gpt-test", 200, 25, 225); err != nil {
+		t.Fatalf("RecordUsage: %v", err)
+	}
+	totals, err = db.GetUsageTotals()
+	if err != nil {
+		t.Fatalf("GetUsageTotals: %v", err)
+	}
+	if want := (UsageTotals{Generations: 2, PromptTokens: 300, CompletionTokens: 75, TotalTokens: 375}); *totals != want {
+		t.Errorf("totals = %+v, want %+v", *totals, want)
+	}
+}
+
+func TestDeleteResultsForTest(t *testing.T) {
+	db := newTestDB(t)
+	biology, biologyQuestions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	chemistry, chemistryQuestions := createTestWithQuestions(t, db, "Chemistry", "Q1")
+	record := func(testID int, questions []*Question) {
+		t.Helper()
+		var answers []AttemptAnswer
+		for _, q := range questions {
+			answers = append(answers, AttemptAnswer{QuestionID: q.ID, UserAnswer: "answer", IsCorrect: true, Answered: true})
+		}
+		if _, err := db.RecordAttempt(testID, 100, len(questions), 10, answers); err != nil {
+			t.Fatalf("RecordAttempt: %v", err)
+		}
+	}
+	record(biology.ID, biologyQuestions)
+	record(biology.ID, biologyQuestions)
+	record(chemistry.ID, chemistryQuestions)
+
+	deleted, err := db.DeleteResultsForTest(biology.ID)
+	if err != nil {
+		t.Fatalf("DeleteResultsForTest: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d results, want 2", deleted)
+	}
+	if n := countRows(t, db, "test_results", "test_id = ?", biology.ID); n != 0 {
+		t.Errorf("%d Biology results left", n)
+	}
+	if n := countRows(t, db, "question_answers", "question_id IN (?, ?)", biologyQuestions[0].ID, biologyQuestions[1].ID); n != 0 {
+		t.Errorf("%d Biology answers left", n)
+	}
+	if n := countRows(t, db, "questions", "test_id = ?", biology.ID); n != 2 {
+		t.Errorf("%d Biology questions left, want both kept", n)
+	}
+	if n := countRows(t, db, "test_results", "test_id = ?", chemistry.ID); n != 1 {
+		t.Errorf("%d Chemistry results left, want 1", n)
+	}
+	if n := countRows(t, db, "question_answers", "question_id = ?", chemistryQuestions[0].ID); n != 1 {
+		t.Errorf("%d Chemistry answers left, want 1", n)
+	}
+
+	if deleted, err := db.DeleteResultsForTest(biology.ID); err != nil || deleted != 0 {
+		t.Errorf("deleting again removed %d results (err %v), want 0", deleted, err)
+	}
+}
+
+func TestGetAnswerHistory(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	// Recorded out of date order, to check the history is ordered by date
+	for _, attempt := range []struct {
+		completed string
+		answer    string
+		correct   bool
+	}{
+		{"2026-03-03 10:00:00", "answer", true},
+		{"2026-03-01 10:00:00", "wrong", false},
+		{"2026-03-02 10:00:00", "answer", true},
+	} {
+		result, err := db.RecordAttempt(test.ID, 50, 1, 10, []AttemptAnswer{
+			{QuestionID: questions[0].ID, UserAnswer: attempt.answer, IsCorrect: attempt.correct, Answered: true},
+			{QuestionID: questions[1].ID, UserAnswer: "wrong", Answered: true},
+		})
+		if err != nil {
+			t.Fatalf("RecordAttempt: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE test_results SET completed_at = ? WHERE id = ?`, attempt.completed, result.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := db.GetAnswerHistory(questions[0].ID)
+	if err != nil {
+		t.Fatalf("GetAnswerHistory: %v", err)
+	}
+	var got []string
+	for _, answer := range history {
+		if answer.QuestionID != questions[0].ID {
+			t.Errorf("history holds an answer to question %d", answer.QuestionID)
+		}
+		got = append(got, fmt.Sprintf("%s %s %v", answer.AnsweredAt.Format("Jan 2"), answer.UserAnswer, answer.IsCorrect))
+	}
+	if want := "Mar 1 wrong false,Mar 2 answer true,Mar 3 answer true"; strings.Join(got, ",") != want {
+		t.Errorf("history = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	if history, err := db.GetAnswerHistory(questions[1].ID + 100); err != nil || len(history) != 0 {
+		t.Errorf("history of an unknown question = %v, %v; want none", history, err)
+	}
+}
+
+func TestStudyList(t *testing.T) {
+	db := newTestDB(t)
+	_, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3")
+
+	for _, q := range []*Question{questions[2], questions[0], questions[2]} {
+		if err := db.AddToStudyList(q.ID); err != nil {
+			t.Fatalf("AddToStudyList: %v", err)
+		}
+	}
+	if n := countRows(t, db, "study_list", "1=1"); n != 2 {
+		t.Errorf("%d starred questions, want 2: starring twice should be a no-op", n)
+	}
+
+	ids, err := db.GetStudyListIDs()
+	if err != nil {
+		t.Fatalf("GetStudyListIDs: %v", err)
+	}
+	if !ids[questions[0].ID] || ids[questions[1].ID] || !ids[questions[2].ID] {
+		t.Errorf("GetStudyListIDs = %v, want Q1 and Q3", ids)
+	}
+
+	starred, err := db.GetStudyListQuestions()
+	if err != nil {
+		t.Fatalf("GetStudyListQuestions: %v", err)
+	}
+	var texts []string
+	for _, q := range starred {
+		texts = append(texts, q.QuestionText)
+	}
+	if len(texts) != 2 || texts[0] != "Q1" || texts[1] != "Q3" {
+		t.Errorf("GetStudyListQuestions = %v, want [Q1 Q3]", texts)
+	}
+
+	if err := db.RemoveFromStudyList(questions[0].ID); err != nil {
+		t.Fatalf("RemoveFromStudyList: %v", err)
+	}
+	if ids, _ := db.GetStudyListIDs(); ids[questions[0].ID] {
+		t.Error("Q1 still starred after RemoveFromStudyList")
+	}
+
+	// Stars go away with the question they belong to
+	if err := db.DeleteQuestion(questions[2].ID); err != nil {
+		t.Fatalf("DeleteQuestion: %v", err)
+	}
+	if n := countRows(t, db, "study_list", "1=1"); n != 0 {
+		t.Errorf("%d starred questions left after deleting the question, want 0", n)
+	}
+}
+
+func TestUpdateResultNote(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1")
+	result, err := db.RecordAttempt(test.ID, 100, 1, 10, []AttemptAnswer{{QuestionID: questions[0].ID, UserAnswer: "answer", IsCorrect: true, Answered: true}})
+	if err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+
+	if err := db.UpdateResultNote(result.ID, "open book"); err != nil {
+		t.Fatalf("UpdateResultNote: %v", err)
+	}
+	results, err := db.GetTestResults(test.ID)
+	if err != nil {
+		t.Fatalf("GetTestResults: %v", err)
+	}
+	if len(results) != 1 || results[0].Note != "open book" {
+		t.Errorf("GetTestResults note = %+v, want \"open book\"", results)
+	}
+	all, err := db.GetAllTestResults()
+	if err != nil {
+		t.Fatalf("GetAllTestResults: %v", err)
+	}
+	if len(all) != 1 || all[0].Note != "open book" {
+		t.Errorf("GetAllTestResults note = %+v, want \"open book\"", all)
+	}
+
+	if err := db.UpdateResultNote(result.ID, ""); err != nil {
+		t.Fatalf("clearing the note: %v", err)
+	}
+	if results, _ := db.GetTestResults(test.ID); results[0].Note != "" {
+		t.Errorf("note = %q after clearing it", results[0].Note)
+	}
+
+	if err := db.UpdateResultNote(result.ID+1, "tired"); err == nil {
+		t.Error("noting a missing result succeeded")
+	}
+}
+
+func TestGetStatsEmpty(t *testing.T) {
+	db := newTestDB(t)
+	createTestWithQuestions(t, db, "Biology", "Q1")
+
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TestsTaken != 0 || stats.AverageScore != 0 || stats.TotalTime != 0 {
+		t.Errorf("stats of an empty database = %+v, want zeroes", stats)
+	}
+	if stats.Best != nil || stats.Worst != nil || len(stats.PerTest) != 0 {
+		t.Errorf("stats of an empty database list tests: %+v", stats)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	db := newTestDB(t)
+	biology, _ := createTestWithQuestions(t, db, "Biology", "Q1")
+	chemistry, _ := createTestWithQuestions(t, db, "Chemistry", "Q1")
+	createTestWithQuestions(t, db, "Physics", "Q1") // Never taken
+	for _, r := range []struct {
+		testID    int
+		score     float64
+		timeTaken int
+	}{
+		{biology.ID, 80, 60},
+		{biology.ID, 100, 40},
+		{chemistry.ID, 50, 120},
+	} {
+		if _, err := db.SaveTestResult(r.testID, r.score, 10, int(r.score/10), r.timeTaken); err != nil {
+			t.Fatalf("SaveTestResult: %v", err)
+		}
+	}
+
+	stats, err := db.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TestsTaken != 3 || stats.TotalTime != 220 {
+		t.Errorf("tests taken %d, time %d; want 3 and 220", stats.TestsTaken, stats.TotalTime)
+	}
+	if got := fmt.Sprintf("%.2f", stats.AverageScore); got != "76.67" {
+		t.Errorf("average score = %s, want 76.67", got)
+	}
+
+	want := []TestStats{
+		{TestID: biology.ID, TestName: "Biology", Attempts: 2, AverageScore: 90, BestScore: 100},
+		{TestID: chemistry.ID, TestName: "Chemistry", Attempts: 1, AverageScore: 50, BestScore: 50},
+	}
+	if len(stats.PerTest) != len(want) {
+		t.Fatalf("per-test stats = %+v, want %+v", stats.PerTest, want)
+	}
+	for i := range want {
+		if stats.PerTest[i] != want[i] {
+			t.Errorf("per-test stats %d = %+v, want %+v", i, stats.PerTest[i], want[i])
+		}
+	}
+	if stats.Best == nil || stats.Best.TestName != "Biology" || stats.Worst == nil || stats.Worst.TestName != "Chemistry" {
+		t.Errorf("best %+v, worst %+v; want Biology and Chemistry", stats.Best, stats.Worst)
+	}
+}
+
+func TestQuestionDifficulty(t *testing.T) {
+	db := newTestDB(t)
+	test, _ := createTestWithQuestions(t, db, "Biology")
+	levels := []string{"hard", "easy", "", "hard", "medium"}
+	for i, level := range levels {
+		if _, err := db.CreateQuestion(test.ID, fmt.Sprintf("Q%d", i+1), "short_answer", "answer", "", nil, "", level); err != nil {
+			t.Fatalf("CreateQuestion: %v", err)
+		}
+	}
+
+	questions, err := db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionsByTestID: %v", err)
+	}
+	for i, q := range questions {
+		if q.Difficulty != levels[i] {
+			t.Errorf("%s difficulty = %q, want %q", q.QuestionText, q.Difficulty, levels[i])
+		}
+	}
+
+	for level, want := range map[string]string{"hard": "Q1,Q4", "easy": "Q2", "medium": "Q5"} {
+		filtered, err := db.GetQuestionsByDifficulty(test.ID, level)
+		if err != nil {
+			t.Fatalf("GetQuestionsByDifficulty(%s): %v", level, err)
+		}
+		var texts []string
+		for _, q := range filtered {
+			texts = append(texts, q.QuestionText)
+		}
+		if got := strings.Join(texts, ","); got != want {
+			t.Errorf("%s questions = %s, want %s", level, got, want)
+		}
+	}
+
+	other, _ := createTestWithQuestions(t, db, "Chemistry")
+	if filtered, err := db.GetQuestionsByDifficulty(other.ID, "hard"); err != nil || len(filtered) != 0 {
+		t.Errorf("hard questions of another test = %v, %v; want none", filtered, err)
+	}
+}
+
+func TestCloneTest(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2", "Q3")
+	if _, err := db.SaveTestResult(test.ID, 100, 3, 3, 30); err != nil {
+		t.Fatalf("SaveTestResult: %v", err)
+	}
+
+	clone, err := db.CloneTest(test.ID, " Biology 2 ")
+	if err != nil {
+		t.Fatalf("CloneTest: %v", err)
+	}
+	if clone.ID == test.ID || clone.Name != "Biology 2" {
+		t.Errorf("clone = %+v, want a new test named Biology 2", clone)
+	}
+
+	cloned, err := db.GetQuestionsByTestID(clone.ID)
+	if err != nil {
+		t.Fatalf("GetQuestionsByTestID: %v", err)
+	}
+	if len(cloned) != len(questions) {
+		t.Fatalf("clone has %d questions, want %d", len(cloned), len(questions))
+	}
+	for i, q := range cloned {
+		if q.ID == questions[i].ID || q.TestID != clone.ID || q.QuestionText != questions[i].QuestionText {
+			t.Errorf("cloned question %d = %+v, want a copy of %+v", i, q, questions[i])
+		}
+	}
+	if n := countRows(t, db, "test_results", "test_id = ?", clone.ID); n != 0 {
+		t.Errorf("clone has %d results, want none copied", n)
+	}
+
+	// The copies are independent of the original questions
+	if _, err := db.UpdateQuestion(cloned[0].ID, "Changed", "short_answer", "answer", "", nil, ""); err != nil {
+		t.Fatalf("UpdateQuestion: %v", err)
+	}
+	if err := db.DeleteQuestion(cloned[1].ID); err != nil {
+		t.Fatalf("DeleteQuestion: %v", err)
+	}
+	if got := strings.Join(questionTexts(t, db, test.ID), ","); got != "Q1,Q2,Q3" {
+		t.Errorf("original questions = %s after editing the clone", got)
+	}
+
+	if _, err := db.CloneTest(test.ID, "biology 2"); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("cloning to a taken name: %v, want ErrDuplicateName", err)
+	}
+	if _, err := db.CloneTest(clone.ID+100, "Physics"); err == nil {
+		t.Error("cloning a missing test succeeded")
+	}
+}
+
+func TestTestNames(t *testing.T) {
+	db := newTestDB(t)
+	test, err := db.CreateTest("  Biology  ", "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateTest: %v", err)
+	}
+	if test.Name != "Biology" {
+		t.Errorf("name = %q, want it trimmed", test.Name)
+	}
+
+	for _, name := range []string{"Biology", "biology", " BIOLOGY "} {
+		if _, err := db.CreateTest(name, "", "", 0); !errors.Is(err, ErrDuplicateName) {
+			t.Errorf("CreateTest(%q) = %v, want ErrDuplicateName", name, err)
+		}
+		if err := db.CheckTestName(name); !errors.Is(err, ErrDuplicateName) {
+			t.Errorf("CheckTestName(%q) = %v, want ErrDuplicateName", name, err)
+		}
+	}
+	for _, name := range []string{"", "   ", "\t\n"} {
+		if _, err := db.CreateTest(name, "", "", 0); !errors.Is(err, ErrEmptyName) {
+			t.Errorf("CreateTest(%q) = %v, want ErrEmptyName", name, err)
+		}
+		if _, err := db.UpdateTest(test.ID, name, ""); !errors.Is(err, ErrEmptyName) {
+			t.Errorf("UpdateTest(%q) = %v, want ErrEmptyName", name, err)
+		}
+	}
+	if n := countRows(t, db, "tests", "1=1"); n != 1 {
+		t.Errorf("%d tests saved, want only the first", n)
+	}
+
+	// A test keeps its own name, in any case, when updated
+	if _, err := db.UpdateTest(test.ID, "BIOLOGY", ""); err != nil {
+		t.Errorf("UpdateTest to the same name: %v", err)
+	}
+	if err := db.CheckTestName("Chemistry"); err != nil {
+		t.Errorf("CheckTestName of a free name: %v", err)
+	}
+}
+
+func TestReplaceTestQuestions(t *testing.T) {
+	db := newTestDB(t)
+	test, questions := createTestWithQuestions(t, db, "Biology", "Q1", "Q2")
+	other, otherQuestions := createTestWithQuestions(t, db, "Chemistry", "C1")
+	answers := []AttemptAnswer{{QuestionID: questions[0].ID, UserAnswer: "answer", IsCorrect: true, Answered: true}, {QuestionID: questions[1].ID}}
+	if _, err := db.RecordAttempt(test.ID, 50, 1, 20, answers); err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+	for testID, q := range map[int]*Question{test.ID: questions[1], other.ID: otherQuestions[0]} {
+		if err := db.SaveTestAttempt(testID, 0, []int{q.ID}, map[int]string{q.ID: "answer"}, time.Now()); err != nil {
+			t.Fatalf("SaveTestAttempt: %v", err)
+		}
+	}
+
+	err := db.ReplaceTestQuestions(test.ID, []*Question{
+		{QuestionText: "New 1", QuestionType: "short_answer", CorrectAnswer: "answer"},
+		{QuestionText: "New 2", QuestionType: "true_false", CorrectAnswer: "true", Difficulty: "hard"},
+		{QuestionText: "New 3", QuestionType: "short_answer", CorrectAnswer: "answer"},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceTestQuestions: %v", err)
+	}
+
+	if got := strings.Join(questionTexts(t, db, test.ID), ","); got != "New 1,New 2,New 3" {
+		t.Errorf("questions = %s, want the new ones in order", got)
+	}
+	if n := countRows(t, db, "test_results", "test_id = ?", test.ID); n != 1 {
+		t.Errorf("%d results after replacing the questions, want the 1 kept", n)
+	}
+	if n := countRows(t, db, "question_answers", "1=1"); n != 0 {
+		t.Errorf("%d answers to the old questions left, want 0", n)
+	}
+
+	// An unfinished attempt of the old questions can't be resumed
+	if attempt, err := db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("attempt after replacing the questions = %+v, %v; want it deleted", attempt, err)
+	}
+	if attempt, err := db.GetTestAttempt(other.ID); err != nil || attempt == nil {
+		t.Errorf("other test's attempt = %+v, %v; want it kept", attempt, err)
+	}
+}
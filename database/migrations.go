@@ -0,0 +1,99 @@
+package database
+
+import "fmt"
+
+// migration is a schema change for databases created before it. Fresh
+// databases get the current schema from createTables, so every migration must
+// also succeed, doing nothing, on a database that already has the change.
+type migration struct {
+	version     int
+	description string
+	apply       func(db *DB) error
+}
+
+// migrations lists every schema change in the order it was made. Versions are
+// recorded in schema_migrations once applied; never renumber or remove one.
+var migrations = []migration{
+	{1, "add tests.source_path", func(db *DB) error {
+		return db.addColumnIfMissing("tests", "source_path", "TEXT")
+	}},
+	{2, "add tests.time_limit", func(db *DB) error {
+		return db.addColumnIfMissing("tests", "time_limit", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{3, "add test_results.note", func(db *DB) error {
+		return db.addColumnIfMissing("test_results", "note", "TEXT")
+	}},
+	{4, "add questions.source_ref", func(db *DB) error {
+		return db.addColumnIfMissing("questions", "source_ref", "TEXT")
+	}},
+	{5, "add questions.difficulty", func(db *DB) error {
+		return db.addColumnIfMissing("questions", "difficulty", "TEXT")
+	}},
+	{6, "add questions.position", func(db *DB) error {
+		if err := db.addColumnIfMissing("questions", "position", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+		// Keep the creation order of existing questions
+		if _, err := db.Exec(`UPDATE questions SET position = id WHERE position = 0`); err != nil {
+			return fmt.Errorf("failed to number questions: %w", err)
+		}
+		return nil
+	}},
+	{7, "add question_answers.time_spent", func(db *DB) error {
+		return db.addColumnIfMissing("question_answers", "time_spent", "INTEGER NOT NULL DEFAULT 0")
+	}},
+	{8, "add test_attempts.question_ids", func(db *DB) error {
+		return db.addColumnIfMissing("test_attempts", "question_ids", "TEXT NOT NULL DEFAULT '[]'")
+	}},
+}
+
+// migrate applies the migrations the database has not had yet, in order,
+// recording each one as it succeeds
+func (db *DB) migrate() error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := m.apply(db); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+		_, err := db.Exec(`INSERT INTO schema_migrations (version, description) VALUES (?, ?)`, m.version, m.description)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the versions of the migrations already applied
+func (db *DB) appliedMigrations() (map[int]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// oldSchema is the schema of the first release, before any migration
+var oldSchema = []string{
+	`CREATE TABLE tests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE questions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		test_id INTEGER NOT NULL,
+		question_text TEXT NOT NULL,
+		question_type TEXT NOT NULL CHECK(question_type IN ('multiple_choice', 'true_false', 'short_answer')),
+		options TEXT,
+		correct_answer TEXT NOT NULL,
+		explanation TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
+	)`,
+	`CREATE TABLE test_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		test_id INTEGER NOT NULL,
+		score REAL NOT NULL,
+		total_questions INTEGER NOT NULL,
+		correct_answers INTEGER NOT NULL,
+		time_taken INTEGER NOT NULL,
+		completed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
+	)`,
+	`CREATE TABLE question_answers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		result_id INTEGER NOT NULL,
+		question_id INTEGER NOT NULL,
+		user_answer TEXT NOT NULL,
+		is_correct BOOLEAN NOT NULL,
+		FOREIGN KEY (result_id) REFERENCES test_results(id) ON DELETE CASCADE,
+		FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+	)`,
+	`INSERT INTO tests (id, name, description) VALUES (1, 'Biology', 'Cells')`,
+	`INSERT INTO questions (id, test_id, question_text, question_type, options, correct_answer, explanation)
+		VALUES (1, 1, 'Powerhouse of the cell?', 'multiple_choice', '["Mitochondria","Nucleus"]', 'A', 'Energy')`,
+	`INSERT INTO questions (id, test_id, question_text, question_type, options, correct_answer, explanation)
+		VALUES (2, 1, 'Cells divide by?', 'short_answer', '', 'mitosis', '')`,
+	`INSERT INTO test_results (id, test_id, score, total_questions, correct_answers, time_taken) VALUES (1, 1, 50, 2, 1, 60)`,
+	`INSERT INTO question_answers (result_id, question_id, user_answer, is_correct) VALUES (1, 1, 'A', 1), (1, 2, 'meiosis', 0)`,
+}
+
+func TestMigrateOldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range oldSchema {
+		if _, err := old.Exec(query); err != nil {
+			t.Fatalf("building the old schema: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB on an old schema: %v", err)
+	}
+	defer db.Close()
+
+	if n := countRows(t, db, "schema_migrations", "1 = 1"); n != len(migrations) {
+		t.Errorf("%d migrations recorded, want %d", n, len(migrations))
+	}
+
+	test, err := db.GetTest(1)
+	if err != nil || test.Name != "Biology" || test.Description != "Cells" || test.TimeLimit != 0 {
+		t.Errorf("migrated test = %+v, %v", test, err)
+	}
+	questions, err := db.GetQuestionsByTestID(1)
+	if err != nil {
+		t.Fatalf("GetQuestionsByTestID: %v", err)
+	}
+	if len(questions) != 2 || questions[0].QuestionText != "Powerhouse of the cell?" || questions[1].CorrectAnswer != "mitosis" {
+		t.Fatalf("migrated questions = %+v", questions)
+	}
+	if len(questions[0].Options) != 2 || questions[0].Difficulty != "" || questions[0].SourceRef != "" {
+		t.Errorf("migrated question = %+v", questions[0])
+	}
+
+	results, err := db.GetTestResults(1)
+	if err != nil || len(results) != 1 || results[0].CorrectAnswers != 1 || results[0].Note != "" {
+		t.Errorf("migrated results = %+v, %v", results, err)
+	}
+	answers, err := db.GetTestResultAnswers(1)
+	if err != nil || len(answers) != 2 || answers[1].UserAnswer != "meiosis" || answers[1].TimeSpent != 0 {
+		t.Errorf("migrated answers = %+v, %v", answers, err)
+	}
+
+	// The new columns and question types work on the upgraded database
+	q, err := db.CreateQuestion(1, "Gas giants?", "multi_select", "A,B", "", []string{"Jupiter", "Saturn", "Mars"}, "p. 3", "hard")
+	if err != nil {
+		t.Fatalf("CreateQuestion after migrating: %v", err)
+	}
+	if questions, _ := db.GetQuestionsByTestID(1); len(questions) != 3 || questions[2].ID != q.ID {
+		t.Errorf("new question is not last: %+v", questions)
+	}
+
+	// Opening again applies nothing twice
+	db.Close()
+	db, err = NewDB(path)
+	if err != nil {
+		t.Fatalf("reopening the migrated database: %v", err)
+	}
+	if n := countRows(t, db, "schema_migrations", "1 = 1"); n != len(migrations) {
+		t.Errorf("%d migrations recorded after reopening, want %d", n, len(migrations))
+	}
+	if n := countRows(t, db, "questions", "test_id = 1"); n != 3 {
+		t.Errorf("%d questions after reopening, want 3", n)
+	}
+}
+
+func TestMigrateTestAttemptQuestionIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries := append(append([]string(nil), oldSchema...),
+		`CREATE TABLE test_attempts (
+			test_id INTEGER PRIMARY KEY,
+			current_question INTEGER NOT NULL,
+			answers TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (test_id) REFERENCES tests(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO test_attempts (test_id, current_question, answers, started_at) VALUES (1, 1, '{"1":"A"}', '2026-03-01 10:00:00')`,
+	)
+	for _, query := range queries {
+		if _, err := old.Exec(query); err != nil {
+			t.Fatalf("building the old schema: %v", err)
+		}
+	}
+	old.Close()
+
+	db, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB on an old schema: %v", err)
+	}
+	defer db.Close()
+
+	// Attempts saved before the questions were recorded have none
+	attempt, err := db.GetTestAttempt(1)
+	if err != nil || attempt == nil {
+		t.Fatalf("GetTestAttempt = %+v, %v", attempt, err)
+	}
+	if len(attempt.QuestionIDs) != 0 || attempt.CurrentQuestion != 1 || attempt.Answers[1] != "A" {
+		t.Errorf("migrated attempt = %+v", attempt)
+	}
+
+	if err := db.SaveTestAttempt(1, 0, []int{2, 1}, attempt.Answers, attempt.StartedAt); err != nil {
+		t.Fatalf("SaveTestAttempt after migrating: %v", err)
+	}
+	if attempt, _ := db.GetTestAttempt(1); len(attempt.QuestionIDs) != 2 || attempt.QuestionIDs[0] != 2 {
+		t.Errorf("question IDs after saving = %v, want [2 1]", attempt.QuestionIDs)
+	}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Spaced repetition follows SM-2, with a correct answer graded as a perfect
+// recall and a wrong one as a failed recall
+const (
+	defaultEase    = 2.5
+	minEase        = 1.3
+	correctQuality = 5
+	wrongQuality   = 2
+)
+
+// dueDateLayout is how review due dates are stored, so they compare as text
+const dueDateLayout = "2006-01-02"
+
+// Review is the spaced repetition schedule of a question
+type Review struct {
+	QuestionID  int
+	Ease        float64
+	Interval    int // in days
+	Repetitions int // correct answers in a row
+	DueDate     time.Time
+}
+
+// nextReview schedules a question after it was answered on day today. A wrong
+// answer starts its repetitions over and brings it back the next day; each
+// correct answer in a row spaces it out further.
+func nextReview(r Review, correct bool, today time.Time) Review {
+	quality := wrongQuality
+	if correct {
+		quality = correctQuality
+		r.Repetitions++
+		switch r.Repetitions {
+		case 1:
+			r.Interval = 1
+		case 2:
+			r.Interval = 6
+		default:
+			r.Interval = int(math.Round(float64(r.Interval) * r.Ease))
+		}
+	} else {
+		r.Repetitions = 0
+		r.Interval = 1
+	}
+
+	miss := float64(5 - quality)
+	r.Ease = math.Max(minEase, r.Ease+0.1-miss*(0.08+miss*0.02))
+	r.DueDate = today.AddDate(0, 0, r.Interval)
+	return r
+}
+
+// queryExecer runs statements on the database or inside a transaction
+type queryExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// GetReview returns the schedule of a question, or nil if it has never been
+// answered
+func (db *DB) GetReview(questionID int) (*Review, error) {
+	return getReview(db, questionID)
+}
+
+func getReview(q queryExecer, questionID int) (*Review, error) {
+	review := Review{QuestionID: questionID}
+	var dueDate string
+	err := q.QueryRow(`SELECT ease, interval_days, repetitions, due_date FROM review WHERE question_id = ?`, questionID).
+		Scan(&review.Ease, &review.Interval, &review.Repetitions, &dueDate)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	review.DueDate, err = time.ParseInLocation(dueDateLayout, dueDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse due date: %w", err)
+	}
+	return &review, nil
+}
+
+// RecordReview reschedules a question after it was answered today
+func (db *DB) RecordReview(questionID int, correct bool) error {
+	return recordReview(db, questionID, correct)
+}
+
+func recordReview(q queryExecer, questionID int, correct bool) error {
+	review, err := getReview(q, questionID)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		review = &Review{QuestionID: questionID, Ease: defaultEase}
+	}
+
+	now := time.Now()
+	next := nextReview(*review, correct, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+	_, err = q.Exec(`INSERT INTO review (question_id, ease, interval_days, repetitions, due_date) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(question_id) DO UPDATE SET ease = excluded.ease, interval_days = excluded.interval_days,
+			repetitions = excluded.repetitions, due_date = excluded.due_date`,
+		questionID, next.Ease, next.Interval, next.Repetitions, next.DueDate.Format(dueDateLayout))
+	if err != nil {
+		return fmt.Errorf("failed to record review: %w", err)
+	}
+	return nil
+}
+
+// GetDueQuestions returns the questions of every test that are due for review
+// today or overdue, the longest overdue first
+func (db *DB) GetDueQuestions() ([]*Question, error) {
+	query := `SELECT q.id, q.test_id, q.question_text, q.question_type, q.options, q.correct_answer, q.explanation, COALESCE(q.source_ref, ''), COALESCE(q.difficulty, ''), q.created_at
+		FROM review r
+		JOIN questions q ON r.question_id = q.id
+		WHERE r.due_date <= ?
+		ORDER BY r.due_date, q.test_id, q.position, q.id`
+	return db.queryQuestions(query, time.Now().Format(dueDateLayout))
+}
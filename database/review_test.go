@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextReview(t *testing.T) {
+	today := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.Local)
+	steps := []struct {
+		correct     bool
+		interval    int
+		repetitions int
+		ease        float64
+	}{
+		{true, 1, 1, 2.6},
+		{true, 6, 2, 2.7},
+		{true, 16, 3, 2.8}, // 6 days at ease 2.7
+		{false, 1, 0, 2.48},
+		{true, 1, 1, 2.58},
+	}
+
+	r := Review{QuestionID: 1, Ease: defaultEase}
+	for i, step := range steps {
+		r = nextReview(r, step.correct, today)
+		if r.Interval != step.interval || r.Repetitions != step.repetitions {
+			t.Errorf("step %d: interval %d after %d repetitions, want %d after %d", i, r.Interval, r.Repetitions, step.interval, step.repetitions)
+		}
+		if diff := r.Ease - step.ease; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("step %d: ease %.2f, want %.2f", i, r.Ease, step.ease)
+		}
+		if want := today.AddDate(0, 0, step.interval); !r.DueDate.Equal(want) {
+			t.Errorf("step %d: due %s, want %s", i, r.DueDate.Format(dueDateLayout), want.Format(dueDateLayout))
+		}
+	}
+}
+
+func TestNextReviewEaseFloor(t *testing.T) {
+	r := Review{Ease: defaultEase}
+	for i := 0; i < 10; i++ {
+		r = nextReview(r, false, time.Now())
+	}
+	if r.Ease != minEase {
+		t.Errorf("ease after repeated misses = %.2f, want the floor %.2f", r.Ease, minEase)
+	}
+}
+
+func TestRecordReview(t *testing.T) {
+	db := newTestDB(t)
+	_, questions := createTestWithQuestions(t, db, "Biology", "Q1")
+	id := questions[0].ID
+
+	if review, err := db.GetReview(id); err != nil || review != nil {
+		t.Fatalf("GetReview before any answer = %+v, %v; want nil", review, err)
+	}
+	for _, correct := range []bool{true, true} {
+		if err := db.RecordReview(id, correct); err != nil {
+			t.Fatalf("RecordReview: %v", err)
+		}
+	}
+	review, err := db.GetReview(id)
+	if err != nil {
+		t.Fatalf("GetReview: %v", err)
+	}
+	if review.Repetitions != 2 || review.Interval != 6 {
+		t.Errorf("review = %+v, want 2 repetitions and a 6 day interval", review)
+	}
+
+	due, err := db.GetDueQuestions()
+	if err != nil {
+		t.Fatalf("GetDueQuestions: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("%d questions due right after a correct answer, want none", len(due))
+	}
+}
@@ -3,6 +3,7 @@ module pdf-test-generator
 go 1.25.0
 
 require (
+	github.com/charmbracelet/bubbles v0.21.0
 	github.com/charmbracelet/bubbletea v1.3.8
 	github.com/charmbracelet/lipgloss v1.1.0
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/joho/godotenv"
 	tea "github.com/charmbracelet/bubbletea"
+	"pdf-test-generator/chatgpt"
 	"pdf-test-generator/tui"
 )
 
@@ -16,15 +18,8 @@ func main() {
 		log.Println("Warning: .env file not found. Using system environment variables.")
 	}
 
-	// Get API key from environment
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" || apiKey == "your_openai_api_key_here" {
-		log.Println("Warning: OPENAI_API_KEY not set or using placeholder. ChatGPT features will be disabled.")
-		apiKey = ""
-	}
-
 	// Initialize TUI application
-	app, err := tui.NewApp("test_generator.db", apiKey)
+	app, err := tui.NewApp("test_generator.db", newQuestionGenerator())
 	if err != nil {
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
@@ -35,4 +30,27 @@ func main() {
 		log.Fatal(err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+
+	// Print a summary of the session once the alternate screen is gone
+	if summary := app.SessionSummary(); summary != "" {
+		fmt.Print(summary)
+	}
+}
+
+// newQuestionGenerator returns the question generation backend chosen by
+// QUESTION_BACKEND: "ollama" for a local Ollama server, otherwise OpenAI
+func newQuestionGenerator() chatgpt.QuestionGenerator {
+	if os.Getenv("QUESTION_BACKEND") == "ollama" {
+		return chatgpt.NewOllamaClient(os.Getenv("OLLAMA_URL"), os.Getenv("OLLAMA_MODEL"))
+	}
+
+	// Get API key from environment
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" || apiKey == "your_openai_api_key_here" {
+		log.Println("Warning: OPENAI_API_KEY not set or using placeholder. ChatGPT features will be disabled.")
+		apiKey = ""
+	}
+
+	// Chat model used for generation; the client falls back to its default when unset
+	return chatgpt.NewClient(apiKey, os.Getenv("OPENAI_MODEL"))
+}
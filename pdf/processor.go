@@ -1,23 +1,172 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/ledongthuc/pdf"
 )
 
+// DefaultMinLineLength is the length below which extracted lines are dropped
+// as artifacts such as stray page numbers
+const DefaultMinLineLength = 3
+
+// DefaultMaxFileSize is the largest PDF, in bytes, opened by default
+const DefaultMaxFileSize = 50 << 20
+
+// SupportedExtensions lists the kinds of file text can be extracted from:
+// PDFs, and plain text and Markdown notes read as UTF-8
+var SupportedExtensions = []string{".pdf", ".txt", ".md"}
+
 // PDFProcessor handles PDF text extraction
-type PDFProcessor struct{}
+type PDFProcessor struct {
+	// MinLineLength is the shortest line kept when cleaning extracted text;
+	// shorter lines are usually page numbers or layout artifacts
+	MinLineLength int
+	// MaxFileSize is the largest file, in bytes, that is opened, so a huge
+	// PDF fails at once instead of hanging the app; 0 means no limit
+	MaxFileSize int64
+}
 
 // NewPDFProcessor creates a new PDF processor
 func NewPDFProcessor() *PDFProcessor {
-	return &PDFProcessor{}
+	return &PDFProcessor{
+		MinLineLength: DefaultMinLineLength,
+		MaxFileSize:   DefaultMaxFileSize,
+	}
+}
+
+// ErrPasswordRequired is returned when a PDF is encrypted and no password, or
+// the wrong one, was given to open it
+var ErrPasswordRequired = errors.New("PDF is password-protected")
+
+// openPDF opens a PDF file, decrypting it with password when it is encrypted.
+// An encrypted file that password does not open fails with ErrPasswordRequired,
+// and a file over MaxFileSize is refused.
+func (processor *PDFProcessor) openPDF(filePath, password string) (*os.File, *pdf.Reader, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if err := processor.checkFileSize(fi.Size()); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	// The reader asks for passwords until one works or it gets an empty one,
+	// so offer the given password once
+	offered := false
+	r, err := pdf.NewReaderEncrypted(f, fi.Size(), func() string {
+		if offered {
+			return ""
+		}
+		offered = true
+		return password
+	})
+	if err != nil {
+		f.Close()
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return nil, nil, ErrPasswordRequired
+		}
+		return nil, nil, err
+	}
+	return f, r, nil
+}
+
+// checkFileSize refuses files larger than MaxFileSize
+func (processor *PDFProcessor) checkFileSize(size int64) error {
+	if processor.MaxFileSize > 0 && size > processor.MaxFileSize {
+		return fmt.Errorf("file too large (%.1f MB, limit %g MB)", megabytes(size), megabytes(processor.MaxFileSize))
+	}
+	return nil
+}
+
+// megabytes converts a size in bytes to megabytes
+func megabytes(size int64) float64 {
+	return float64(size) / (1 << 20)
+}
+
+// isPlainText reports whether a file is read as text instead of with the PDF reader
+func isPlainText(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".txt", ".md":
+		return true
+	default:
+		return false
+	}
 }
 
-// ExtractText extracts text content from a PDF file
-func (processor *PDFProcessor) ExtractText(filePath string) (string, error) {
-	f, r, err := pdf.Open(filePath)
+// readPlainText reads a UTF-8 text or Markdown file and cleans it like PDF text
+func (processor *PDFProcessor) readPlainText(filePath string) (string, error) {
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	if err := processor.checkFileSize(fi.Size()); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%s is not UTF-8 text", filepath.Base(filePath))
+	}
+
+	text := processor.cleanText(string(data))
+	if text == "" {
+		return "", errNoPlainText
+	}
+	return text, nil
+}
+
+// errNoPlainText is returned for a text file that is empty or only whitespace
+var errNoPlainText = errors.New("no text could be extracted from the file")
+
+// ExtractText extracts text content from a PDF, text or Markdown file, chosen
+// by its extension. Each PDF page starts with a "[Page N]" marker so generated
+// questions can refer back to their source page.
+// progress, if not nil, is called as each page is reached with its number and
+// the page count.
+func (processor *PDFProcessor) ExtractText(filePath string, progress func(page, total int)) (string, error) {
+	return processor.ExtractTextPagesWithPassword(filePath, "", 0, 0, progress)
+}
+
+// ExtractTextWithPassword extracts text like ExtractText from a PDF that may be
+// encrypted. It fails with ErrPasswordRequired when password does not open it.
+func (processor *PDFProcessor) ExtractTextWithPassword(filePath, password string) (string, error) {
+	return processor.ExtractTextPagesWithPassword(filePath, password, 0, 0, nil)
+}
+
+// ExtractTextPages extracts text like ExtractText from pages start to end only,
+// counted from 1 and inclusive. A range outside the document is an error.
+func (processor *PDFProcessor) ExtractTextPages(filePath string, start, end int) (string, error) {
+	return processor.ExtractTextPagesWithPassword(filePath, "", start, end, nil)
+}
+
+// ExtractTextPagesWithPassword extracts pages start to end of a PDF that may be
+// encrypted. A start and end of 0 extract the whole document. progress, if not
+// nil, is called as each page is reached with its position in the range and
+// the number of pages in the range.
+func (processor *PDFProcessor) ExtractTextPagesWithPassword(filePath, password string, start, end int, progress func(page, total int)) (string, error) {
+	if isPlainText(filePath) {
+		if start != 0 || end != 0 {
+			return "", fmt.Errorf("page ranges only apply to PDF files")
+		}
+		return processor.readPlainText(filePath)
+	}
+
+	f, r, err := processor.openPDF(filePath, password)
 	if err != nil {
 		return "", fmt.Errorf("failed to open PDF file: %w", err)
 	}
@@ -26,12 +175,23 @@ func (processor *PDFProcessor) ExtractText(filePath string) (string, error) {
 	var textBuilder strings.Builder
 	totalPages := r.NumPage()
 
-	for pageIndex := 1; pageIndex <= totalPages; pageIndex++ {
+	if start == 0 && end == 0 {
+		start, end = 1, totalPages
+	}
+	if err := validatePageRange(start, end, totalPages); err != nil {
+		return "", err
+	}
+
+	for pageIndex := start; pageIndex <= end; pageIndex++ {
 		page := r.Page(pageIndex)
 		if page.V.IsNull() {
 			continue
 		}
 
+		if progress != nil {
+			progress(pageIndex-start+1, end-start+1)
+		}
+
 		// Extract text from the page
 		pageText, err := page.GetPlainText(nil)
 		if err != nil {
@@ -42,6 +202,7 @@ func (processor *PDFProcessor) ExtractText(filePath string) (string, error) {
 		// Clean and format the text
 		cleanedText := processor.cleanText(pageText)
 		if cleanedText != "" {
+			fmt.Fprintf(&textBuilder, "[Page %d] ", pageIndex)
 			textBuilder.WriteString(cleanedText)
 			textBuilder.WriteString("\n\n")
 		}
@@ -49,59 +210,98 @@ func (processor *PDFProcessor) ExtractText(filePath string) (string, error) {
 
 	extractedText := textBuilder.String()
 	if extractedText == "" {
+		if start != 1 || end != totalPages {
+			return "", fmt.Errorf("no text could be extracted from pages %d-%d", start, end)
+		}
 		return "", fmt.Errorf("no text could be extracted from the PDF")
 	}
 
 	return strings.TrimSpace(extractedText), nil
 }
 
-// cleanText cleans and formats extracted text
+// validatePageRange checks that pages start to end exist in a document of
+// totalPages pages
+func validatePageRange(start, end, totalPages int) error {
+	switch {
+	case start < 1 || end < 1:
+		return fmt.Errorf("page numbers start at 1")
+	case start > end:
+		return fmt.Errorf("page range %d-%d ends before it starts", start, end)
+	case end > totalPages:
+		return fmt.Errorf("page range %d-%d is past the end of the PDF, which has %d pages", start, end, totalPages)
+	}
+	return nil
+}
+
+// cleanText cleans and formats extracted text. The lines of a paragraph are
+// joined with single spaces, and paragraphs separated by blank lines are kept
+// apart by a blank line. Lines shorter than MinLineLength are dropped.
 func (processor *PDFProcessor) cleanText(text string) string {
-	// Remove excessive whitespace
-	lines := strings.Split(text, "\n")
-	var cleanedLines []string
+	var paragraphs []string
+	var lines []string
+
+	endParagraph := func() {
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, strings.Join(lines, " "))
+			lines = nil
+		}
+	}
 
-	for _, line := range lines {
+	for _, line := range strings.Split(text, "\n") {
 		// Trim whitespace from each line
 		line = strings.TrimSpace(line)
-		
-		// Skip empty lines
+
+		// A blank line ends the paragraph
 		if line == "" {
+			endParagraph()
 			continue
 		}
 
 		// Skip lines that are too short (likely artifacts)
-		if len(line) < 3 {
+		if len(line) < processor.MinLineLength {
 			continue
 		}
 
-		cleanedLines = append(cleanedLines, line)
+		lines = append(lines, line)
 	}
+	endParagraph()
 
-	return strings.Join(cleanedLines, " ")
+	return strings.Join(paragraphs, "\n\n")
 }
 
-// GetTextSummary returns a summary of the extracted text for preview
+// GetTextSummary returns a summary of the extracted text for preview.
+// maxLength is measured in characters, so multibyte text is never cut mid-character.
 func (processor *PDFProcessor) GetTextSummary(text string, maxLength int) string {
-	if len(text) <= maxLength {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
 		return text
 	}
 
 	// Find a good breaking point near the max length
 	breakPoint := maxLength
 	for i := maxLength; i > maxLength-50 && i > 0; i-- {
-		if text[i] == ' ' || text[i] == '.' || text[i] == '\n' {
+		if runes[i] == ' ' || runes[i] == '.' || runes[i] == '\n' {
 			breakPoint = i
 			break
 		}
 	}
 
-	return text[:breakPoint] + "..."
+	return string(runes[:breakPoint]) + "..."
 }
 
-// ValidatePDF checks if a file is a valid PDF
+// ValidatePDF checks if a file is a valid PDF, or readable text for text and
+// Markdown files
 func (processor *PDFProcessor) ValidatePDF(filePath string) error {
-	f, r, err := pdf.Open(filePath)
+	// An empty text file is still valid; HasExtractableText reports it has no text
+	if isPlainText(filePath) {
+		_, err := processor.readPlainText(filePath)
+		if errors.Is(err, errNoPlainText) {
+			return nil
+		}
+		return err
+	}
+
+	f, r, err := processor.openPDF(filePath, "")
 	if err != nil {
 		return fmt.Errorf("invalid PDF file: %w", err)
 	}
@@ -114,9 +314,67 @@ func (processor *PDFProcessor) ValidatePDF(filePath string) error {
 	return nil
 }
 
-// GetPDFInfo returns basic information about the PDF
+// HasExtractableText probes the first maxPages pages of a PDF and reports whether
+// any text can be extracted. Scanned or empty documents return false.
+func (processor *PDFProcessor) HasExtractableText(filePath string, maxPages int) (bool, error) {
+	if isPlainText(filePath) {
+		text, err := processor.readPlainText(filePath)
+		if errors.Is(err, errNoPlainText) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(text) != "", nil
+	}
+
+	f, r, err := processor.openPDF(filePath, "")
+	if err != nil {
+		return false, fmt.Errorf("failed to open PDF file: %w", err)
+	}
+	defer f.Close()
+
+	totalPages := r.NumPage()
+	if maxPages > 0 && maxPages < totalPages {
+		totalPages = maxPages
+	}
+
+	for pageIndex := 1; pageIndex <= totalPages; pageIndex++ {
+		page := r.Page(pageIndex)
+		if page.V.IsNull() {
+			continue
+		}
+
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			continue
+		}
+
+		if processor.cleanText(pageText) != "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// infoKeys maps the Info dictionary entries GetPDFInfo reads to its result keys
+var infoKeys = map[string]string{
+	"Title":    "title",
+	"Author":   "author",
+	"Subject":  "subject",
+	"Keywords": "keywords",
+}
+
+// GetPDFInfo returns basic information about the PDF: its page count under
+// "pages", and its title, author, subject and keywords as strings that are
+// empty when the document does not set them
 func (processor *PDFProcessor) GetPDFInfo(filePath string) (map[string]interface{}, error) {
-	f, r, err := pdf.Open(filePath)
+	if isPlainText(filePath) {
+		return nil, fmt.Errorf("%s is not a PDF file", filepath.Base(filePath))
+	}
+
+	f, r, err := processor.openPDF(filePath, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PDF file: %w", err)
 	}
@@ -124,15 +382,19 @@ func (processor *PDFProcessor) GetPDFInfo(filePath string) (map[string]interface
 
 	info := map[string]interface{}{
 		"pages": r.NumPage(),
-		"title": "",
+	}
+	for _, key := range infoKeys {
+		info[key] = ""
 	}
 
 	// Try to get document info
 	if r.Trailer().Key("Info").Kind() != pdf.Null {
 		infoDict := r.Trailer().Key("Info")
 		if !infoDict.IsNull() {
-			if title := infoDict.Key("Title"); !title.IsNull() {
-				info["title"] = title.Text()
+			for name, key := range infoKeys {
+				if value := infoDict.Key(name); !value.IsNull() {
+					info[key] = strings.TrimSpace(value.Text())
+				}
 			}
 		}
 	}
@@ -0,0 +1,304 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// writeFile writes content to a file named name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlainTextValidation(t *testing.T) {
+	processor := NewPDFProcessor()
+	tests := []struct {
+		name, content string
+		hasText       bool
+	}{
+		{"notes.txt", "Photosynthesis turns light into chemical energy.", true},
+		{"notes.md", "# Cells\n\nThe cell is the basic unit of life.", true},
+		{"empty.txt", "", false},
+		{"blank.md", "  \n\n\t\n", false},
+	}
+
+	for _, test := range tests {
+		path := writeFile(t, test.name, test.content)
+		if err := processor.ValidatePDF(path); err != nil {
+			t.Errorf("ValidatePDF(%s): %v", test.name, err)
+		}
+		hasText, err := processor.HasExtractableText(path, 3)
+		if err != nil {
+			t.Errorf("HasExtractableText(%s): %v", test.name, err)
+		}
+		if hasText != test.hasText {
+			t.Errorf("HasExtractableText(%s) = %v, want %v", test.name, hasText, test.hasText)
+		}
+	}
+
+	path := writeFile(t, "binary.txt", "\xff\xfe\x00")
+	if err := processor.ValidatePDF(path); err == nil {
+		t.Error("ValidatePDF accepted a file that is not UTF-8")
+	}
+}
+
+func TestGetTextSummaryMultibyte(t *testing.T) {
+	processor := NewPDFProcessor()
+	text := strings.Repeat("é—ü日本語", 100)
+
+	for _, maxLength := range []int{1, 7, 50, 199, 600} {
+		summary := processor.GetTextSummary(text, maxLength)
+		if !utf8.ValidString(summary) {
+			t.Errorf("summary of %d runes is not valid UTF-8: %q", maxLength, summary)
+		}
+		body := strings.TrimSuffix(summary, "...")
+		if !strings.HasPrefix(text, body) {
+			t.Errorf("summary of %d runes is not a prefix of the text: %q", maxLength, summary)
+		}
+		if n := utf8.RuneCountInString(body); n > maxLength {
+			t.Errorf("summary of %d runes has %d", maxLength, n)
+		}
+	}
+
+	if summary := processor.GetTextSummary("café", 10); summary != "café" {
+		t.Errorf("short text summarized as %q", summary)
+	}
+}
+
+// writePDF writes a PDF with one page per text, each showing its text in
+// Helvetica, and returns its path
+func writePDF(t *testing.T, pages ...string) string {
+	t.Helper()
+	return writePDFWithInfo(t, "", pages...)
+}
+
+// writePDFWithInfo writes a PDF like writePDF whose trailer refers to info as
+// its Info dictionary, unless info is empty
+func writePDFWithInfo(t *testing.T, info string, pages ...string) string {
+	t.Helper()
+	n := len(pages)
+	font := 3 + 2*n // Object number of the font, after the page and content objects
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Page tree, filled in below
+	}
+	var kids []string
+	for i, text := range pages {
+		page, content := 3+2*i, 4+2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>", content, font),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n)
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	trailer := ""
+	if info != "" {
+		objects = append(objects, info)
+		trailer = fmt.Sprintf(" /Info %d 0 R", len(objects))
+	}
+
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R%s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	return writeFile(t, "test.pdf", b.String())
+}
+
+func TestExtractTextPages(t *testing.T) {
+	processor := NewPDFProcessor()
+	path := writePDF(t, "Chapter one covers cells", "Chapter two covers mitosis", "Chapter three covers meiosis")
+
+	text, err := processor.ExtractTextPages(path, 2, 3)
+	if err != nil {
+		t.Fatalf("ExtractTextPages(2, 3): %v", err)
+	}
+	if strings.Contains(text, "cells") || !strings.Contains(text, "[Page 2] Chapter two covers mitosis") || !strings.Contains(text, "[Page 3] Chapter three") {
+		t.Errorf("pages 2-3 extracted as %q", text)
+	}
+
+	text, err = processor.ExtractTextPages(path, 1, 1)
+	if err != nil {
+		t.Fatalf("ExtractTextPages(1, 1): %v", err)
+	}
+	if strings.TrimSpace(text) != "[Page 1] Chapter one covers cells" {
+		t.Errorf("page 1 extracted as %q", text)
+	}
+
+	for _, r := range [][2]int{{2, 4}, {0, 2}, {3, 2}} {
+		if _, err := processor.ExtractTextPages(path, r[0], r[1]); err == nil {
+			t.Errorf("ExtractTextPages(%d, %d) of a 3 page PDF succeeded", r[0], r[1])
+		}
+	}
+}
+
+func TestCleanTextKeepsParagraphs(t *testing.T) {
+	processor := NewPDFProcessor()
+	text := "  Cells are the basic unit of life.\nThey were first seen in 1665.  \n7\n\n\nMitosis divides one cell\ninto two identical cells.\n"
+
+	want := "Cells are the basic unit of life. They were first seen in 1665.\n\nMitosis divides one cell into two identical cells."
+	if got := processor.cleanText(text); got != want {
+		t.Errorf("cleanText =\n%q\nwant\n%q", got, want)
+	}
+
+	// A lower threshold keeps short lines such as page numbers
+	processor.MinLineLength = 1
+	if got := processor.cleanText("Heading\n7\nBody text"); got != "Heading 7 Body text" {
+		t.Errorf("cleanText with MinLineLength 1 = %q", got)
+	}
+}
+
+func TestExtractTextProgress(t *testing.T) {
+	processor := NewPDFProcessor()
+	path := writePDF(t, "Chapter one covers cells", "Chapter two covers mitosis", "Chapter three covers meiosis")
+
+	var calls [][2]int
+	progress := func(page, total int) { calls = append(calls, [2]int{page, total}) }
+	if _, err := processor.ExtractText(path, progress); err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	if want := [][2]int{{1, 3}, {2, 3}, {3, 3}}; fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("progress calls %v, want %v", calls, want)
+	}
+
+	// Positions count from the start of a page range
+	calls = nil
+	if _, err := processor.ExtractTextPagesWithPassword(path, "", 2, 3, progress); err != nil {
+		t.Fatalf("ExtractTextPagesWithPassword(2, 3): %v", err)
+	}
+	if want := [][2]int{{1, 2}, {2, 2}}; fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("progress calls for pages 2-3 %v, want %v", calls, want)
+	}
+
+	if _, err := processor.ExtractText(path, nil); err != nil {
+		t.Errorf("ExtractText without a progress callback: %v", err)
+	}
+}
+
+func TestGetPDFInfo(t *testing.T) {
+	processor := NewPDFProcessor()
+	path := writePDFWithInfo(t, "<< /Title (Cell Biology) /Author ( Jane Doe ) /Subject (Chapter 3) >>", "Cells", "Mitosis")
+
+	info, err := processor.GetPDFInfo(path)
+	if err != nil {
+		t.Fatalf("GetPDFInfo: %v", err)
+	}
+	want := map[string]interface{}{
+		"pages":    2,
+		"title":    "Cell Biology",
+		"author":   "Jane Doe",
+		"subject":  "Chapter 3",
+		"keywords": "",
+	}
+	if fmt.Sprint(info) != fmt.Sprint(want) {
+		t.Errorf("GetPDFInfo = %v, want %v", info, want)
+	}
+
+	// Without an Info dictionary every field is empty
+	info, err = processor.GetPDFInfo(writePDF(t, "Cells"))
+	if err != nil {
+		t.Fatalf("GetPDFInfo without Info: %v", err)
+	}
+	for _, key := range []string{"title", "author", "subject", "keywords"} {
+		if info[key] != "" {
+			t.Errorf("%s = %v without an Info dictionary, want empty", key, info[key])
+		}
+	}
+}
+
+func TestFileSizeLimit(t *testing.T) {
+	processor := NewPDFProcessor()
+	limit := processor.MaxFileSize
+	if limit != DefaultMaxFileSize {
+		t.Fatalf("MaxFileSize = %d, want the default %d", limit, DefaultMaxFileSize)
+	}
+
+	for _, tt := range []struct {
+		size    int64
+		wantErr string
+	}{
+		{limit - 1, ""},
+		{limit, ""},
+		{limit + 1<<20, "file too large (51.0 MB, limit 50 MB)"},
+		{300 << 20, "file too large (300.0 MB, limit 50 MB)"},
+	} {
+		err := processor.checkFileSize(tt.size)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("checkFileSize(%d): %v", tt.size, err)
+			}
+		} else if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("checkFileSize(%d) = %v, want %q", tt.size, err, tt.wantErr)
+		}
+	}
+
+	// The limit is checked before a file is read
+	processor.MaxFileSize = 16
+	for _, path := range []string{writePDF(t, "Chapter one covers cells"), writeFile(t, "notes.txt", "Photosynthesis turns light into chemical energy.")} {
+		if err := processor.ValidatePDF(path); err == nil || !strings.Contains(err.Error(), "file too large") {
+			t.Errorf("ValidatePDF(%s) over the limit: %v", filepath.Base(path), err)
+		}
+		if _, err := processor.ExtractText(path, nil); err == nil || !strings.Contains(err.Error(), "file too large") {
+			t.Errorf("ExtractText(%s) over the limit: %v", filepath.Base(path), err)
+		}
+	}
+
+	processor.MaxFileSize = 0
+	if _, err := processor.ExtractText(writePDF(t, "Chapter one covers cells"), nil); err != nil {
+		t.Errorf("ExtractText without a limit: %v", err)
+	}
+}
+
+func TestExtractPlainText(t *testing.T) {
+	processor := NewPDFProcessor()
+	tests := []struct {
+		name, content, want string
+	}{
+		{"notes.txt", "Photosynthesis turns light\ninto chemical energy.\n\nIt happens in chloroplasts.\n",
+			"Photosynthesis turns light into chemical energy.\n\nIt happens in chloroplasts."},
+		{"lecture.md", "# Cells\n\nThe cell is the basic unit of life — café, naïve.\n",
+			"# Cells\n\nThe cell is the basic unit of life — café, naïve."},
+		{"SHOUTING.MD", "Upper case extensions are read as text too.", "Upper case extensions are read as text too."},
+	}
+
+	for _, test := range tests {
+		path := writeFile(t, test.name, test.content)
+		text, err := processor.ExtractText(path, nil)
+		if err != nil {
+			t.Errorf("ExtractText(%s): %v", test.name, err)
+			continue
+		}
+		if text != test.want {
+			t.Errorf("ExtractText(%s) = %q, want %q", test.name, text, test.want)
+		}
+	}
+
+	path := writeFile(t, "notes.txt", "Photosynthesis turns light into chemical energy.")
+	if _, err := processor.ExtractTextPages(path, 1, 1); err == nil {
+		t.Error("ExtractTextPages accepted a page range for a text file")
+	}
+	if _, err := processor.ExtractText(writeFile(t, "empty.md", ""), nil); err == nil {
+		t.Error("ExtractText of an empty file succeeded")
+	}
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportSelectedAnswerKey writes the answer key of the selected test to a
+// Markdown file in the working directory
+func (a *App) exportSelectedAnswerKey() (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+
+	questions, err := a.db.GetQuestionsByTestID(selectedTest.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return a, nil
+	}
+
+	if len(questions) == 0 {
+		a.testSelection.errorMsg = "This test has no questions"
+		return a, nil
+	}
+
+	path := exportFileName(selectedTest.Name, "-answer-key.md")
+	if err := os.WriteFile(path, []byte(formatAnswerKey(selectedTest, questions)), 0644); err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to write answer key: %v", err)
+		return a, nil
+	}
+
+	a.testSelection.successMsg = fmt.Sprintf("Answer key saved to %s", path)
+	return a, nil
+}
+
+// formatAnswerKey renders the answer key of a test as Markdown: the question
+// number, its correct answer and the explanation, without the question text
+func formatAnswerKey(test *database.Test, questions []*database.Question) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Answer Key: %s\n\n", test.Name)
+	if test.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", test.Description)
+	}
+
+	for i, q := range questions {
+		fmt.Fprintf(&b, "%d. **%s**", i+1, formatKeyAnswer(q))
+		if q.Explanation != "" {
+			fmt.Fprintf(&b, " — %s", q.Explanation)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// formatKeyAnswer renders the correct answer of a question for the answer key.
+// Option letters are followed by the option text so the key can be read alone.
+func formatKeyAnswer(q *database.Question) string {
+	switch q.QuestionType {
+	case "multiple_choice", "multi_select":
+		var parts []string
+		for _, letter := range strings.Split(normalizeSelectionAnswer(q.CorrectAnswer), ",") {
+			// Anything but an option letter, e.g. an answer stored as option text, is shown as is
+			if len(letter) != 1 || letter[0] < 'A' || int(letter[0]-'A') >= len(q.Options) {
+				return q.CorrectAnswer
+			}
+			parts = append(parts, fmt.Sprintf("%s) %s", letter, q.Options[letter[0]-'A']))
+		}
+		return strings.Join(parts, "; ")
+	default:
+		return formatAnswer(q.QuestionType, q.CorrectAnswer)
+	}
+}
+
+// exportFileName builds a file name for a file exported from a test, from the
+// test's name followed by suffix, e.g. "biology-answer-key.md"
+func exportFileName(testName, suffix string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(testName)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			b.WriteRune('-')
+		}
+	}
+
+	name := b.String()
+	if name == "" {
+		name = "test"
+	}
+	return name + suffix
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/database"
+)
+
+func TestFormatAnswerKey(t *testing.T) {
+	test := &database.Test{Name: "Biology", Description: "Cells"}
+	questions := []*database.Question{
+		{QuestionType: "multiple_choice", Options: []string{"Nucleus", "Mitochondria"}, CorrectAnswer: "B", Explanation: "Energy"},
+		{QuestionType: "multi_select", Options: []string{"Plant", "Rock", "Fungus"}, CorrectAnswer: "c,a"},
+		{QuestionType: "multiple_choice", Options: []string{"Yes", "No"}, CorrectAnswer: "Maybe"},
+		{QuestionType: "true_false", CorrectAnswer: "t"},
+		{QuestionType: "short_answer", CorrectAnswer: "mitosis|cell division"},
+		{QuestionType: "fill_blank", CorrectAnswer: "ATP|adenosine triphosphate"},
+	}
+
+	want := "# Answer Key: Biology\n\nCells\n\n" +
+		"1. **B) Mitochondria** — Energy\n" +
+		"2. **A) Plant; C) Fungus**\n" +
+		"3. **Maybe**\n" +
+		"4. **True**\n" +
+		"5. **mitosis**\n" +
+		"6. **ATP / adenosine triphosphate**\n"
+	if got := formatAnswerKey(test, questions); got != want {
+		t.Errorf("answer key =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportAnswerKey(t *testing.T) {
+	t.Chdir(t.TempDir())
+	a := newTestApp(t)
+	createTest(t, a, "Cell Biology", shortAnswers("Q1", "Q2")...)
+	openTestList(t, a, "view_tests")
+
+	press(a, "a")
+	if a.testSelection.errorMsg != "" {
+		t.Fatalf("exporting the answer key: %s", a.testSelection.errorMsg)
+	}
+	data, err := os.ReadFile("cell-biology-answer-key.md")
+	if err != nil {
+		t.Fatalf("reading the answer key: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# Answer Key: Cell Biology\n") || !strings.Contains(string(data), "2. **answer**\n") {
+		t.Errorf("answer key file =\n%s", data)
+	}
+	if strings.Contains(string(data), "Q1") {
+		t.Error("the answer key includes question text")
+	}
+}
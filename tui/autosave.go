@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"time"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autosaveIndicatorDuration is how long "✓ autosaved" stays visible after a save
+const autosaveIndicatorDuration = 3 * time.Second
+
+// testTickMsg is sent every second while a test is being taken. startedAt
+// identifies the attempt the tick belongs to, so ticks from an earlier test stop.
+type testTickMsg struct {
+	startedAt time.Time
+}
+
+// testTick schedules the next tick of the attempt started at startedAt
+func testTick(startedAt time.Time) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return testTickMsg{startedAt: startedAt}
+	})
+}
+
+// autosaveEnabled reports whether the current attempt is saved periodically.
+// Practice sessions are not backed by a saved test and are never autosaved.
+func (a *App) autosaveEnabled() bool {
+	return a.currentTest != nil && a.currentTest.ID != 0 && a.settingInt(settingAutosaveInterval) > 0
+}
+
+// handleTestTick submits the current attempt when its time limit runs out,
+// autosaves it once the configured interval has passed and keeps ticking
+// while the test is in progress
+func (a *App) handleTestTick(msg testTickMsg) (tea.Model, tea.Cmd) {
+	if a.currentView != TestTakingView || !msg.startedAt.Equal(a.testStartTime) || a.testTaking.showResult {
+		return a, nil
+	}
+
+	if remaining, timed := a.timeRemaining(); timed && remaining == 0 {
+		a.expireTest()
+		return a, nil
+	}
+
+	interval := time.Duration(a.settingInt(settingAutosaveInterval)) * time.Second
+	lastSave := a.testTaking.lastAutosave
+	if lastSave.IsZero() {
+		lastSave = a.testStartTime
+	}
+
+	if a.autosaveEnabled() && time.Since(lastSave) >= interval {
+		a.autosaveAttempt()
+	}
+
+	return a, testTick(msg.startedAt)
+}
+
+// saveAnsweredProgress saves the attempt after an answer is recorded, so an
+// interrupted test can be resumed. Practice sessions are not saved.
+func (a *App) saveAnsweredProgress() {
+	if a.currentTest != nil && a.currentTest.ID != 0 {
+		a.autosaveAttempt()
+	}
+}
+
+// autosaveAttempt persists the questions of the current attempt, in the
+// order they are taken, and the answers given so far
+func (a *App) autosaveAttempt() {
+	questionIDs := make([]int, len(a.currentQuestions))
+	for i, q := range a.currentQuestions {
+		questionIDs[i] = q.ID
+	}
+	err := a.db.SaveTestAttempt(a.currentTest.ID, a.testTaking.currentQuestion, questionIDs, a.storedAnswers(), a.testStartTime)
+	if err != nil {
+		a.testTaking.errorMsg = err.Error()
+		return
+	}
+	a.testTaking.lastAutosave = time.Now()
+}
+
+// renderAutosaveIndicator shows a brief confirmation after each autosave
+func (a *App) renderAutosaveIndicator() string {
+	if a.testTaking.lastAutosave.IsZero() || time.Since(a.testTaking.lastAutosave) > autosaveIndicatorDuration {
+		return ""
+	}
+	return " | " + infoStyle.Render("✓ autosaved")
+}
+
+// resumeTest starts an attempt at a test from saved progress: it takes the
+// same questions in the same order, its answers are restored in this
+// attempt's option order, it opens on the question it was on and the time
+// already spent counts towards the time limit. questions are all of the
+// test's questions.
+func (a *App) resumeTest(test *database.Test, questions []*database.Question, attempt *database.TestAttempt) tea.Cmd {
+	a.startTest(test, attemptQuestions(questions, attempt))
+	a.testStartTime = time.Now().Add(-attempt.UpdatedAt.Sub(attempt.StartedAt))
+
+	for _, q := range a.currentQuestions {
+		if answer, ok := attempt.Answers[q.ID]; ok {
+			a.userAnswers[q.ID] = a.displayedAnswer(q.ID, answer)
+		}
+	}
+
+	a.testTaking.currentQuestion = min(max(attempt.CurrentQuestion, 0), len(a.currentQuestions)-1)
+	a.restoreAnswer()
+
+	if _, timed := a.timeRemaining(); !timed && !a.autosaveEnabled() {
+		return nil
+	}
+	return testTick(a.testStartTime)
+}
+
+// attemptQuestions picks the questions of a saved attempt out of the test's
+// questions, in the order the attempt took them. Questions deleted since are
+// skipped. Attempts saved before their questions were recorded, or whose
+// questions are all gone, use every question of the test.
+func attemptQuestions(questions []*database.Question, attempt *database.TestAttempt) []*database.Question {
+	byID := make(map[int]*database.Question, len(questions))
+	for _, q := range questions {
+		byID[q.ID] = q
+	}
+	
+	var picked []*database.Question
+	for _, id := range attempt.QuestionIDs {
+		if q, ok := byID[id]; ok {
+			picked = append(picked, q)
+		}
+	}
+	if len(picked) == 0 {
+		return questions
+	}
+	return picked
+}
+
+// displayedAnswer converts an answer in stored option order to the letters of
+// the options as they are shown in this attempt, the reverse of storedAnswer
+func (a *App) displayedAnswer(questionID int, answer string) string {
+	order, ok := a.testTaking.optionOrder[questionID]
+	if !ok {
+		return answer
+	}
+	position := make([]int, len(order))
+	for i, index := range order {
+		position[index] = i
+	}
+	return mapLetters(answer, position)
+}
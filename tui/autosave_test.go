@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestResumeAttempt(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3")
+	test := createTest(t, a, "Biology", questions...)
+
+	a.startTest(test, questions)
+	a.userAnswers[questions[0].ID] = "answer"
+	a.testTaking.currentQuestion = 1
+	a.saveAnsweredProgress()
+	a.endTest()
+
+	openTestList(t, a, "take_test")
+	if !a.testSelection.unfinished[test.ID] {
+		t.Fatal("the test is not listed as unfinished")
+	}
+	press(a, "enter")
+	if a.testSelection.confirmAction != "resume" {
+		t.Fatalf("confirm action = %q, want the resume prompt", a.testSelection.confirmAction)
+	}
+	press(a, "y")
+
+	if a.currentView != TestTakingView || a.currentTest.ID != test.ID {
+		t.Fatalf("resuming did not start the test: view %v", a.currentView)
+	}
+	if a.testTaking.currentQuestion != 1 || a.userAnswers[questions[0].ID] != "answer" {
+		t.Errorf("resumed on question %d with answers %v", a.testTaking.currentQuestion, a.userAnswers)
+	}
+
+	// Finishing the attempt clears its saved progress
+	a.userAnswers[questions[1].ID] = "answer"
+	a.userAnswers[questions[2].ID] = "answer"
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	if attempt, err := a.db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("attempt after finishing = %+v, %v; want it cleared", attempt, err)
+	}
+}
+
+func TestResumeShuffledAttempt(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3", "Q4", "Q5", "Q6")
+	createTest(t, a, "Biology", questions...)
+
+	openTestList(t, a, "take_test")
+	a.testSelection.shuffle = true
+	press(a, "enter")
+	press(a, "enter") // Take all of them
+	if a.currentView != TestTakingView {
+		t.Fatalf("test not started: %s", a.testSelection.errorMsg)
+	}
+	order := fmt.Sprint(questionIDs(a.currentQuestions))
+	left := a.currentQuestions[3].ID
+	a.userAnswers[a.currentQuestions[0].ID] = "answer"
+	a.testTaking.currentQuestion = 3
+	a.saveAnsweredProgress()
+	a.endTest()
+
+	// Shuffling again on resume would lose the user's place
+	openTestList(t, a, "take_test")
+	a.testSelection.shuffle = true
+	press(a, "enter")
+	press(a, "y")
+	if a.currentView != TestTakingView {
+		t.Fatalf("attempt not resumed: %s", a.testSelection.errorMsg)
+	}
+	if got := fmt.Sprint(questionIDs(a.currentQuestions)); got != order {
+		t.Errorf("resumed in order %s, want the saved order %s", got, order)
+	}
+	if current := a.currentQuestions[a.testTaking.currentQuestion]; current.ID != left {
+		t.Errorf("resumed on question %d (%s), want %d", current.ID, current.QuestionText, left)
+	}
+	if len(a.userAnswers) != 1 {
+		t.Errorf("answers after resuming = %v, want the one given", a.userAnswers)
+	}
+}
+
+func TestResumeQuestionSubset(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3", "Q4", "Q5", "Q6")
+	test := createTest(t, a, "Biology", questions...)
+
+	openTestList(t, a, "take_test")
+	press(a, "enter")
+	a.testSelection.input = "3"
+	press(a, "enter")
+	if a.currentView != TestTakingView || len(a.currentQuestions) != 3 {
+		t.Fatalf("subset not started: %d questions, %s", len(a.currentQuestions), a.testSelection.errorMsg)
+	}
+	sampled := fmt.Sprint(questionIDs(a.currentQuestions))
+	a.userAnswers[a.currentQuestions[0].ID] = "answer"
+	a.testTaking.currentQuestion = 1
+	a.saveAnsweredProgress()
+	a.endTest()
+
+	openTestList(t, a, "take_test")
+	press(a, "enter")
+	press(a, "y")
+	if got := fmt.Sprint(questionIDs(a.currentQuestions)); got != sampled {
+		t.Fatalf("resumed with questions %s, want the sampled %s", got, sampled)
+	}
+
+	for _, q := range a.currentQuestions {
+		a.userAnswers[q.ID] = "answer"
+	}
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	results, err := a.db.GetTestResults(test.ID)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("results = %+v, %v", results, err)
+	}
+	if results[0].TotalQuestions != 3 || results[0].CorrectAnswers != 3 {
+		t.Errorf("scored %d/%d, want 3/3 on the sampled questions", results[0].CorrectAnswers, results[0].TotalQuestions)
+	}
+}
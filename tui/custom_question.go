@@ -1,17 +1,29 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"pdf-test-generator/database"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Number of option slots a multiple choice question starts with, and the range
+// authors can grow or shrink it to
+const (
+	defaultOptionCount = 4
+	minOptionCount     = 2
+	maxOptionCount     = 8
+)
+
 // CustomQuestionModel represents the custom question creation state
 type CustomQuestionModel struct {
 	step           int    // 0: test info, 1: question creation, 2: review
 	cursor         int
-	inputMode      string // "test_name", "test_desc", "question", "answer", "explanation", "option"
+	inputMode      string // "test_name", "test_desc", "time_limit", "question", "answer", "explanation", "option"
 	input          string
 	errorMsg       string
 	successMsg     string
@@ -19,6 +31,10 @@ type CustomQuestionModel struct {
 	// Test info
 	testName       string
 	testDesc       string
+	timeLimit      int // in minutes, 0 for no limit
+	
+	// Reject questions saved without an explanation
+	requireExplanation bool
 	
 	// Current question being created
 	currentQuestion struct {
@@ -27,6 +43,7 @@ type CustomQuestionModel struct {
 		options     []string
 		correctAnswer string
 		explanation string
+		difficulty  string
 	}
 	
 	// Questions created so far
@@ -34,6 +51,11 @@ type CustomQuestionModel struct {
 	questionTypes  []string
 	typeIndex      int
 	optionIndex    int
+	
+	// Highlighted question in the review step, and the position of the
+	// question loaded back for editing (-1 while creating a new one)
+	reviewCursor   int
+	editIndex      int
 }
 
 // QuestionData represents a created question
@@ -43,24 +65,27 @@ type QuestionData struct {
 	Options       []string
 	CorrectAnswer string
 	Explanation   string
+	Difficulty    string // "easy", "medium", "hard" or empty when not rated
 }
 
 // NewCustomQuestionModel creates a new custom question model
 func NewCustomQuestionModel() *CustomQuestionModel {
 	return &CustomQuestionModel{
 		step: 0,
+		editIndex: -1,
 		testName: "Custom Test",
 		testDesc: "Custom created test",
-		questionTypes: []string{"multiple_choice", "true_false", "short_answer"},
+		questionTypes: []string{"multiple_choice", "multi_select", "true_false", "short_answer", "fill_blank"},
 		currentQuestion: struct {
 			text        string
 			qType       string
 			options     []string
 			correctAnswer string
 			explanation string
+			difficulty  string
 		}{
 			qType: "multiple_choice",
-			options: make([]string, 4), // Default 4 options for multiple choice
+			options: make([]string, defaultOptionCount), // Default 4 options for multiple choice
 		},
 	}
 }
@@ -131,7 +156,25 @@ func (a *App) viewTestInfoStep() string {
 	if a.customQuestion.cursor == 1 {
 		cursor = ">"
 	}
-	s += fmt.Sprintf("%s Test Description: %s (press 'd' to edit)\n\n", cursor, a.customQuestion.testDesc)
+	s += fmt.Sprintf("%s Test Description: %s (press 'd' to edit)\n", cursor, a.customQuestion.testDesc)
+	
+	// Time limit
+	cursor = " "
+	if a.customQuestion.cursor == 2 {
+		cursor = ">"
+	}
+	s += fmt.Sprintf("%s Time Limit: %s (press 't' to edit)\n", cursor, formatTimeLimit(a.customQuestion.timeLimit))
+	
+	// Explanation requirement
+	cursor = " "
+	if a.customQuestion.cursor == 3 {
+		cursor = ">"
+	}
+	required := "off"
+	if a.customQuestion.requireExplanation {
+		required = "on"
+	}
+	s += fmt.Sprintf("%s Require Explanations: %s (press 'r' to toggle)\n\n", cursor, required)
 	
 	s += "Press Enter to continue to question creation\n"
 	s += "Use arrow keys to navigate, letters to edit\n"
@@ -142,6 +185,9 @@ func (a *App) viewTestInfoStep() string {
 // viewQuestionCreationStep renders the question creation step
 func (a *App) viewQuestionCreationStep() string {
 	s := fmt.Sprintf("Step 2: Create Questions (%d created so far)\n\n", len(a.customQuestion.questions))
+	if a.customQuestion.editIndex >= 0 {
+		s = fmt.Sprintf("Step 2: Edit Question %d of %d\n\n", a.customQuestion.editIndex+1, len(a.customQuestion.questions))
+	}
 	
 	if a.customQuestion.inputMode != "" {
 		return s + a.viewCustomQuestionInputMode()
@@ -161,18 +207,18 @@ func (a *App) viewQuestionCreationStep() string {
 		cursor = ">"
 	}
 	questionPreview := a.customQuestion.currentQuestion.text
-	if len(questionPreview) > 50 {
-		questionPreview = questionPreview[:50] + "..."
+	if runes := []rune(questionPreview); len(runes) > 50 {
+		questionPreview = string(runes[:50]) + "..."
 	}
 	s += fmt.Sprintf("%s Question: %s (press 'q' to edit)\n", cursor, questionPreview)
 	
-	// Options (for multiple choice)
-	if a.customQuestion.currentQuestion.qType == "multiple_choice" {
+	// Options (for multiple choice and select all that apply)
+	if a.customQuestionHasOptions() {
 		cursor = " "
 		if a.customQuestion.cursor == 2 {
 			cursor = ">"
 		}
-		s += fmt.Sprintf("%s Options: (press 'o' to edit)\n", cursor)
+		s += fmt.Sprintf("%s Options: (press 'o' to edit, '+'/'-' to add or remove)\n", cursor)
 		for i, option := range a.customQuestion.currentQuestion.options {
 			optionText := option
 			if optionText == "" {
@@ -195,13 +241,25 @@ func (a *App) viewQuestionCreationStep() string {
 		cursor = ">"
 	}
 	explanationPreview := a.customQuestion.currentQuestion.explanation
-	if len(explanationPreview) > 50 {
-		explanationPreview = explanationPreview[:50] + "..."
+	if runes := []rune(explanationPreview); len(runes) > 50 {
+		explanationPreview = string(runes[:50]) + "..."
+	}
+	s += fmt.Sprintf("%s Explanation: %s (press 'e' to edit)\n", cursor, explanationPreview)
+	
+	// Difficulty
+	cursor = " "
+	if a.customQuestion.cursor == 5 {
+		cursor = ">"
 	}
-	s += fmt.Sprintf("%s Explanation: %s (press 'e' to edit)\n\n", cursor, explanationPreview)
+	s += fmt.Sprintf("%s Difficulty: %s (press 'v' to change)\n\n", cursor, formatDifficulty(a.customQuestion.currentQuestion.difficulty))
 	
-	s += "Press 's' to save this question and create another\n"
-	s += "Press 'f' to finish and review all questions\n"
+	if a.customQuestion.editIndex >= 0 {
+		s += "Press 's' to save your changes to this question\n"
+		s += "Press 'f' to discard your changes and return to the review\n"
+	} else {
+		s += "Press 's' to save this question and create another\n"
+		s += "Press 'f' to finish and review all questions\n"
+	}
 	s += "Use arrow keys to navigate\n"
 	
 	return s
@@ -218,12 +276,38 @@ func (a *App) viewReviewStep() string {
 	}
 	
 	s += fmt.Sprintf("Test: %s\n", a.customQuestion.testName)
-	s += fmt.Sprintf("Description: %s\n\n", a.customQuestion.testDesc)
+	s += fmt.Sprintf("Description: %s\n", a.customQuestion.testDesc)
+	s += fmt.Sprintf("Time Limit: %s\n\n", formatTimeLimit(a.customQuestion.timeLimit))
 	
 	s += "Questions:\n\n"
-	for i, q := range a.customQuestion.questions {
-		s += fmt.Sprintf("%d. %s\n", i+1, q.Text)
+	s += a.renderQuestionList(a.customQuestion.questions, a.customQuestion.reviewCursor)
+	
+	s += "↑↓ Highlight a question • 'e' to edit it • 'x' to delete it • 'K'/'J' to move it up/down\n"
+	s += "Press Enter to save test to database\n"
+	s += "Press 'b' to go back and add more questions\n"
+	
+	return s
+}
+
+// renderQuestionList renders questions as the numbered list shown when
+// reviewing a custom test or previewing an import. The question at index
+// highlight is marked; pass -1 for a list without a highlight.
+func (a *App) renderQuestionList(questions []QuestionData, highlight int) string {
+	var s string
+	for i, q := range questions {
+		line := fmt.Sprintf("%d. %s", i+1, q.Text)
+		switch {
+		case highlight < 0:
+			s += line + "\n"
+		case i == highlight:
+			s += "> " + selectedStyle.Render(line) + "\n"
+		default:
+			s += "  " + line + "\n"
+		}
 		s += fmt.Sprintf("   Type: %s\n", a.getQuestionTypeDisplay(q.Type))
+		if q.Difficulty != "" {
+			s += fmt.Sprintf("   Difficulty: %s\n", formatDifficulty(q.Difficulty))
+		}
 		if len(q.Options) > 0 {
 			s += "   Options: "
 			for j, opt := range q.Options {
@@ -239,10 +323,6 @@ func (a *App) viewReviewStep() string {
 		}
 		s += "\n"
 	}
-	
-	s += "Press Enter to save test to database\n"
-	s += "Press 'b' to go back and add more questions\n"
-	
 	return s
 }
 
@@ -254,10 +334,20 @@ func (a *App) viewCustomQuestionInputMode() string {
 		prompt = "Enter test name:"
 	case "test_desc":
 		prompt = "Enter test description:"
+	case "time_limit":
+		prompt = "Enter time limit in minutes (0 for no limit):"
 	case "question":
 		prompt = "Enter question text:"
 	case "answer":
 		prompt = "Enter correct answer:"
+		switch a.customQuestion.currentQuestion.qType {
+		case "multi_select":
+			prompt = "Enter correct letters (e.g. A,C):"
+		case "short_answer":
+			prompt = "Enter correct answer, separating other accepted phrasings with | (e.g. USA|United States):"
+		case "fill_blank":
+			prompt = "Enter accepted answers, separated by | (e.g. Paris|paris):"
+		}
 	case "explanation":
 		prompt = "Enter explanation (optional):"
 	case "option":
@@ -266,6 +356,26 @@ func (a *App) viewCustomQuestionInputMode() string {
 	
 	s := prompt + "\n"
 	s += "> " + a.customQuestion.input + "\n\n"
+	
+	if a.customQuestion.inputMode == "option" {
+		for i, option := range a.customQuestion.currentQuestion.options {
+			if i == a.customQuestion.optionIndex {
+				option = a.customQuestion.input
+			}
+			if option == "" {
+				option = "[empty]"
+			}
+			line := fmt.Sprintf("%c) %s", 'A'+i, option)
+			if i == a.customQuestion.optionIndex {
+				s += fmt.Sprintf("► %s\n", selectedStyle.Render(line))
+			} else {
+				s += fmt.Sprintf("  %s\n", line)
+			}
+		}
+		s += fmt.Sprintf("\nCorrect answer: %s\n\n", a.customQuestion.currentQuestion.correctAnswer)
+		s += "Shift+↑/↓ to move this option, keeping the correct answer\n"
+	}
+	
 	s += "Press Enter to confirm, Esc to cancel\n"
 	
 	return s
@@ -279,7 +389,7 @@ func (a *App) handleTestInfoStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.customQuestion.cursor--
 		}
 	case "down", "j":
-		if a.customQuestion.cursor < 1 {
+		if a.customQuestion.cursor < 3 {
 			a.customQuestion.cursor++
 		}
 	case "n":
@@ -292,6 +402,15 @@ func (a *App) handleTestInfoStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.customQuestion.inputMode = "test_desc"
 			a.customQuestion.input = a.customQuestion.testDesc
 		}
+	case "t":
+		if a.customQuestion.cursor == 2 {
+			a.customQuestion.inputMode = "time_limit"
+			a.customQuestion.input = strconv.Itoa(a.customQuestion.timeLimit)
+		}
+	case "r":
+		if a.customQuestion.cursor == 3 {
+			a.customQuestion.requireExplanation = !a.customQuestion.requireExplanation
+		}
 	case "enter", " ":
 		a.customQuestion.step = 1
 		a.customQuestion.cursor = 0
@@ -307,7 +426,7 @@ func (a *App) handleQuestionCreationStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.customQuestion.cursor--
 		}
 	case "down", "j":
-		maxCursor := 4
+		maxCursor := 5
 		if a.customQuestion.cursor < maxCursor {
 			a.customQuestion.cursor++
 		}
@@ -321,25 +440,46 @@ func (a *App) handleQuestionCreationStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.customQuestion.input = a.customQuestion.currentQuestion.text
 		}
 	case "o":
-		if a.customQuestion.cursor == 2 && a.customQuestion.currentQuestion.qType == "multiple_choice" {
+		if a.customQuestion.cursor == 2 && a.customQuestionHasOptions() {
 			a.customQuestion.inputMode = "option"
 			a.customQuestion.optionIndex = 0
 			a.customQuestion.input = a.customQuestion.currentQuestion.options[0]
 		}
 	case "a":
+		if a.customQuestion.cursor == 2 && a.customQuestionHasOptions() {
+			a.addOptionSlot()
+		}
 		if a.customQuestion.cursor == 3 {
 			a.customQuestion.inputMode = "answer"
 			a.customQuestion.input = a.customQuestion.currentQuestion.correctAnswer
 		}
+	case "+":
+		if a.customQuestion.cursor == 2 && a.customQuestionHasOptions() {
+			a.addOptionSlot()
+		}
+	case "-":
+		if a.customQuestion.cursor == 2 && a.customQuestionHasOptions() {
+			a.removeOptionSlot()
+		}
 	case "e":
 		if a.customQuestion.cursor == 4 {
 			a.customQuestion.inputMode = "explanation"
 			a.customQuestion.input = a.customQuestion.currentQuestion.explanation
 		}
+	case "v":
+		if a.customQuestion.cursor == 5 {
+			a.customQuestion.currentQuestion.difficulty = nextDifficulty(a.customQuestion.currentQuestion.difficulty)
+		}
 	case "s":
 		return a.saveCurrentQuestion()
 	case "f":
-		if len(a.customQuestion.questions) > 0 {
+		if a.customQuestion.editIndex >= 0 {
+			// Leave the question as it was before editing
+			a.customQuestion.editIndex = -1
+			a.resetCurrentQuestion()
+			a.customQuestion.step = 2
+			a.customQuestion.cursor = 0
+		} else if len(a.customQuestion.questions) > 0 {
 			a.customQuestion.step = 2
 			a.customQuestion.cursor = 0
 		} else {
@@ -352,6 +492,26 @@ func (a *App) handleQuestionCreationStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // handleReviewStep handles review step input
 func (a *App) handleReviewStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
+	case "up", "k":
+		if a.customQuestion.reviewCursor > 0 {
+			a.customQuestion.reviewCursor--
+		}
+	case "down", "j":
+		if a.customQuestion.reviewCursor < len(a.customQuestion.questions)-1 {
+			a.customQuestion.reviewCursor++
+		}
+	case "e":
+		if len(a.customQuestion.questions) > 0 {
+			a.editCustomQuestion(a.customQuestion.reviewCursor)
+		}
+	case "x":
+		if len(a.customQuestion.questions) > 0 {
+			a.deleteCustomQuestion(a.customQuestion.reviewCursor)
+		}
+	case "K", "shift+up":
+		a.moveCustomQuestion(-1)
+	case "J", "shift+down":
+		a.moveCustomQuestion(1)
 	case "enter", " ":
 		return a.saveCustomTest()
 	case "b":
@@ -361,6 +521,53 @@ func (a *App) handleReviewStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// editCustomQuestion loads a created question back into the question form.
+// Saving it replaces the question in place.
+func (a *App) editCustomQuestion(index int) {
+	q := a.customQuestion.questions[index]
+	
+	a.customQuestion.currentQuestion.text = q.Text
+	a.customQuestion.currentQuestion.qType = q.Type
+	a.customQuestion.currentQuestion.options = append([]string{}, q.Options...)
+	a.customQuestion.currentQuestion.correctAnswer = q.CorrectAnswer
+	a.customQuestion.currentQuestion.explanation = q.Explanation
+	a.customQuestion.currentQuestion.difficulty = q.Difficulty
+	for i, qType := range a.customQuestion.questionTypes {
+		if qType == q.Type {
+			a.customQuestion.typeIndex = i
+		}
+	}
+	
+	a.customQuestion.editIndex = index
+	a.customQuestion.step = 1
+	a.customQuestion.cursor = 0
+}
+
+// deleteCustomQuestion removes a created question from the review list
+func (a *App) deleteCustomQuestion(index int) {
+	questions := a.customQuestion.questions
+	a.customQuestion.questions = append(questions[:index:index], questions[index+1:]...)
+	
+	if a.customQuestion.reviewCursor >= len(a.customQuestion.questions) && a.customQuestion.reviewCursor > 0 {
+		a.customQuestion.reviewCursor--
+	}
+	a.customQuestion.successMsg = fmt.Sprintf("Question %d deleted (%d left)", index+1, len(a.customQuestion.questions))
+}
+
+// moveCustomQuestion moves the highlighted question up (-1) or down (1) in the
+// review list, which is the order the questions are saved and asked in
+func (a *App) moveCustomQuestion(delta int) {
+	questions := a.customQuestion.questions
+	from := a.customQuestion.reviewCursor
+	to := from + delta
+	if len(questions) == 0 || to < 0 || to >= len(questions) {
+		return
+	}
+	
+	questions[from], questions[to] = questions[to], questions[from]
+	a.customQuestion.reviewCursor = to
+}
+
 // handleCustomQuestionInput handles input mode
 func (a *App) handleCustomQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -375,6 +582,12 @@ func (a *App) handleCustomQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		case "test_desc":
 			a.customQuestion.testDesc = strings.TrimSpace(a.customQuestion.input)
+		case "time_limit":
+			if minutes, err := parseTimeLimit(a.customQuestion.input); err == nil {
+				a.customQuestion.timeLimit = minutes
+			} else {
+				a.customQuestion.errorMsg = err.Error()
+			}
 		case "question":
 			if err := a.validateInput(a.customQuestion.input, 5); err == nil {
 				a.customQuestion.currentQuestion.text = strings.TrimSpace(a.customQuestion.input)
@@ -393,7 +606,7 @@ func (a *App) handleCustomQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if err := a.validateInput(a.customQuestion.input, 1); err == nil {
 				a.customQuestion.currentQuestion.options[a.customQuestion.optionIndex] = strings.TrimSpace(a.customQuestion.input)
 				// Move to next option or finish
-				if a.customQuestion.optionIndex < 3 {
+				if a.customQuestion.optionIndex < len(a.customQuestion.currentQuestion.options)-1 {
 					a.customQuestion.optionIndex++
 					a.customQuestion.input = a.customQuestion.currentQuestion.options[a.customQuestion.optionIndex]
 					return a, nil // Stay in input mode for next option
@@ -408,6 +621,15 @@ func (a *App) handleCustomQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Cancel input
 		a.customQuestion.inputMode = ""
 		a.customQuestion.input = ""
+	case "shift+up", "shift+down":
+		// Reorder the option being edited
+		if a.customQuestion.inputMode == "option" {
+			offset := 1
+			if msg.String() == "shift+up" {
+				offset = -1
+			}
+			a.moveCurrentOption(offset)
+		}
 	case "backspace":
 		// Remove last character
 		if len(a.customQuestion.input) > 0 {
@@ -422,6 +644,72 @@ func (a *App) handleCustomQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// addOptionSlot adds an empty option to the question being built
+func (a *App) addOptionSlot() {
+	if len(a.customQuestion.currentQuestion.options) >= maxOptionCount {
+		a.customQuestion.errorMsg = fmt.Sprintf("Questions can have at most %d options", maxOptionCount)
+		return
+	}
+	a.customQuestion.currentQuestion.options = append(a.customQuestion.currentQuestion.options, "")
+}
+
+// removeOptionSlot removes the last option of the question being built
+func (a *App) removeOptionSlot() {
+	options := a.customQuestion.currentQuestion.options
+	if len(options) <= minOptionCount {
+		a.customQuestion.errorMsg = fmt.Sprintf("Questions need at least %d options", minOptionCount)
+		return
+	}
+	a.customQuestion.currentQuestion.options = options[:len(options)-1]
+}
+
+// moveCurrentOption swaps the option being edited with its neighbour, keeping
+// the typed text and updating the correct answer letters so they still point
+// at the same option text
+func (a *App) moveCurrentOption(offset int) {
+	q := &a.customQuestion.currentQuestion
+	from := a.customQuestion.optionIndex
+	to := from + offset
+	if to < 0 || to >= len(q.options) {
+		return
+	}
+	
+	q.options[from] = strings.TrimSpace(a.customQuestion.input)
+	q.options[from], q.options[to] = q.options[to], q.options[from]
+	q.correctAnswer = swapAnswerLetters(q.qType, q.correctAnswer, from, to)
+	
+	a.customQuestion.optionIndex = to
+	a.customQuestion.input = q.options[to]
+}
+
+// swapAnswerLetters exchanges the option letters at positions i and j in a
+// correct answer, for a single letter or a select-all-that-apply letter list
+func swapAnswerLetters(qType, answer string, i, j int) string {
+	letterI := string(rune('A' + i))
+	letterJ := string(rune('A' + j))
+	
+	if qType == "multi_select" {
+		selected := letterSet(answer)
+		selected[letterI], selected[letterJ] = selected[letterJ], selected[letterI]
+		var letters []string
+		for letter, ok := range selected {
+			if ok {
+				letters = append(letters, letter)
+			}
+		}
+		return normalizeSelectionAnswer(strings.Join(letters, ","))
+	}
+	
+	switch strings.ToUpper(strings.TrimSpace(answer)) {
+	case letterI:
+		return letterJ
+	case letterJ:
+		return letterI
+	default:
+		return answer
+	}
+}
+
 // cycleQuestionType cycles through question types
 func (a *App) cycleQuestionType() {
 	a.customQuestion.typeIndex = (a.customQuestion.typeIndex + 1) % len(a.customQuestion.questionTypes)
@@ -429,15 +717,78 @@ func (a *App) cycleQuestionType() {
 	
 	// Reset options based on type
 	switch a.customQuestion.currentQuestion.qType {
-	case "multiple_choice":
-		a.customQuestion.currentQuestion.options = make([]string, 4)
+	case "multiple_choice", "multi_select":
+		a.customQuestion.currentQuestion.options = make([]string, defaultOptionCount)
 	case "true_false":
 		a.customQuestion.currentQuestion.options = []string{}
-	case "short_answer":
+	case "short_answer", "fill_blank":
 		a.customQuestion.currentQuestion.options = []string{}
 	}
 }
 
+// customQuestionHasOptions reports whether the question being built takes lettered options
+func (a *App) customQuestionHasOptions() bool {
+	qType := a.customQuestion.currentQuestion.qType
+	return qType == "multiple_choice" || qType == "multi_select"
+}
+
+// customChoiceAnswer resolves the correct answer typed for a multiple choice
+// question, a letter or the text of an option, to the letter of a filled-in
+// option, so a question cannot be saved with an answer it does not offer
+func customChoiceAnswer(options []string, answer string) (string, error) {
+	last := -1
+	for i, option := range options {
+		if strings.TrimSpace(option) != "" {
+			last = i
+		}
+	}
+	
+	letter, err := normalizeChoiceAnswer(options, answer)
+	if err == nil && strings.TrimSpace(options[letter[0]-'A']) != "" {
+		return letter, nil
+	}
+	if err == nil {
+		return "", fmt.Errorf("Correct answer %s is an empty option; fill it in or choose another", letter)
+	}
+	
+	trimmed := strings.ToUpper(strings.TrimRight(strings.TrimSpace(answer), ").:"))
+	if len(trimmed) == 1 && trimmed[0] >= 'A' && trimmed[0] <= 'Z' {
+		return "", fmt.Errorf("Correct answer %s is not one of the options A-%c", trimmed, 'A'+last)
+	}
+	return "", fmt.Errorf("Correct answer %q matches no option; enter a letter A-%c or an option's text", strings.TrimSpace(answer), 'A'+last)
+}
+
+// customSelectionAnswer checks a select all that apply answer typed in the
+// builder and returns it as sorted option letters. Every letter must name an
+// option that has been filled in.
+func customSelectionAnswer(options []string, answer string) (string, error) {
+	if err := checkSelectionLetters(options, answer); err != nil {
+		return "", fmt.Errorf("Correct answer %q must list option letters, e.g. A,C", strings.TrimSpace(answer))
+	}
+	
+	normalized := normalizeSelectionAnswer(answer)
+	for _, letter := range strings.Split(normalized, ",") {
+		if strings.TrimSpace(options[letter[0]-'A']) == "" {
+			return "", fmt.Errorf("Correct answer %s is an empty option; fill it in or choose another", letter)
+		}
+	}
+	return normalized, nil
+}
+
+// compactOptions drops blank option slots and remaps the letters of answer to
+// the positions the remaining options move to
+func compactOptions(options []string, answer string) ([]string, string) {
+	var kept []string
+	position := make([]int, len(options))
+	for i, option := range options {
+		position[i] = len(kept)
+		if strings.TrimSpace(option) != "" {
+			kept = append(kept, option)
+		}
+	}
+	return kept, mapLetters(answer, position)
+}
+
 // saveCurrentQuestion saves the current question to the list
 func (a *App) saveCurrentQuestion() (tea.Model, tea.Cmd) {
 	// Validate question
@@ -451,8 +802,13 @@ func (a *App) saveCurrentQuestion() (tea.Model, tea.Cmd) {
 		return a, nil
 	}
 	
+	if a.customQuestion.requireExplanation && strings.TrimSpace(a.customQuestion.currentQuestion.explanation) == "" {
+		a.customQuestion.errorMsg = "Explanation is required"
+		return a, nil
+	}
+	
 	// Validate multiple choice options
-	if a.customQuestion.currentQuestion.qType == "multiple_choice" {
+	if a.customQuestionHasOptions() {
 		validOptions := 0
 		for _, opt := range a.customQuestion.currentQuestion.options {
 			if strings.TrimSpace(opt) != "" {
@@ -463,6 +819,10 @@ func (a *App) saveCurrentQuestion() (tea.Model, tea.Cmd) {
 			a.customQuestion.errorMsg = "Multiple choice questions need at least 2 options"
 			return a, nil
 		}
+		if err := validateOptions(a.customQuestion.currentQuestion.options); err != nil {
+			a.customQuestion.errorMsg = fmt.Sprintf("Duplicate options: %v", err)
+			return a, nil
+		}
 	}
 	
 	// Save question
@@ -472,25 +832,69 @@ func (a *App) saveCurrentQuestion() (tea.Model, tea.Cmd) {
 		Options:       make([]string, len(a.customQuestion.currentQuestion.options)),
 		CorrectAnswer: strings.TrimSpace(a.customQuestion.currentQuestion.correctAnswer),
 		Explanation:   strings.TrimSpace(a.customQuestion.currentQuestion.explanation),
+		Difficulty:    a.customQuestion.currentQuestion.difficulty,
 	}
 	
 	copy(question.Options, a.customQuestion.currentQuestion.options)
+	switch question.Type {
+	case "multiple_choice":
+		letter, err := customChoiceAnswer(question.Options, question.CorrectAnswer)
+		if err != nil {
+			a.customQuestion.errorMsg = err.Error()
+			return a, nil
+		}
+		question.CorrectAnswer = letter
+	case "multi_select":
+		letters, err := customSelectionAnswer(question.Options, question.CorrectAnswer)
+		if err != nil {
+			a.customQuestion.errorMsg = err.Error()
+			return a, nil
+		}
+		question.CorrectAnswer = letters
+	case "short_answer", "fill_blank":
+		accepted := acceptedAnswers(question.CorrectAnswer)
+		if len(accepted) == 0 {
+			a.customQuestion.errorMsg = "Correct answer is required"
+			return a, nil
+		}
+		question.CorrectAnswer = strings.Join(accepted, "|")
+	}
+	
+	// Blank option slots are not stored; the answer follows its options
+	if a.customQuestionHasOptions() {
+		question.Options, question.CorrectAnswer = compactOptions(question.Options, question.CorrectAnswer)
+	}
+	
+	// An edited question replaces the original and returns to the review
+	if index := a.customQuestion.editIndex; index >= 0 {
+		a.customQuestion.questions[index] = question
+		a.customQuestion.editIndex = -1
+		a.resetCurrentQuestion()
+		a.customQuestion.successMsg = fmt.Sprintf("Question %d updated", index+1)
+		a.customQuestion.step = 2
+		a.customQuestion.cursor = 0
+		return a, nil
+	}
+	
 	a.customQuestion.questions = append(a.customQuestion.questions, question)
+	a.resetCurrentQuestion()
 	
-	// Reset current question
+	a.customQuestion.successMsg = fmt.Sprintf("Question saved! (%d total)", len(a.customQuestion.questions))
+	a.customQuestion.cursor = 0
+	
+	return a, nil
+}
+
+// resetCurrentQuestion clears the question form, keeping the selected type
+func (a *App) resetCurrentQuestion() {
 	a.customQuestion.currentQuestion.text = ""
 	a.customQuestion.currentQuestion.correctAnswer = ""
 	a.customQuestion.currentQuestion.explanation = ""
-	if a.customQuestion.currentQuestion.qType == "multiple_choice" {
-		a.customQuestion.currentQuestion.options = make([]string, 4)
+	if a.customQuestionHasOptions() {
+		a.customQuestion.currentQuestion.options = make([]string, defaultOptionCount)
 	} else {
 		a.customQuestion.currentQuestion.options = []string{}
 	}
-	
-	a.customQuestion.successMsg = fmt.Sprintf("Question saved! (%d total)", len(a.customQuestion.questions))
-	a.customQuestion.cursor = 0
-	
-	return a, nil
 }
 
 // saveCustomTest saves the custom test to database
@@ -501,7 +905,16 @@ func (a *App) saveCustomTest() (tea.Model, tea.Cmd) {
 	}
 	
 	// Create test in database
-	test, err := a.db.CreateTest(a.customQuestion.testName, a.customQuestion.testDesc)
+	test, err := a.db.CreateTest(a.customQuestion.testName, a.customQuestion.testDesc, "", a.customQuestion.timeLimit*60)
+	if errors.Is(err, database.ErrDuplicateName) {
+		// Ask for another name, keeping the questions
+		a.customQuestion.errorMsg = fmt.Sprintf("A test named '%s' already exists, please enter a different name", a.customQuestion.testName)
+		a.customQuestion.step = 0
+		a.customQuestion.cursor = 0
+		a.customQuestion.inputMode = "test_name"
+		a.customQuestion.input = a.customQuestion.testName
+		return a, nil
+	}
 	if err != nil {
 		a.customQuestion.errorMsg = fmt.Sprintf("Failed to create test: %v", err)
 		return a, nil
@@ -509,7 +922,7 @@ func (a *App) saveCustomTest() (tea.Model, tea.Cmd) {
 	
 	// Save questions to database
 	for _, q := range a.customQuestion.questions {
-		_, err := a.db.CreateQuestion(test.ID, q.Text, q.Type, q.CorrectAnswer, q.Explanation, q.Options)
+		_, err := a.db.CreateQuestion(test.ID, q.Text, q.Type, q.CorrectAnswer, q.Explanation, q.Options, "", q.Difficulty)
 		if err != nil {
 			a.customQuestion.errorMsg = fmt.Sprintf("Failed to save question: %v", err)
 			return a, nil
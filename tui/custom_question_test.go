@@ -0,0 +1,351 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"pdf-test-generator/chatgpt"
+)
+
+// fillCustomQuestion sets up the builder's current question directly
+func fillCustomQuestion(a *App, qType string, options []string, answer string) {
+	a.customQuestion.step = 1
+	a.customQuestion.currentQuestion.text = "Which apply?"
+	a.customQuestion.currentQuestion.qType = qType
+	a.customQuestion.currentQuestion.options = options
+	a.customQuestion.currentQuestion.correctAnswer = answer
+}
+
+func TestSaveSelectAllChecksLetters(t *testing.T) {
+	options := []string{"Paris", "Rome", "", "Berlin"}
+	tests := []struct {
+		answer string
+		want   string // Stored answer, or "" when the question is rejected
+	}{
+		{"a, d", "A,C"}, // Berlin moves up into the empty slot
+		{"D,A,A", "A,C"},
+		{"Z", ""},
+		{"Paris", ""},
+		{"A,C", ""}, // C is an empty slot
+	}
+	for _, tt := range tests {
+		a := newTestApp(t)
+		fillCustomQuestion(a, "multi_select", append([]string(nil), options...), tt.answer)
+		a.saveCurrentQuestion()
+
+		if tt.want == "" {
+			if len(a.customQuestion.questions) != 0 || a.customQuestion.errorMsg == "" {
+				t.Errorf("answer %q was saved as %+v", tt.answer, a.customQuestion.questions)
+			}
+			continue
+		}
+		if len(a.customQuestion.questions) != 1 {
+			t.Errorf("answer %q was rejected: %s", tt.answer, a.customQuestion.errorMsg)
+			continue
+		}
+		saved := a.customQuestion.questions[0]
+		if saved.CorrectAnswer != tt.want {
+			t.Errorf("answer %q stored as %q, want %q", tt.answer, saved.CorrectAnswer, tt.want)
+		}
+		if strings.Join(saved.Options, "|") != "Paris|Rome|Berlin" {
+			t.Errorf("options stored as %q, want the blank slot dropped", saved.Options)
+		}
+	}
+}
+
+func TestCompactOptionsRemapsChoice(t *testing.T) {
+	options, answer := compactOptions([]string{"", "Paris", " ", "Rome"}, "D")
+	if strings.Join(options, "|") != "Paris|Rome" || answer != "B" {
+		t.Errorf("compacted to %q answered %q, want [Paris Rome] answered B", options, answer)
+	}
+}
+
+func TestSixOptionQuestionRendersAllOptions(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = CustomQuestionView
+	a.customQuestion.testName = "Planets"
+	fillCustomQuestion(a, "multiple_choice", make([]string, defaultOptionCount), "F")
+	a.customQuestion.cursor = 2
+	press(a, "+")
+	press(a, "+")
+	if n := len(a.customQuestion.currentQuestion.options); n != 6 {
+		t.Fatalf("%d option slots after adding two, want 6", n)
+	}
+	planets := []string{"Mercury", "Venus", "Earth", "Mars", "Jupiter", "Saturn"}
+	copy(a.customQuestion.currentQuestion.options, planets)
+	a.saveCurrentQuestion()
+	if len(a.customQuestion.questions) != 1 {
+		t.Fatalf("question not saved: %s", a.customQuestion.errorMsg)
+	}
+	a.saveCustomTest()
+
+	tests, err := a.db.GetAllTests()
+	if err != nil || len(tests) != 1 {
+		t.Fatalf("saved tests = %v, %v", tests, err)
+	}
+	questions, err := a.db.GetQuestionsByTestID(tests[0].ID)
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("saved questions = %v, %v", questions, err)
+	}
+	if len(questions[0].Options) != 6 || questions[0].CorrectAnswer != "F" {
+		t.Fatalf("stored %q answered %q, want six options answered F", questions[0].Options, questions[0].CorrectAnswer)
+	}
+
+	a.startTest(tests[0], questions)
+	view := a.viewMultipleChoice(a.currentQuestions[0])
+	for i, option := range a.currentQuestions[0].Options {
+		if !strings.Contains(view, fmt.Sprintf("%c) ", 'A'+i)) || !strings.Contains(view, option) {
+			t.Errorf("option %c) %s is not rendered:\n%s", 'A'+i, option, view)
+		}
+	}
+	for _, planet := range planets {
+		if !strings.Contains(view, planet) {
+			t.Errorf("%s is not rendered", planet)
+		}
+	}
+}
+
+func TestQuestionPreviewMultibyte(t *testing.T) {
+	a := newTestApp(t)
+	fillCustomQuestion(a, "short_answer", nil, "")
+	a.customQuestion.currentQuestion.text = strings.Repeat("日本語—é", 30)
+
+	view := a.viewQuestionCreationStep()
+	if !utf8.ValidString(view) {
+		t.Fatal("the builder view is not valid UTF-8")
+	}
+	if !strings.Contains(view, strings.Repeat("日本語—é", 10)+"...") {
+		t.Errorf("question preview is not cut after 50 runes:\n%s", view)
+	}
+}
+
+func TestDuplicateOptionsRejected(t *testing.T) {
+	if err := validateOptions([]string{"Paris", "Rome", "", " "}); err != nil {
+		t.Errorf("blank options counted as duplicates: %v", err)
+	}
+	if err := validateOptions([]string{"Paris", "Rome", " paris "}); err == nil || !strings.Contains(err.Error(), "A and C") {
+		t.Errorf("validateOptions = %v, want options A and C reported", err)
+	}
+
+	a := newTestApp(t)
+	fillCustomQuestion(a, "multiple_choice", []string{"Paris", "ROME", "rome"}, "A")
+	a.saveCurrentQuestion()
+	if len(a.customQuestion.questions) != 0 || a.customQuestion.errorMsg == "" {
+		t.Errorf("question with repeated options was saved as %+v", a.customQuestion.questions)
+	}
+
+	_, err := importQuestion(&chatgpt.GeneratedQuestion{Question: "Capital?", Type: "multiple_choice", Options: []string{"Paris", "Paris "}, CorrectAnswer: "A"})
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("importQuestion = %v, want a duplicate options error", err)
+	}
+	err = normalizeGeneratedAnswers([]*chatgpt.GeneratedQuestion{{Question: "Capital?", Type: "multi_select", Options: []string{"Paris", "Rome", "paris"}, CorrectAnswer: "A,B"}})
+	if err == nil {
+		t.Error("generated question with repeated options was accepted")
+	}
+}
+
+// reviewQuestions opens the builder's review step with the given questions
+func reviewQuestions(a *App, questions ...QuestionData) {
+	a.currentView = CustomQuestionView
+	a.customQuestion.testName = "Built"
+	a.customQuestion.questions = questions
+	a.customQuestion.step = 2
+}
+
+// questionDataTexts lists the texts of the builder's questions
+func questionDataTexts(a *App) string {
+	var texts []string
+	for _, q := range a.customQuestion.questions {
+		texts = append(texts, q.Text)
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestEditQuestionInReview(t *testing.T) {
+	a := newTestApp(t)
+	reviewQuestions(a,
+		QuestionData{Text: "First question", Type: "short_answer", CorrectAnswer: "one"},
+		QuestionData{Text: "Second question", Type: "short_answer", CorrectAnswer: "two"},
+		QuestionData{Text: "Third question", Type: "short_answer", CorrectAnswer: "three"},
+	)
+
+	press(a, "down")
+	press(a, "e")
+	if a.customQuestion.step != 1 || a.customQuestion.currentQuestion.text != "Second question" {
+		t.Fatalf("step %d with text %q, want question 2 loaded for editing", a.customQuestion.step, a.customQuestion.currentQuestion.text)
+	}
+
+	// Change the question text and answer, then save it back
+	press(a, "down")
+	press(a, "q")
+	typeText(a, " edited")
+	press(a, "enter")
+	press(a, "down")
+	press(a, "down")
+	press(a, "a")
+	press(a, "backspace")
+	press(a, "backspace")
+	press(a, "backspace")
+	typeText(a, "2")
+	press(a, "enter")
+	press(a, "s")
+
+	if a.customQuestion.step != 2 || a.customQuestion.editIndex != -1 {
+		t.Fatalf("after saving the edit: step %d, edit index %d (%s)", a.customQuestion.step, a.customQuestion.editIndex, a.customQuestion.errorMsg)
+	}
+	if got := questionDataTexts(a); got != "First question,Second question edited,Third question" {
+		t.Errorf("questions after editing = %s", got)
+	}
+	if got := a.customQuestion.questions[1].CorrectAnswer; got != "2" {
+		t.Errorf("edited answer = %q, want 2", got)
+	}
+
+	// Deleting the highlighted question shortens the list
+	press(a, "x")
+	if got := questionDataTexts(a); got != "First question,Third question" {
+		t.Errorf("questions after deleting = %s", got)
+	}
+}
+
+func TestRequireExplanation(t *testing.T) {
+	for _, required := range []bool{true, false} {
+		a := newTestApp(t)
+		a.currentView = CustomQuestionView
+		for range 3 {
+			press(a, "down")
+		}
+		if required {
+			press(a, "r")
+		}
+		if a.customQuestion.requireExplanation != required {
+			t.Fatalf("require explanation = %v, want %v", a.customQuestion.requireExplanation, required)
+		}
+
+		fillCustomQuestion(a, "short_answer", nil, "Paris")
+		a.saveCurrentQuestion()
+		if required {
+			if len(a.customQuestion.questions) != 0 || a.customQuestion.errorMsg != "Explanation is required" {
+				t.Errorf("required: saved %d questions with error %q, want the question rejected", len(a.customQuestion.questions), a.customQuestion.errorMsg)
+			}
+			a.customQuestion.currentQuestion.explanation = "  "
+			a.saveCurrentQuestion()
+			if len(a.customQuestion.questions) != 0 {
+				t.Error("required: a blank explanation was accepted")
+			}
+			a.customQuestion.currentQuestion.explanation = "Paris is the capital of France."
+			a.saveCurrentQuestion()
+		}
+		if len(a.customQuestion.questions) != 1 {
+			t.Errorf("require explanation %v: question not saved: %s", required, a.customQuestion.errorMsg)
+		}
+	}
+}
+
+func TestSaveMultipleChoiceChecksAnswer(t *testing.T) {
+	options := []string{"Paris", "Rome", "Berlin", ""}
+	tests := []struct {
+		answer string
+		want   string // Stored letter, or the error when the question is rejected
+	}{
+		{"b", "B"},
+		{"C)", "C"},
+		{" berlin ", "C"},
+		{"D", "Correct answer D is an empty option; fill it in or choose another"},
+		{"E", "Correct answer E is not one of the options A-C"},
+		{"Madrid", `Correct answer "Madrid" matches no option; enter a letter A-C or an option's text`},
+	}
+	for _, tt := range tests {
+		a := newTestApp(t)
+		fillCustomQuestion(a, "multiple_choice", append([]string(nil), options...), tt.answer)
+		a.saveCurrentQuestion()
+
+		if len(tt.want) > 1 {
+			if len(a.customQuestion.questions) != 0 || a.customQuestion.errorMsg != tt.want {
+				t.Errorf("answer %q: saved %d questions with error %q, want %q", tt.answer, len(a.customQuestion.questions), a.customQuestion.errorMsg, tt.want)
+			}
+			continue
+		}
+		if len(a.customQuestion.questions) != 1 {
+			t.Errorf("answer %q was rejected: %s", tt.answer, a.customQuestion.errorMsg)
+			continue
+		}
+		if got := a.customQuestion.questions[0].CorrectAnswer; got != tt.want {
+			t.Errorf("answer %q stored as %q, want %q", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestCustomQuestionDifficulty(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = CustomQuestionView
+	a.customQuestion.testName = "Built"
+	fillCustomQuestion(a, "short_answer", nil, "answer")
+	a.customQuestion.cursor = 5
+
+	// 'v' cycles through the levels and back to not rated
+	for _, want := range []string{"easy", "medium", "hard", "", "easy", "medium", "hard"} {
+		press(a, "v")
+		if got := a.customQuestion.currentQuestion.difficulty; got != want {
+			t.Fatalf("difficulty = %q after 'v', want %q", got, want)
+		}
+	}
+	if view := a.viewCustomQuestion(); !strings.Contains(view, "Difficulty: Hard") {
+		t.Errorf("difficulty not shown:\n%s", view)
+	}
+
+	a.saveCurrentQuestion()
+	if len(a.customQuestion.questions) != 1 {
+		t.Fatalf("question not saved: %s", a.customQuestion.errorMsg)
+	}
+	a.saveCustomTest()
+	tests, err := a.db.GetAllTests()
+	if err != nil || len(tests) != 1 {
+		t.Fatalf("saved tests = %v, %v", tests, err)
+	}
+	questions, err := a.db.GetQuestionsByDifficulty(tests[0].ID, "hard")
+	if err != nil || len(questions) != 1 {
+		t.Errorf("hard questions of the saved test = %v, %v; want the question", questions, err)
+	}
+}
+
+func TestSaveCustomTestDuplicateName(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Built", shortAnswers("Q1")...)
+	reviewQuestions(a, QuestionData{Text: "New question", Type: "short_answer", CorrectAnswer: "answer"})
+
+	a.saveCustomTest()
+	if a.customQuestion.inputMode != "test_name" || !strings.Contains(a.customQuestion.errorMsg, "already exists") {
+		t.Fatalf("duplicate name not refused: mode %q, error %q", a.customQuestion.inputMode, a.customQuestion.errorMsg)
+	}
+	if len(a.customQuestion.questions) != 1 {
+		t.Fatalf("questions lost when the name was refused: %+v", a.customQuestion.questions)
+	}
+
+	// Blank names are refused too
+	a.customQuestion.input = "   "
+	press(a, "enter")
+	if a.customQuestion.testName != "Built" || a.customQuestion.errorMsg == "" {
+		t.Errorf("blank name accepted as %q", a.customQuestion.testName)
+	}
+
+	a.customQuestion.inputMode = "test_name"
+	a.customQuestion.input = "Built 2"
+	press(a, "enter")
+	a.saveCustomTest()
+	tests, err := a.db.GetAllTests()
+	if err != nil || len(tests) != 2 {
+		t.Fatalf("saved tests = %v, %v; want two", tests, err)
+	}
+	for _, test := range tests {
+		if test.Name != "Built 2" {
+			continue
+		}
+		if count, err := a.db.CountQuestions(test.ID); err != nil || count != 1 {
+			t.Errorf("Built 2 has %d questions (%v), want 1", count, err)
+		}
+		return
+	}
+	t.Errorf("no test saved as Built 2: %+v", tests)
+}
@@ -4,20 +4,35 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"pdf-test-generator/pdf"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // FileSelectionModel represents the file selection state
 type FileSelectionModel struct {
-	files       []string
+	files       []FileEntry // Directories first, then files of the selected kind
 	cursor      int
 	currentDir  string
-	purpose     string // "pdf_generation" or other purposes
+	purpose     string // "pdf_generation" or "import"
 	errorMsg    string
 	loading     bool
 	inputMode   bool
 	input       string
+	
+	// Batch validation results for the current directory
+	showValidation bool
+	validation     []PDFValidationData
+}
+
+// PDFValidationData represents the validation outcome for a single PDF
+type PDFValidationData struct {
+	Path   string
+	Pages  int
+	Status string // "readable", "no_text", "invalid"
+	Detail string
 }
 
 // NewFileSelectionModel creates a new file selection model
@@ -25,8 +40,37 @@ func NewFileSelectionModel() *FileSelectionModel {
 	homeDir, _ := os.UserHomeDir()
 	return &FileSelectionModel{
 		currentDir: homeDir,
-		files:      []string{},
+		files:      []FileEntry{},
+	}
+}
+
+// startingPDFDirectory picks the directory file selection opens in: the last
+// directory a PDF was chosen from, then the configured default, then home
+func (a *App) startingPDFDirectory() string {
+	for _, dir := range []string{a.settingValue(settingLastPDFDir), a.settingValue(settingDefaultPDFDir)} {
+		if dir == "" {
+			continue
+		}
+		dir = expandHome(dir)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	
+	homeDir, _ := os.UserHomeDir()
+	return homeDir
+}
+
+// expandHome replaces a leading ~ in a path with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
 	}
+	return filepath.Join(homeDir, path[1:])
 }
 
 // updateFileSelection handles file selection updates
@@ -37,6 +81,18 @@ func (a *App) updateFileSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.handleFileInputMode(msg)
 		}
 		
+		if a.fileSelection.loading {
+			return a, nil // Ignore input while the files are checked
+		}
+		
+		if a.fileSelection.showValidation {
+			switch msg.String() {
+			case "v", "b":
+				a.fileSelection.showValidation = false
+			}
+			return a, nil
+		}
+		
 		switch msg.String() {
 		case "up", "k":
 			if a.fileSelection.cursor > 0 {
@@ -55,6 +111,11 @@ func (a *App) updateFileSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Change directory
 			a.fileSelection.inputMode = true
 			a.fileSelection.input = a.fileSelection.currentDir
+		case "v":
+			// Validate every PDF in the directory
+			if a.countListedFiles() > 0 && a.fileSelection.purpose == "pdf_generation" {
+				return a, a.validatePDFDirectory()
+			}
 		}
 	}
 	return a, nil
@@ -62,7 +123,8 @@ func (a *App) updateFileSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // viewFileSelection renders the file selection view
 func (a *App) viewFileSelection() string {
-	s := a.renderHeader("Select PDF File")
+	kind := a.selectionFileKind()
+	s := a.renderHeader("Select " + kind + " File")
 	
 	if a.fileSelection.inputMode {
 		s += "Enter directory path:\n"
@@ -78,22 +140,45 @@ func (a *App) viewFileSelection() string {
 	
 	s += fmt.Sprintf("Current directory: %s\n\n", a.fileSelection.currentDir)
 	
+	if a.fileSelection.loading {
+		s += fmt.Sprintf("⏳ Checking %d file(s)...\n", a.countListedFiles())
+		return s + a.renderFooter()
+	}
+	
+	if a.fileSelection.showValidation {
+		return s + a.viewPDFValidation() + a.renderFooter()
+	}
+	
+	if a.countListedFiles() == 0 {
+		s += fmt.Sprintf("No %s files found in this directory.\n\n", kind)
+	}
+	
 	if len(a.fileSelection.files) == 0 {
-		s += "No PDF files found in this directory.\n\n"
 		s += "Press 'c' to change directory, 'r' to refresh\n"
 	} else {
-		s += "PDF Files:\n\n"
-		for i, file := range a.fileSelection.files {
+		for i, entry := range a.fileSelection.files {
+			name := "📁 " + entry.Name
+			if !entry.IsDir {
+				if runes := []rune(entry.Name); len(runes) > 40 {
+					entry.Name = string(runes[:37]) + "..."
+				}
+				name = fmt.Sprintf("%-40s %9s  %s", entry.Name, formatFileSize(entry.Size), entry.ModTime.Format("Jan 2, 2006 3:04 PM"))
+			}
+			
 			cursor := " "
 			if a.fileSelection.cursor == i {
 				cursor = ">"
 				style := selectedStyle
-				s += fmt.Sprintf("%s %s\n", cursor, style.Render(filepath.Base(file)))
+				s += fmt.Sprintf("%s %s\n", cursor, style.Render(name))
 			} else {
-				s += fmt.Sprintf("%s %s\n", cursor, filepath.Base(file))
+				s += fmt.Sprintf("%s %s\n", cursor, name)
 			}
 		}
-		s += "\nPress Enter to select, 'c' to change directory, 'r' to refresh\n"
+		s += "\nPress Enter to open a folder or select a file, 'c' to change directory, 'r' to refresh"
+		if a.fileSelection.purpose == "pdf_generation" && a.countListedFiles() > 0 {
+			s += ", 'v' to validate all"
+		}
+		s += "\n"
 	}
 	
 	return s + a.renderFooter()
@@ -136,34 +221,180 @@ func (a *App) handleFileSelection() (tea.Model, tea.Cmd) {
 		return a, nil
 	}
 	
-	selectedFile := a.fileSelection.files[a.fileSelection.cursor]
+	entry := a.fileSelection.files[a.fileSelection.cursor]
+	if entry.IsDir {
+		a.fileSelection.currentDir = entry.Path
+		a.refreshFileList()
+		return a, nil
+	}
+	selectedFile := entry.Path
 	
 	switch a.fileSelection.purpose {
 	case "pdf_generation":
 		// Process PDF for question generation
 		a.pdfProcess.selectedFile = selectedFile
+		a.loadPDFInfo()
+		a.saveSetting(settingLastPDFDir, a.fileSelection.currentDir)
 		a.currentView = PDFProcessView
 		return a, nil
+	case "import":
+		// Preview the tests in the file before anything is saved
+		return a.loadImportPreview(selectedFile)
 	default:
 		return a, nil
 	}
 }
 
-// refreshFileList refreshes the list of PDF files in current directory
+// selectionFileKind names the kind of file being picked for the current purpose
+func (a *App) selectionFileKind() string {
+	if a.fileSelection.purpose == "import" {
+		return "JSON"
+	}
+	return "PDF/text"
+}
+
+// selectionExtensions lists the extensions of the files that can be picked
+// for the current purpose
+func (a *App) selectionExtensions() []string {
+	if a.fileSelection.purpose == "import" {
+		return []string{".json"}
+	}
+	return pdf.SupportedExtensions
+}
+
+// refreshFileList refreshes the list of subdirectories and files of the
+// selected kind in current directory
 func (a *App) refreshFileList() {
-	files, err := a.listPDFFiles(a.fileSelection.currentDir)
+	files, err := a.listDirectory(a.fileSelection.currentDir, a.selectionExtensions())
 	if err != nil {
 		a.fileSelection.errorMsg = fmt.Sprintf("Error reading directory: %v", err)
-		a.fileSelection.files = []string{}
+		a.fileSelection.files = []FileEntry{}
 	} else {
 		a.fileSelection.files = files
 	}
 	a.fileSelection.cursor = 0
 }
 
+// countListedFiles returns the number of listed entries that are files
+func (a *App) countListedFiles() int {
+	count := 0
+	for _, entry := range a.fileSelection.files {
+		if !entry.IsDir {
+			count++
+		}
+	}
+	return count
+}
+
 // Initialize file list when entering this view
 func (a *App) initFileSelection() {
 	if len(a.fileSelection.files) == 0 {
 		a.refreshFileList()
 	}
-}
\ No newline at end of file
+}
+// pdfValidatedMsg delivers the results of checking the files listed in dir
+// in the background
+type pdfValidatedMsg struct {
+	dir     string
+	results []PDFValidationData
+}
+
+// validatePDFDirectory checks every listed PDF and text file for readability
+// and page count in the background
+func (a *App) validatePDFDirectory() tea.Cmd {
+	var files []string
+	for _, entry := range a.fileSelection.files {
+		if !entry.IsDir {
+			files = append(files, entry.Path)
+		}
+	}
+	
+	processor := a.pdfProcessor
+	dir := a.fileSelection.currentDir
+	a.fileSelection.loading = true
+	return func() tea.Msg {
+		results := make([]PDFValidationData, 0, len(files))
+		for _, file := range files {
+			results = append(results, validateFile(processor, file))
+		}
+		return pdfValidatedMsg{dir: dir, results: results}
+	}
+}
+
+// validateFile checks whether a PDF or text file can be opened and has text
+// to generate questions from
+func validateFile(processor *pdf.PDFProcessor, file string) PDFValidationData {
+	result := PDFValidationData{Path: file}
+	
+	if err := processor.ValidatePDF(file); err != nil {
+		result.Status = "invalid"
+		result.Detail = err.Error()
+		return result
+	}
+	
+	if info, err := processor.GetPDFInfo(file); err == nil {
+		if pages, ok := info["pages"].(int); ok {
+			result.Pages = pages
+		}
+	}
+	
+	hasText, err := processor.HasExtractableText(file, 3)
+	switch {
+	case err != nil:
+		result.Status = "invalid"
+		result.Detail = err.Error()
+	case hasText:
+		result.Status = "readable"
+	default:
+		result.Status = "no_text"
+		result.Detail = "scanned or empty"
+	}
+	return result
+}
+
+// handlePDFValidated shows the results of checking a directory, unless the
+// user has moved to another directory since
+func (a *App) handlePDFValidated(msg pdfValidatedMsg) (tea.Model, tea.Cmd) {
+	a.fileSelection.loading = false
+	if msg.dir != a.fileSelection.currentDir {
+		return a, nil
+	}
+	a.fileSelection.validation = msg.results
+	a.fileSelection.showValidation = true
+	return a, nil
+}
+
+// viewPDFValidation renders the batch validation results as a table
+func (a *App) viewPDFValidation() string {
+	readable := 0
+	for _, result := range a.fileSelection.validation {
+		if result.Status == "readable" {
+			readable++
+		}
+	}
+	
+	s := fmt.Sprintf("Checked %d file(s), %d readable:\n\n", len(a.fileSelection.validation), readable)
+	s += fmt.Sprintf("  %-40s %6s  %s\n", "File", "Pages", "Status")
+	
+	for _, result := range a.fileSelection.validation {
+		name := filepath.Base(result.Path)
+		if runes := []rune(name); len(runes) > 40 {
+			name = string(runes[:37]) + "..."
+		}
+		
+		var status string
+		switch result.Status {
+		case "readable":
+			status = successStyle.Render("✓ readable")
+		case "no_text":
+			status = errorStyle.Render("✗ no text (" + result.Detail + ")")
+		default:
+			status = errorStyle.Render("✗ invalid: " + result.Detail)
+		}
+		
+		s += fmt.Sprintf("  %-40s %6d  %s\n", name, result.Pages, status)
+	}
+	
+	s += "\nPress 'v' or 'b' to return to the file list\n"
+	return s
+}
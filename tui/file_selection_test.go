@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePDFDirectory(t *testing.T) {
+	a := newTestApp(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"notes.txt":  "The mitochondria is the powerhouse of the cell.",
+		"empty.txt":  "",
+		"broken.pdf": "not a PDF",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.currentView = FileSelectionView
+	a.fileSelection.purpose = "pdf_generation"
+	a.fileSelection.currentDir = dir
+	a.refreshFileList()
+
+	cmd := press(a, "v")
+	if cmd == nil || !a.fileSelection.loading || a.fileSelection.showValidation {
+		t.Fatal("'v' did not start checking the files in the background")
+	}
+	runCmd(t, a, cmd)
+	if a.fileSelection.loading || !a.fileSelection.showValidation {
+		t.Fatal("the check results are not shown")
+	}
+
+	statuses := make(map[string]string)
+	for _, result := range a.fileSelection.validation {
+		statuses[filepath.Base(result.Path)] = result.Status
+	}
+	want := map[string]string{"notes.txt": "readable", "empty.txt": "no_text", "broken.pdf": "invalid"}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s is %q, want %q", name, statuses[name], status)
+		}
+	}
+}
+
+// listedNames lists the names of the entries shown in file selection
+func listedNames(a *App) string {
+	var names []string
+	for _, entry := range a.fileSelection.files {
+		names = append(names, entry.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestFileSelectionEntersDirectories(t *testing.T) {
+	a := newTestApp(t)
+	root := t.TempDir()
+	for _, dir := range []string{"chapters", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"intro.pdf", "notes.docx", filepath.Join("chapters", "cells.pdf")} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("%PDF"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.currentView = FileSelectionView
+	a.fileSelection.purpose = "pdf_generation"
+	a.fileSelection.currentDir = root
+	a.refreshFileList()
+	if got := listedNames(a); got != "..,chapters,intro.pdf" {
+		t.Fatalf("listed %s, want the parent, the visible folder and the PDF", got)
+	}
+	if view := a.viewFileSelection(); !strings.Contains(view, "📁 chapters") {
+		t.Errorf("folders are not marked:\n%s", view)
+	}
+
+	press(a, "down")
+	press(a, "enter")
+	if a.fileSelection.currentDir != filepath.Join(root, "chapters") || a.currentView != FileSelectionView {
+		t.Fatalf("entering the folder moved to %s in view %s", a.fileSelection.currentDir, a.currentView)
+	}
+	if got := listedNames(a); got != "..,cells.pdf" {
+		t.Errorf("listed %s in the folder", got)
+	}
+
+	press(a, "enter") // ..
+	if a.fileSelection.currentDir != root {
+		t.Errorf("going up moved to %s, want %s", a.fileSelection.currentDir, root)
+	}
+}
+
+func TestFormatFileSize(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KB",
+		5 * 1024 * 1024: "5.0 MB",
+		3 << 40:         "3072.0 GB",
+	}
+	for size, want := range tests {
+		if got := formatFileSize(size); got != want {
+			t.Errorf("formatFileSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestFileListNewestFirst(t *testing.T) {
+	a := newTestApp(t)
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"old.pdf", "newest.pdf", "middle.pdf"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 1536*(i+1))), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-time.Duration([]int{48, 0, 24}[i]) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.currentView = FileSelectionView
+	a.fileSelection.purpose = "pdf_generation"
+	a.fileSelection.currentDir = dir
+	a.refreshFileList()
+	if got := listedNames(a); got != "..,newest.pdf,middle.pdf,old.pdf" {
+		t.Errorf("listed %s, want the most recently modified first", got)
+	}
+	if view := a.viewFileSelection(); !strings.Contains(view, "1.5 KB") || !strings.Contains(view, "3.0 KB") {
+		t.Errorf("file sizes are not shown:\n%s", view)
+	}
+}
+
+func TestFileSelectionListsTextNotes(t *testing.T) {
+	a := newTestApp(t)
+	dir := t.TempDir()
+	for _, name := range []string{"cells.pdf", "lecture.md", "notes.txt", "slides.pptx", "export.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("Cells"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listed := func(purpose string) []string {
+		a.fileSelection.purpose = purpose
+		a.fileSelection.currentDir = dir
+		a.refreshFileList()
+		names := strings.Split(listedNames(a), ",")
+		sort.Strings(names)
+		return names
+	}
+
+	a.currentView = FileSelectionView
+	if got := strings.Join(listed("pdf_generation"), ","); got != "..,cells.pdf,lecture.md,notes.txt" {
+		t.Errorf("listed %s for generation, want the PDF and text notes", got)
+	}
+	if got := strings.Join(listed("import"), ","); got != "..,export.json" {
+		t.Errorf("listed %s for import, want the JSON file", got)
+	}
+}
+
+func TestStartingPDFDirectory(t *testing.T) {
+	a := newTestApp(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, dir := range []string{"pdfs", "chapters"} {
+		if err := os.Mkdir(filepath.Join(home, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := a.startingPDFDirectory(); got != home {
+		t.Errorf("with nothing set, starts in %s, want home %s", got, home)
+	}
+
+	a.saveSetting(settingDefaultPDFDir, "~/pdfs")
+	if got, want := a.startingPDFDirectory(), filepath.Join(home, "pdfs"); got != want {
+		t.Errorf("with a default directory, starts in %s, want %s", got, want)
+	}
+
+	// Choosing a PDF remembers its directory, which wins over the default
+	chapters := filepath.Join(home, "chapters")
+	if err := os.WriteFile(filepath.Join(chapters, "cells.pdf"), []byte("%PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a.currentView = FileSelectionView
+	a.fileSelection.purpose = "pdf_generation"
+	a.fileSelection.currentDir = chapters
+	a.refreshFileList()
+	press(a, "down") // past ..
+	press(a, "enter")
+	if a.currentView != PDFProcessView {
+		t.Fatalf("choosing the PDF moved to view %s", a.currentView)
+	}
+	if got := a.startingPDFDirectory(); got != chapters {
+		t.Errorf("after choosing a PDF, starts in %s, want %s", got, chapters)
+	}
+
+	// A remembered directory that is gone falls back to the default, then home
+	if err := os.RemoveAll(chapters); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a.startingPDFDirectory(), filepath.Join(home, "pdfs"); got != want {
+		t.Errorf("with the last directory gone, starts in %s, want the default %s", got, want)
+	}
+	if err := os.RemoveAll(filepath.Join(home, "pdfs")); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.startingPDFDirectory(); got != home {
+		t.Errorf("with both directories gone, starts in %s, want home %s", got, home)
+	}
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StudyModel represents the flashcard study state. Nothing is scored or
+// saved; each card shows a question and flips to its answer on a keypress.
+type StudyModel struct {
+	test      *database.Test
+	questions []*database.Question
+	current   int
+	revealed  bool // The answer side of the current card is shown
+	finished  bool // Every card has been seen
+	errorMsg  string
+}
+
+// NewStudyModel creates a new flashcard study model
+func NewStudyModel() *StudyModel {
+	return &StudyModel{}
+}
+
+// startStudy opens a test's questions as a deck of flashcards
+func (a *App) startStudy(test *database.Test) (tea.Model, tea.Cmd) {
+	questions, err := a.db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return a, nil
+	}
+
+	if len(questions) == 0 {
+		a.testSelection.errorMsg = "This test has no questions"
+		return a, nil
+	}
+
+	a.study = &StudyModel{test: test, questions: questions}
+	a.currentView = StudyView
+	return a, nil
+}
+
+// updateStudy handles flashcard updates: space or enter flips a card to its
+// answer, and space on a flipped card moves on to the next one
+func (a *App) updateStudy(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if a.study.finished {
+			switch msg.String() {
+			case "r":
+				a.study.current = 0
+				a.study.revealed = false
+				a.study.finished = false
+			case "q", "enter":
+				a.currentView = MainMenuView
+			}
+			return a, nil
+		}
+
+		switch msg.String() {
+		case " ", "enter":
+			if !a.study.revealed {
+				a.study.revealed = true
+			} else if msg.String() == " " {
+				a.nextFlashcard()
+			}
+		case "left", "h":
+			if a.study.current > 0 {
+				a.study.current--
+				a.study.revealed = false
+			}
+		case "s":
+			if err := a.toggleStar(a.study.questions[a.study.current].ID); err != nil {
+				a.study.errorMsg = err.Error()
+			}
+		case "q":
+			a.currentView = MainMenuView
+		}
+	}
+	return a, nil
+}
+
+// nextFlashcard advances to the next card, or ends the deck after the last one
+func (a *App) nextFlashcard() {
+	a.study.revealed = false
+	if a.study.current < len(a.study.questions)-1 {
+		a.study.current++
+		return
+	}
+	a.study.finished = true
+}
+
+// viewStudy renders the current flashcard
+func (a *App) viewStudy() string {
+	s := a.renderHeader(fmt.Sprintf("Studying: %s", a.study.test.Name))
+
+	if a.study.errorMsg != "" {
+		s += a.renderError(a.study.errorMsg)
+		a.study.errorMsg = ""
+	}
+
+	if a.study.finished {
+		s += fmt.Sprintf("🎉 You went through all %d cards!\n\n", len(a.study.questions))
+		s += "Press 'r' to study the deck again, Enter or 'q' to return to main menu\n"
+		return s + a.renderFooter()
+	}
+
+	q := a.study.questions[a.study.current]
+
+	s += fmt.Sprintf("Card %d of %d • %s\n", a.study.current+1, len(a.study.questions), a.getQuestionTypeDisplay(q.QuestionType))
+	if a.studyList[q.ID] {
+		s += infoStyle.Render("★ Starred for study") + "\n"
+	}
+	s += "\n"
+
+	card := q.QuestionText + "\n"
+	if q.QuestionType == "multiple_choice" || q.QuestionType == "multi_select" {
+		card += "\n"
+		for i, option := range q.Options {
+			card += fmt.Sprintf("%c) %s\n", 'A'+i, option)
+		}
+	}
+
+	if a.study.revealed {
+		card += "\n" + successStyle.Render("Answer: "+formatKeyAnswer(q)) + "\n"
+		if q.Explanation != "" {
+			card += fmt.Sprintf("\nExplanation: %s\n", q.Explanation)
+		}
+	}
+	s += borderStyle.Render(card) + "\n\n"
+
+	if a.study.revealed {
+		s += "Space for the next card • ← Previous card • 's' to star for study\n"
+	} else {
+		s += "Space or Enter to reveal the answer • ← Previous card • 's' to star for study\n"
+	}
+	s += "Press 'q' to return to main menu\n"
+
+	return s + a.renderFooter()
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"pdf-test-generator/database"
+)
+
+func TestFlashcardFlipAndAdvance(t *testing.T) {
+	a := newTestApp(t)
+	questions := []*database.Question{
+		{QuestionText: "Powerhouse of the cell?", QuestionType: "multiple_choice", Options: []string{"Nucleus", "Mitochondria"}, CorrectAnswer: "B", Explanation: "It makes ATP."},
+		{QuestionText: "Cells divide by?", QuestionType: "short_answer", CorrectAnswer: "mitosis"},
+	}
+	test := createTest(t, a, "Biology", questions...)
+	openTestList(t, a, "study")
+	press(a, "enter")
+	if a.currentView != StudyView {
+		t.Fatalf("view %s, want the flashcards", a.currentView)
+	}
+
+	card := func(current int, revealed, finished bool) {
+		t.Helper()
+		if a.study.current != current || a.study.revealed != revealed || a.study.finished != finished {
+			t.Fatalf("card %d revealed %v finished %v, want card %d revealed %v finished %v",
+				a.study.current, a.study.revealed, a.study.finished, current, revealed, finished)
+		}
+	}
+
+	card(0, false, false)
+	if view := a.viewStudy(); strings.Contains(view, "Answer:") || !strings.Contains(view, "Card 1 of 2 • Multiple Choice") {
+		t.Errorf("front of the first card:\n%s", view)
+	}
+	press(a, " ")
+	card(0, true, false)
+	if view := a.viewStudy(); !strings.Contains(view, "Answer: B) Mitochondria") || !strings.Contains(view, "It makes ATP.") {
+		t.Errorf("back of the first card:\n%s", view)
+	}
+	press(a, "enter") // Enter only flips, it does not advance
+	card(0, true, false)
+	press(a, " ")
+	card(1, false, false)
+
+	press(a, "left")
+	card(0, false, false)
+	press(a, " ")
+	press(a, " ")
+	press(a, "enter")
+	card(1, true, false)
+	if view := a.viewStudy(); !strings.Contains(view, "Answer: mitosis") {
+		t.Errorf("back of the second card:\n%s", view)
+	}
+	press(a, " ")
+	card(1, false, true)
+
+	press(a, "r")
+	card(0, false, false)
+	press(a, "q")
+	if a.currentView != MainMenuView {
+		t.Errorf("view after q = %s, want the main menu", a.currentView)
+	}
+	if results, _ := a.db.GetTestResults(test.ID); len(results) != 0 {
+		t.Errorf("studying saved %d results", len(results))
+	}
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyBinding is a key, or keys, and what it does, as listed in the help overlay
+type keyBinding struct {
+	keys        string
+	description string
+}
+
+// globalKeyBindings work in every view
+var globalKeyBindings = []keyBinding{
+	{"?", "show or hide this help"},
+	{"esc", "go back to main menu"},
+	{"ctrl+c", "quit"},
+}
+
+// viewKeyBindings lists the keys of each view. Keys that only apply to one
+// step or mode of a view say so in their description.
+var viewKeyBindings = map[ViewType][]keyBinding{
+	MainMenuView: {
+		{"↑/k ↓/j", "move"},
+		{"enter", "select"},
+		{"q", "quit"},
+	},
+	FileSelectionView: {
+		{"↑/k ↓/j", "move"},
+		{"enter", "open the folder or file"},
+		{"c", "type a directory to change to"},
+		{"r", "refresh the list"},
+		{"v", "check every listed file for readable text"},
+		{"b", "return to the file list from the check"},
+	},
+	PDFProcessView: {
+		{"enter", "extract text, continue or generate"},
+		{"p", "extract only a range of pages"},
+		{"↑/k ↓/j", "move between options"},
+		{"n", "set the number of questions"},
+		{"t", "toggle the highlighted question type"},
+		{"e", "edit the test name"},
+		{"d", "edit the description"},
+		{"l", "set a time limit"},
+		{"v", "cycle the difficulty"},
+		{"b", "go back a step"},
+	},
+	QuestionGenView: {
+		{"c/esc", "cancel generation without saving"},
+		{"r", "retry after an error"},
+		{"q", "return to main menu"},
+	},
+	CustomQuestionView: {
+		{"↑/k ↓/j", "move between fields"},
+		{"n/d/t", "edit the test name, description or time limit"},
+		{"r", "require an explanation for every question"},
+		{"t", "cycle the question type"},
+		{"q/o/a/e", "edit the question, options, answer or explanation"},
+		{"+/-", "add or remove an option"},
+		{"v", "cycle the difficulty"},
+		{"s", "save the question"},
+		{"f", "finish and review"},
+		{"e/x", "edit or delete the highlighted question in review"},
+		{"K/J", "move the highlighted question up or down in review"},
+		{"enter", "confirm, or save the test in review"},
+		{"b", "go back to adding questions"},
+	},
+	TestSelectionView: {
+		{"↑/k ↓/j", "move"},
+		{"enter", "choose the test"},
+		{"pgup/pgdown", "previous or next page of tests"},
+		{"/", "filter by name"},
+		{"S", "cycle the sort order"},
+		{"tab", "switch between active and mastered tests"},
+		{"e", "rename the test"},
+		{"c", "copy the test and its questions under a new name"},
+		{"t", "edit the test's tags"},
+		{"T", "show only tests with the next tag"},
+		{"d", "delete the test"},
+		{"r", "refresh the list"},
+		{"o", "shuffle question order (taking a test)"},
+		{"v", "drill one difficulty (taking a test)"},
+		{"g", "regenerate from the source PDF (viewing tests)"},
+		{"w", "wipe the test's results (viewing tests)"},
+		{"s", "generate a similar test (viewing tests)"},
+		{"m", "manage the test's questions (viewing tests)"},
+		{"a", "export the answer key (viewing tests)"},
+		{"p/P", "export a handout, with the answer key on P (viewing tests)"},
+	},
+	TestTakingView: {
+		{"↑/k ↓/j", "move between options"},
+		{"space/x", "check an option (select all that apply)"},
+		{"enter", "answer and continue"},
+		{"←/h/p", "previous question"},
+		{"F/ctrl+f", "toggle focus mode"},
+		{"g/ctrl+g", "jump to a question from a grid of all of them"},
+		{"m/ctrl+b", "mark the question for review, or unmark it"},
+		{"pgup/pgdown", "scroll a long question"},
+		{"esc", "quit the test, after confirming"},
+		{"r", "review answers (when finished)"},
+		{"w", "retry the questions you got wrong (when finished)"},
+		{"←/h →/l", "previous or next answer (in review)"},
+		{"t", "show answer history (in review)"},
+		{"s", "star the question (in review)"},
+	},
+	TestResultsView: {
+		{"↑/k ↓/j", "move"},
+		{"n/p", "next or previous page of results (also pgdown/pgup)"},
+		{"enter", "show the result's details"},
+		{"d", "delete the result"},
+		{"x", "export the test's results to CSV"},
+		{"r", "refresh results"},
+		{"pgup/pgdown", "scroll the details (in details)"},
+		{"s", "star the question (in details)"},
+		{"n", "add or edit a note (in details)"},
+		{"m", "export the test with its answer key (in details)"},
+		{"b", "back to the results list"},
+		{"q", "return to main menu"},
+	},
+	SettingsView: {
+		{"↑/k ↓/j", "move"},
+		{"enter", "edit or toggle the setting"},
+		{"r", "refresh usage stats"},
+		{"q", "return to main menu"},
+	},
+	EditTestView: {
+		{"↑/k ↓/j", "move"},
+		{"/", "search questions"},
+		{"e", "edit the question text"},
+		{"d", "delete the question"},
+		{"K/J", "move the question up or down"},
+		{"b", "go back"},
+	},
+	ImportView: {
+		{"↑/k ↓/j", "move"},
+		{"s/r/m", "skip, rename or merge a conflicting test"},
+		{"enter", "import"},
+		{"b", "pick another file"},
+	},
+	StudyView: {
+		{"space/enter", "flip the card"},
+		{"←/h", "previous card"},
+		{"s", "star the question"},
+		{"r", "study the deck again (when finished)"},
+		{"q", "return to main menu"},
+	},
+	StatsView: {
+		{"r", "refresh"},
+		{"q", "return to main menu"},
+	},
+}
+
+// typingText reports whether the current view is taking free text, where '?'
+// is typed rather than opening help
+func (a *App) typingText() bool {
+	switch a.currentView {
+	case FileSelectionView:
+		return a.fileSelection.inputMode
+	case PDFProcessView:
+		return a.pdfProcess.inputMode != ""
+	case CustomQuestionView:
+		return a.customQuestion.inputMode != ""
+	case TestSelectionView:
+		return a.testSelection.inputMode != "" || a.testSelection.searchMode
+	case TestTakingView:
+		if a.testTaking.showResult || a.testTaking.confirmQuit || len(a.currentQuestions) == 0 {
+			return false
+		}
+		return isTypedAnswer(a.currentQuestions[a.testTaking.currentQuestion].QuestionType)
+	case TestResultsView:
+		return a.testResults.inputMode
+	case SettingsView:
+		return a.settings.inputMode
+	case EditTestView:
+		return a.editTest.inputMode || a.editTest.searchMode
+	default:
+		return false
+	}
+}
+
+// renderHelpOverlay draws the current view's key bindings in a box over the
+// middle of the rendered view
+func (a *App) renderHelpOverlay(view string) string {
+	bindings := append(append([]keyBinding{}, viewKeyBindings[a.currentView]...), globalKeyBindings...)
+
+	width := 0
+	for _, binding := range bindings {
+		width = max(width, lipgloss.Width(binding.keys))
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Keyboard shortcuts") + "\n\n")
+	for _, binding := range bindings {
+		b.WriteString(fmt.Sprintf("%s  %s\n", selectedStyle.Render(fmt.Sprintf("%-*s", width, binding.keys)), binding.description))
+	}
+	b.WriteString("\n" + infoStyle.Render("Press '?' or esc to close"))
+
+	box := strings.Split(borderStyle.Render(b.String()), "\n")
+	lines := strings.Split(view, "\n")
+
+	// Centre the box over the view, growing the view if the box is taller
+	height := max(len(lines), a.height)
+	for len(lines) < len(box) || len(lines) < height {
+		lines = append(lines, "")
+	}
+	top := (len(lines) - len(box)) / 2
+	indent := ""
+	if a.width > 0 {
+		indent = strings.Repeat(" ", max(a.width-lipgloss.Width(box[0]), 0)/2)
+	}
+	for i, line := range box {
+		lines[top+i] = indent + line
+	}
+	return strings.Join(lines, "\n")
+}
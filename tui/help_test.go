@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpOverlayToggles(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Alpha", shortAnswers("Q1")...)
+	createTest(t, a, "Beta", shortAnswers("Q1")...)
+	openTestList(t, a, "view_tests")
+	press(a, "down")
+
+	for _, closeKey := range []string{"?", "esc"} {
+		press(a, "?")
+		if !a.showHelp || a.currentView != TestSelectionView {
+			t.Fatalf("'?' left help %v in view %s, want the overlay over the test list", a.showHelp, a.currentView)
+		}
+		view := a.View()
+		if !strings.Contains(view, "Keyboard shortcuts") || !strings.Contains(view, "go back to main menu") {
+			t.Fatalf("overlay does not list the key bindings:\n%s", view)
+		}
+
+		// Keys go to the overlay, not the view underneath
+		press(a, "up")
+		press(a, "d")
+		if a.testSelection.cursor != 1 || a.testSelection.confirmAction != "" {
+			t.Fatalf("keys reached the test list under the overlay: cursor %d, action %q", a.testSelection.cursor, a.testSelection.confirmAction)
+		}
+
+		press(a, closeKey)
+		if a.showHelp || a.currentView != TestSelectionView || a.testSelection.cursor != 1 {
+			t.Fatalf("%s left help %v in view %s at cursor %d, want the test list as it was", closeKey, a.showHelp, a.currentView, a.testSelection.cursor)
+		}
+		if view := a.View(); strings.Contains(view, "Keyboard shortcuts") {
+			t.Errorf("overlay still drawn after %s:\n%s", closeKey, view)
+		}
+	}
+
+	// While searching, '?' is part of the search
+	press(a, "/")
+	press(a, "?")
+	if a.showHelp || a.testSelection.search != "?" {
+		t.Errorf("'?' while searching opened help %v with search %q", a.showHelp, a.testSelection.search)
+	}
+}
+
+func TestSettingsHelpMatchesKeys(t *testing.T) {
+	// What each key listed for the settings view does, checked against its handler
+	checks := map[string]struct {
+		description string
+		check       func(t *testing.T, a *App)
+	}{
+		"↑/k ↓/j": {"move", func(t *testing.T, a *App) {
+			for _, step := range []struct {
+				key  string
+				want int
+			}{{"down", 1}, {"j", 2}, {"up", 1}, {"k", 0}} {
+				press(a, step.key)
+				if a.settings.cursor != step.want {
+					t.Errorf("%s moved to setting %d, want %d", step.key, a.settings.cursor, step.want)
+				}
+			}
+		}},
+		"enter": {"edit or toggle the setting", func(t *testing.T, a *App) {
+			for i, def := range settingDefinitions {
+				if def.key == settingHideMastered {
+					a.settings.cursor = i
+				}
+			}
+			press(a, "enter")
+			if !a.settingBool(settingHideMastered) {
+				t.Error("enter did not toggle the setting")
+			}
+		}},
+		"r": {"refresh usage stats", func(t *testing.T, a *App) {
+			if err := a.db.RecordUsage("gpt-test", 100, 50, 150); err != nil {
+				t.Fatal(err)
+			}
+			before := a.settingValues[settingHideMastered]
+			press(a, "r")
+			if a.settings.usage == nil || a.settings.usage.Generations != 1 || a.settings.usage.TotalTokens != 150 {
+				t.Errorf("'r' did not refresh the usage stats: %+v", a.settings.usage)
+			}
+			if a.settingValues[settingHideMastered] != before {
+				t.Error("'r' changed a setting")
+			}
+		}},
+		"q": {"return to main menu", func(t *testing.T, a *App) {
+			press(a, "q")
+			if a.currentView != MainMenuView {
+				t.Errorf("'q' moved to view %s, want the main menu", a.currentView)
+			}
+		}},
+	}
+
+	listed := viewKeyBindings[SettingsView]
+	if len(listed) != len(checks) {
+		t.Errorf("help lists %d settings keys, want %d", len(listed), len(checks))
+	}
+	for _, binding := range listed {
+		c, ok := checks[binding.keys]
+		if !ok {
+			t.Errorf("help lists %q (%s), which the settings view does not handle", binding.keys, binding.description)
+			continue
+		}
+		if binding.description != c.description {
+			t.Errorf("help says %q does %q, want %q", binding.keys, binding.description, c.description)
+		}
+
+		a := newTestApp(t)
+		a.currentView = SettingsView
+		a.loadUsageTotals()
+		c.check(t, a)
+	}
+}
@@ -0,0 +1,392 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"pdf-test-generator/chatgpt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ImportModel represents the import preview state. Parsed tests are held
+// here until the user confirms, so nothing is saved before the preview.
+type ImportModel struct {
+	path       string
+	tests      []*ImportedTest
+	warnings   []string
+	cursor     int
+	errorMsg   string
+	successMsg string
+}
+
+// ImportedTest is a test parsed from an import file but not yet saved
+type ImportedTest struct {
+	Name        string
+	Description string
+	Questions   []QuestionData
+
+	// Set when a saved test already has this name
+	ExistingID int
+	Resolution string // "skip", "rename" or "merge"
+	RenameTo   string // Free name used when Resolution is "rename"
+}
+
+// importFile is the JSON layout read by the importer. A file holds either
+// a single test object or an array of them.
+type importFile struct {
+	Name        string                       `json:"name"`
+	Description string                       `json:"description"`
+	Questions   []*chatgpt.GeneratedQuestion `json:"questions"`
+}
+
+// NewImportModel creates a new import model
+func NewImportModel() *ImportModel {
+	return &ImportModel{}
+}
+
+// updateImport handles import preview updates
+func (a *App) updateImport(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if a.importer.cursor > 0 {
+				a.importer.cursor--
+			}
+		case "down", "j":
+			if a.importer.cursor < len(a.importer.tests)-1 {
+				a.importer.cursor++
+			}
+		case "s":
+			a.resolveImportConflict("skip")
+		case "r":
+			a.resolveImportConflict("rename")
+		case "m":
+			a.resolveImportConflict("merge")
+		case "enter":
+			if len(a.importer.tests) > 0 {
+				return a.commitImport()
+			}
+		case "b":
+			a.currentView = FileSelectionView
+		}
+	}
+	return a, nil
+}
+
+// viewImport renders the parsed tests, validation warnings and name conflicts
+func (a *App) viewImport() string {
+	s := a.renderHeader("Import Tests")
+
+	if a.importer.errorMsg != "" {
+		s += a.renderError(a.importer.errorMsg)
+		a.importer.errorMsg = ""
+	}
+
+	if a.importer.successMsg != "" {
+		s += a.renderSuccess(a.importer.successMsg)
+		a.importer.successMsg = ""
+	}
+
+	s += fmt.Sprintf("File: %s\n\n", a.importer.path)
+
+	if len(a.importer.warnings) > 0 {
+		s += errorStyle.Render(fmt.Sprintf("⚠️  %d warning(s):", len(a.importer.warnings))) + "\n"
+		for _, warning := range a.importer.warnings {
+			s += "  - " + warning + "\n"
+		}
+		s += "\n"
+	}
+
+	if len(a.importer.tests) == 0 {
+		s += "Nothing to import.\n\n"
+		s += "Press 'b' to pick another file\n"
+		return s + a.renderFooter()
+	}
+
+	s += "Tests to import:\n\n"
+	for i, test := range a.importer.tests {
+		line := fmt.Sprintf("%s (%d questions) — %s", test.Name, len(test.Questions), describeImportAction(test))
+		if a.importer.cursor == i {
+			s += fmt.Sprintf("> %s\n", selectedStyle.Render(line))
+		} else {
+			s += fmt.Sprintf("  %s\n", line)
+		}
+	}
+	s += "\n"
+
+	selected := a.importer.tests[a.importer.cursor]
+	if selected.Description != "" {
+		s += fmt.Sprintf("Description: %s\n\n", selected.Description)
+	}
+	s += a.renderQuestionList(selected.Questions, -1)
+
+	if selected.ExistingID != 0 {
+		s += "A test with this name exists: 's' skip, 'r' rename, 'm' merge into it\n"
+	}
+	s += "Press Enter to import, 'b' to pick another file\n"
+
+	return s + a.renderFooter()
+}
+
+// describeImportAction summarizes what confirming the import does with a test
+func describeImportAction(test *ImportedTest) string {
+	if test.ExistingID == 0 {
+		return "new test"
+	}
+	switch test.Resolution {
+	case "rename":
+		return fmt.Sprintf("name taken, import as %q", test.RenameTo)
+	case "merge":
+		return "name taken, merge into existing test"
+	default:
+		return "name taken, skip"
+	}
+}
+
+// resolveImportConflict sets how the highlighted test's name clash is resolved
+func (a *App) resolveImportConflict(resolution string) {
+	if len(a.importer.tests) == 0 {
+		return
+	}
+	test := a.importer.tests[a.importer.cursor]
+	if test.ExistingID == 0 {
+		return
+	}
+	test.Resolution = resolution
+}
+
+// loadImportPreview parses an import file and checks its tests against the
+// saved ones without writing anything to the database
+func (a *App) loadImportPreview(path string) (tea.Model, tea.Cmd) {
+	tests, warnings, err := parseImportFile(path)
+	if err != nil {
+		a.fileSelection.errorMsg = err.Error()
+		return a, nil
+	}
+
+	existing, err := a.db.GetAllTests()
+	if err != nil {
+		a.fileSelection.errorMsg = fmt.Sprintf("Failed to load tests: %v", err)
+		return a, nil
+	}
+
+	taken := make(map[string]int, len(existing))
+	for _, test := range existing {
+		taken[strings.ToLower(test.Name)] = test.ID
+	}
+
+	for _, test := range tests {
+		id, ok := taken[strings.ToLower(test.Name)]
+		switch {
+		case ok && id != 0:
+			test.ExistingID = id
+			test.Resolution = "skip"
+			test.RenameTo = uniqueTestName(test.Name, taken)
+			taken[strings.ToLower(test.RenameTo)] = 0
+		case ok:
+			// An earlier test in the file has the same name
+			test.Name = uniqueTestName(test.Name, taken)
+			taken[strings.ToLower(test.Name)] = 0
+		default:
+			taken[strings.ToLower(test.Name)] = 0
+		}
+	}
+
+	a.importer = &ImportModel{path: path, tests: tests, warnings: warnings}
+	a.currentView = ImportView
+	return a, nil
+}
+
+// uniqueTestName appends the first free counter to name, e.g. "Biology (2)"
+func uniqueTestName(name string, taken map[string]int) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if _, ok := taken[strings.ToLower(candidate)]; !ok {
+			return candidate
+		}
+	}
+}
+
+// freeTestName returns name, or name with the first free counter appended when
+// a test already has it
+func (a *App) freeTestName(name string) (string, error) {
+	existing, err := a.db.GetAllTests()
+	if err != nil {
+		return "", fmt.Errorf("Failed to load tests: %v", err)
+	}
+
+	taken := make(map[string]int, len(existing))
+	for _, test := range existing {
+		taken[strings.ToLower(test.Name)] = test.ID
+	}
+	if _, ok := taken[strings.ToLower(name)]; !ok {
+		return name, nil
+	}
+	return uniqueTestName(name, taken), nil
+}
+
+// commitImport saves the previewed tests according to their conflict resolution
+func (a *App) commitImport() (tea.Model, tea.Cmd) {
+	created, merged, skipped := 0, 0, 0
+
+	for _, test := range a.importer.tests {
+		testID := test.ExistingID
+		switch {
+		case test.ExistingID == 0 || test.Resolution == "rename":
+			name := test.Name
+			if test.ExistingID != 0 {
+				name = test.RenameTo
+			}
+			saved, err := a.db.CreateTest(name, test.Description, "", 0)
+			if err != nil {
+				a.importer.errorMsg = fmt.Sprintf("Failed to create test: %v", err)
+				return a, nil
+			}
+			testID = saved.ID
+			created++
+		case test.Resolution == "merge":
+			merged++
+		default:
+			skipped++
+			continue
+		}
+
+		for _, q := range test.Questions {
+			if _, err := a.db.CreateQuestion(testID, q.Text, q.Type, q.CorrectAnswer, q.Explanation, q.Options, "", q.Difficulty); err != nil {
+				a.importer.errorMsg = fmt.Sprintf("Failed to save question: %v", err)
+				return a, nil
+			}
+		}
+	}
+
+	a.importer.tests = nil
+	a.importer.warnings = nil
+	a.importer.cursor = 0
+	a.importer.successMsg = fmt.Sprintf("Imported %d new test(s), merged %d, skipped %d", created, merged, skipped)
+	return a, nil
+}
+
+// parseImportFile reads tests from a JSON file. Invalid questions are dropped
+// with a warning rather than failing the whole import.
+func parseImportFile(path string) ([]*ImportedTest, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	var files []importFile
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &files)
+	} else {
+		var single importFile
+		err = json.Unmarshal(data, &single)
+		files = []importFile{single}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid import file: %w", err)
+	}
+
+	var tests []*ImportedTest
+	var warnings []string
+	for i, file := range files {
+		name := strings.TrimSpace(file.Name)
+		if name == "" {
+			name = fmt.Sprintf("Imported Test %d", i+1)
+			warnings = append(warnings, fmt.Sprintf("Test %d has no name, using %q", i+1, name))
+		}
+
+		test := &ImportedTest{Name: name, Description: strings.TrimSpace(file.Description)}
+		for j, gq := range file.Questions {
+			q, err := importQuestion(gq)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s, question %d skipped: %v", name, j+1, err))
+				continue
+			}
+			test.Questions = append(test.Questions, q)
+		}
+
+		if len(test.Questions) == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s skipped: no valid questions", name))
+			continue
+		}
+		tests = append(tests, test)
+	}
+
+	return tests, warnings, nil
+}
+
+// importQuestion validates an imported question and normalizes its answer to
+// the form stored for its type
+func importQuestion(gq *chatgpt.GeneratedQuestion) (QuestionData, error) {
+	if gq == nil {
+		return QuestionData{}, fmt.Errorf("empty question")
+	}
+
+	q := QuestionData{
+		Text:          strings.TrimSpace(gq.Question),
+		Type:          strings.TrimSpace(gq.Type),
+		Options:       gq.Options,
+		CorrectAnswer: strings.TrimSpace(gq.CorrectAnswer),
+		Explanation:   strings.TrimSpace(gq.Explanation),
+		Difficulty:    strings.ToLower(strings.TrimSpace(gq.Difficulty)),
+	}
+
+	if q.Text == "" {
+		return q, fmt.Errorf("question text is missing")
+	}
+	if q.Difficulty != "" && !isDifficulty(q.Difficulty) {
+		return q, fmt.Errorf("difficulty %q is not easy, medium or hard", q.Difficulty)
+	}
+	if q.CorrectAnswer == "" {
+		return q, fmt.Errorf("correct answer is missing")
+	}
+
+	switch q.Type {
+	case "multiple_choice", "multi_select":
+		if len(q.Options) < minOptionCount {
+			return q, fmt.Errorf("needs at least %d options", minOptionCount)
+		}
+		if err := validateOptions(q.Options); err != nil {
+			return q, fmt.Errorf("duplicate options: %v", err)
+		}
+		if q.Type == "multiple_choice" {
+			letter, err := normalizeChoiceAnswer(q.Options, q.CorrectAnswer)
+			if err != nil {
+				return q, err
+			}
+			q.CorrectAnswer = letter
+			break
+		}
+		if err := checkSelectionLetters(q.Options, q.CorrectAnswer); err != nil {
+			return q, err
+		}
+		q.CorrectAnswer = normalizeSelectionAnswer(q.CorrectAnswer)
+	case "true_false":
+		switch formatAnswer(q.Type, q.CorrectAnswer) {
+		case "True":
+			q.CorrectAnswer = "true"
+		case "False":
+			q.CorrectAnswer = "false"
+		default:
+			return q, fmt.Errorf("correct answer %q is not true or false", q.CorrectAnswer)
+		}
+		q.Options = nil
+	case "short_answer", "fill_blank":
+		accepted := acceptedAnswers(q.CorrectAnswer)
+		if len(accepted) == 0 {
+			return q, fmt.Errorf("correct answer has no accepted answers")
+		}
+		q.CorrectAnswer = strings.Join(accepted, "|")
+		q.Options = nil
+	default:
+		return q, fmt.Errorf("unknown question type %q", q.Type)
+	}
+
+	return q, nil
+}
@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+)
+
+func TestParseImportFileChoiceAnswers(t *testing.T) {
+	path := writeImportFile(t, `{"name": "Capitals", "questions": [
+		{"question": "Letter", "type": "multiple_choice", "options": ["Paris", "Rome", "Berlin"], "correct_answer": "b)"},
+		{"question": "Text", "type": "multiple_choice", "options": ["Paris", "Rome", "Berlin"], "correct_answer": " rome "},
+		{"question": "No match", "type": "multiple_choice", "options": ["Paris", "Rome", "Berlin"], "correct_answer": "Madrid"},
+		{"question": "Letter out of range", "type": "multiple_choice", "options": ["Paris", "Rome", "Berlin"], "correct_answer": "D"}
+	]}`)
+
+	tests, warnings, err := parseImportFile(path)
+	if err != nil {
+		t.Fatalf("parseImportFile: %v", err)
+	}
+	if len(tests) != 1 || len(tests[0].Questions) != 2 {
+		t.Fatalf("imported %+v, want one test with two questions", tests)
+	}
+	for _, q := range tests[0].Questions {
+		if q.CorrectAnswer != "B" {
+			t.Errorf("%s: correct answer = %q, want B", q.Text, q.CorrectAnswer)
+		}
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "question 3") || !strings.Contains(warnings[1], "question 4") {
+		t.Errorf("warnings = %q, want the two unmatched answers skipped", warnings)
+	}
+
+	// The stored letter is what the test taking view scores against
+	a := newTestApp(t)
+	q := tests[0].Questions[1]
+	stored := &database.Question{QuestionType: q.Type, Options: q.Options, CorrectAnswer: q.CorrectAnswer}
+	if !a.isAnswerCorrect(stored, "B") || a.isAnswerCorrect(stored, "A") {
+		t.Error("an imported text answer is not scored by its letter")
+	}
+}
+
+func TestNormalizeGeneratedAnswers(t *testing.T) {
+	questions := []*chatgpt.GeneratedQuestion{
+		{Question: "Letter", Type: "multiple_choice", Options: []string{"Paris", "Rome"}, CorrectAnswer: "a."},
+		{Question: "Text", Type: "multiple_choice", Options: []string{"Paris", "Rome"}, CorrectAnswer: "ROME"},
+		{Question: "Typed", Type: "short_answer", CorrectAnswer: "Paris"},
+	}
+	if err := normalizeGeneratedAnswers(questions); err != nil {
+		t.Fatalf("normalizeGeneratedAnswers: %v", err)
+	}
+	for i, want := range []string{"A", "B", "Paris"} {
+		if questions[i].CorrectAnswer != want {
+			t.Errorf("%s: correct answer = %q, want %q", questions[i].Question, questions[i].CorrectAnswer, want)
+		}
+	}
+
+	bad := []*chatgpt.GeneratedQuestion{{Question: "No match", Type: "multiple_choice", Options: []string{"Paris", "Rome"}, CorrectAnswer: "Madrid"}}
+	if err := normalizeGeneratedAnswers(bad); err == nil {
+		t.Error("an answer matching no option was accepted")
+	}
+}
+
+// writeImportFile writes data to an import file in a temporary directory
+func writeImportFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "import.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportPreviewResolvesConflicts(t *testing.T) {
+	a := newTestApp(t)
+	biology := createTest(t, a, "Biology", shortAnswers("Existing")...)
+	createTest(t, a, "Physics", shortAnswers("Existing")...)
+	geology := createTest(t, a, "Geology", shortAnswers("Existing")...)
+
+	path := writeImportFile(t, `[
+		{"name": "biology", "questions": [{"question": "Merged", "type": "short_answer", "correct_answer": "yes"}]},
+		{"name": "Physics", "questions": [{"question": "Renamed", "type": "short_answer", "correct_answer": "yes"}]},
+		{"name": "Geology", "questions": [{"question": "Skipped", "type": "short_answer", "correct_answer": "yes"}]},
+		{"name": "Chemistry", "questions": [
+			{"question": "New", "type": "short_answer", "correct_answer": "yes"},
+			{"question": "", "type": "short_answer", "correct_answer": "yes"}
+		]}
+	]`)
+	a.loadImportPreview(path)
+	if a.currentView != ImportView {
+		t.Fatalf("preview not shown: %s", a.fileSelection.errorMsg)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 3 {
+		t.Fatalf("%d tests saved before confirming, want 3", len(tests))
+	}
+
+	view := a.viewImport()
+	for _, want := range []string{"1 warning(s)", "Chemistry, question 2 skipped", "name taken, skip", "new test", "Merged"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("preview does not show %q:\n%s", want, view)
+		}
+	}
+
+	press(a, "m") // biology
+	press(a, "down")
+	press(a, "r") // Physics
+	if got := a.importer.tests[1].RenameTo; got != "Physics (2)" {
+		t.Errorf("rename target = %q, want Physics (2)", got)
+	}
+	press(a, "enter")
+	if a.importer.errorMsg != "" {
+		t.Fatalf("import failed: %s", a.importer.errorMsg)
+	}
+	if !strings.Contains(a.importer.successMsg, "Imported 2 new test(s), merged 1, skipped 1") {
+		t.Errorf("success message = %q", a.importer.successMsg)
+	}
+
+	if questions, _ := a.db.GetQuestionsByTestID(biology.ID); len(questions) != 2 || questions[1].QuestionText != "Merged" {
+		t.Errorf("merged test questions = %+v", questions)
+	}
+	if questions, _ := a.db.GetQuestionsByTestID(geology.ID); len(questions) != 1 {
+		t.Errorf("skipped test has %d questions, want 1", len(questions))
+	}
+	tests, err := a.db.GetAllTests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, test := range tests {
+		names[test.Name] = true
+	}
+	if len(tests) != 5 || !names["Physics (2)"] || !names["Chemistry"] {
+		t.Errorf("tests after importing = %v", names)
+	}
+}
@@ -11,6 +11,7 @@ type MainMenuModel struct {
 	choices  []string
 	cursor   int
 	selected map[int]struct{}
+	errorMsg string
 }
 
 // NewMainMenuModel creates a new main menu model
@@ -21,6 +22,12 @@ func NewMainMenuModel() *MainMenuModel {
 			"✏️  Create custom questions",
 			"📝 Take practice test",
 			"📊 View saved tests",
+			"⭐ Study starred questions",
+			"📅 Review questions due today",
+			"🃏 Study a test with flashcards",
+			"📥 Import tests from JSON",
+			"📈 View study statistics",
+			"⚙️  Settings & stats",
 			"🚪 Exit",
 		},
 		selected: make(map[int]struct{}),
@@ -52,6 +59,12 @@ func (a *App) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 // viewMainMenu renders the main menu
 func (a *App) viewMainMenu() string {
 	s := a.renderHeader("PDF Test Generator")
+
+	if a.mainMenu.errorMsg != "" {
+		s += a.renderError(a.mainMenu.errorMsg)
+		a.mainMenu.errorMsg = ""
+	}
+
 	s += "What would you like to do?\n\n"
 
 	for i, choice := range a.mainMenu.choices {
@@ -65,7 +78,7 @@ func (a *App) viewMainMenu() string {
 		}
 	}
 
-	s += "\nPress 'q' to quit, arrow keys to navigate, enter to select.\n"
+	s += "\nPress 'q' to quit, arrow keys to navigate, enter to select, '?' for help.\n"
 	return s
 }
 
@@ -76,6 +89,7 @@ func (a *App) handleMainMenuSelection() (tea.Model, tea.Cmd) {
 		// Generate questions from PDF
 		a.currentView = FileSelectionView
 		a.fileSelection.purpose = "pdf_generation"
+		a.refreshFileList()
 		return a, nil
 	case 1:
 		// Create custom questions
@@ -83,15 +97,38 @@ func (a *App) handleMainMenuSelection() (tea.Model, tea.Cmd) {
 		return a, nil
 	case 2:
 		// Take practice test
-		a.currentView = TestSelectionView
-		a.testSelection.purpose = "take_test"
+		a.openTestSelection("take_test")
 		return a, nil
 	case 3:
 		// View saved tests
-		a.currentView = TestSelectionView
-		a.testSelection.purpose = "view_tests"
+		a.openTestSelection("view_tests")
 		return a, nil
 	case 4:
+		// Quiz on starred questions
+		return a.startStudyList()
+	case 5:
+		// Quiz on questions due for spaced repetition review
+		return a.startDueReview()
+	case 6:
+		// Flip through a test's questions without scoring
+		a.openTestSelection("study")
+		return a, nil
+	case 7:
+		// Import tests from a JSON file
+		a.currentView = FileSelectionView
+		a.fileSelection.purpose = "import"
+		a.refreshFileList()
+		return a, nil
+	case 8:
+		// Progress across all saved results
+		a.openStats()
+		return a, nil
+	case 9:
+		// Settings and usage stats
+		a.currentView = SettingsView
+		a.loadUsageTotals()
+		return a, nil
+	case 10:
 		// Exit
 		return a, tea.Quit
 	}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"pdf-test-generator/database"
+)
+
+// ExportTestMarkdown renders a test as a Markdown handout: a numbered list of
+// questions with their options, followed by an answer key with explanations
+// when includeAnswers is set
+func ExportTestMarkdown(test *database.Test, questions []*database.Question, includeAnswers bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", test.Name)
+	if test.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", test.Description)
+	}
+
+	for i, q := range questions {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, q.QuestionText)
+		switch q.QuestionType {
+		case "multiple_choice", "multi_select":
+			if q.QuestionType == "multi_select" {
+				b.WriteString("   *Select all that apply.*\n")
+			}
+			for j, option := range q.Options {
+				fmt.Fprintf(&b, "   - %c) %s\n", 'A'+j, option)
+			}
+		case "true_false":
+			b.WriteString("   - True\n   - False\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if includeAnswers {
+		b.WriteString("## Answer Key\n\n")
+		for i, q := range questions {
+			fmt.Fprintf(&b, "%d. **%s**", i+1, formatKeyAnswer(q))
+			if q.Explanation != "" {
+				fmt.Fprintf(&b, " — %s", q.Explanation)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// exportSelectedTestMarkdown writes the selected test as a Markdown handout
+// to the working directory
+func (a *App) exportSelectedTestMarkdown(includeAnswers bool) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+
+	path, err := a.writeTestMarkdown(selectedTest, includeAnswers)
+	if err != nil {
+		a.testSelection.errorMsg = err.Error()
+		return
+	}
+	a.testSelection.successMsg = fmt.Sprintf("Test saved to %s", path)
+}
+
+// writeTestMarkdown writes a test's handout to a Markdown file in the working
+// directory and returns its path
+func (a *App) writeTestMarkdown(test *database.Test, includeAnswers bool) (string, error) {
+	questions, err := a.db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return "", fmt.Errorf("this test has no questions")
+	}
+
+	path := exportFileName(test.Name, ".md")
+	if err := os.WriteFile(path, []byte(ExportTestMarkdown(test, questions, includeAnswers)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write test: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"os"
+	"testing"
+
+	"pdf-test-generator/database"
+)
+
+// markdownFixture is a small test with one question of each shape
+func markdownFixture() (*database.Test, []*database.Question) {
+	test := &database.Test{Name: "Planets", Description: "The solar system"}
+	questions := []*database.Question{
+		{QuestionText: "Largest planet?", QuestionType: "multiple_choice", Options: []string{"Mars", "Jupiter"}, CorrectAnswer: "B", Explanation: "It is a gas giant."},
+		{QuestionText: "Gas giants?", QuestionType: "multi_select", Options: []string{"Saturn", "Earth", "Neptune"}, CorrectAnswer: "A,C"},
+		{QuestionText: "Pluto is a planet.", QuestionType: "true_false", CorrectAnswer: "false", Explanation: "It is a dwarf planet."},
+		{QuestionText: "Closest planet to the Sun?", QuestionType: "short_answer", CorrectAnswer: "Mercury"},
+	}
+	return test, questions
+}
+
+const markdownGolden = `# Planets
+
+The solar system
+
+1. Largest planet?
+   - A) Mars
+   - B) Jupiter
+
+2. Gas giants?
+   *Select all that apply.*
+   - A) Saturn
+   - B) Earth
+   - C) Neptune
+
+3. Pluto is a planet.
+   - True
+   - False
+
+4. Closest planet to the Sun?
+
+`
+
+const markdownGoldenKey = `## Answer Key
+
+1. **B) Jupiter** — It is a gas giant.
+2. **A) Saturn; C) Neptune**
+3. **False** — It is a dwarf planet.
+4. **Mercury**
+`
+
+func TestExportTestMarkdown(t *testing.T) {
+	test, questions := markdownFixture()
+	if got := ExportTestMarkdown(test, questions, false); got != markdownGolden {
+		t.Errorf("handout =\n%s\nwant\n%s", got, markdownGolden)
+	}
+	if got := ExportTestMarkdown(test, questions, true); got != markdownGolden+markdownGoldenKey {
+		t.Errorf("handout with answers =\n%s\nwant\n%s", got, markdownGolden+markdownGoldenKey)
+	}
+}
+
+func TestExportSelectedTestMarkdown(t *testing.T) {
+	t.Chdir(t.TempDir())
+	a := newTestApp(t)
+	fixture, questions := markdownFixture()
+	test, err := a.db.CreateTest(fixture.Name, fixture.Description, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range questions {
+		if _, err := a.db.CreateQuestion(test.ID, q.QuestionText, q.QuestionType, q.CorrectAnswer, q.Explanation, q.Options, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openTestList(t, a, "view_tests")
+
+	press(a, "P")
+	if a.testSelection.errorMsg != "" {
+		t.Fatalf("exporting: %s", a.testSelection.errorMsg)
+	}
+	data, err := os.ReadFile("planets.md")
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	if string(data) != markdownGolden+markdownGoldenKey {
+		t.Errorf("planets.md =\n%s", data)
+	}
+}
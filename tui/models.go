@@ -2,8 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +15,7 @@ import (
 	"pdf-test-generator/database"
 	"pdf-test-generator/pdf"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -28,13 +32,18 @@ const (
 	TestResultsView     ViewType = "test_results"
 	FileSelectionView   ViewType = "file_selection"
 	QuestionGenView     ViewType = "question_gen"
+	SettingsView        ViewType = "settings"
+	EditTestView        ViewType = "edit_test"
+	ImportView          ViewType = "import"
+	StudyView           ViewType = "study"
+	StatsView           ViewType = "stats"
 )
 
 // App represents the main application state
 type App struct {
 	currentView ViewType
 	db          *database.DB
-	chatGPT     *chatgpt.Client
+	chatGPT     chatgpt.QuestionGenerator
 	pdfProcessor *pdf.PDFProcessor
 	
 	// View models
@@ -46,16 +55,31 @@ type App struct {
 	testResults     *TestResultsModel
 	fileSelection   *FileSelectionModel
 	questionGen     *QuestionGenModel
+	settings        *SettingsModel
+	editTest        *EditTestModel
+	importer        *ImportModel
+	study           *StudyModel
+	stats           *StatsModel
 	
 	// Shared state
 	currentTest     *database.Test
 	currentQuestions []*database.Question
 	userAnswers     map[int]string
 	testStartTime   time.Time
+	settingValues   map[string]string
+	studyList       map[int]bool // IDs of starred questions
+	session         SessionStats
+	rng             *rand.Rand // Source for shuffling; replace with a seeded one for reproducible order
+	
+	showHelp bool // Key bindings overlay is shown over the current view
+	
+	// Terminal size, zero until the first WindowSizeMsg arrives
+	width           int
+	height          int
 }
 
-// NewApp creates a new application instance
-func NewApp(dbPath, apiKey string) (*App, error) {
+// NewApp creates a new application instance that generates questions with the given backend
+func NewApp(dbPath string, generator chatgpt.QuestionGenerator) (*App, error) {
 	db, err := database.NewDB(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
@@ -64,9 +88,10 @@ func NewApp(dbPath, apiKey string) (*App, error) {
 	app := &App{
 		currentView:  MainMenuView,
 		db:          db,
-		chatGPT:     chatgpt.NewClient(apiKey),
+		chatGPT:     generator,
 		pdfProcessor: pdf.NewPDFProcessor(),
 		userAnswers: make(map[int]string),
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 
 	// Initialize view models
@@ -78,6 +103,21 @@ func NewApp(dbPath, apiKey string) (*App, error) {
 	app.testResults = NewTestResultsModel()
 	app.fileSelection = NewFileSelectionModel()
 	app.questionGen = NewQuestionGenModel()
+	app.settings = NewSettingsModel()
+	app.editTest = NewEditTestModel()
+	app.importer = NewImportModel()
+	app.study = NewStudyModel()
+	app.stats = NewStatsModel()
+
+	if err := app.loadSettings(); err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	app.applySettings()
+	app.fileSelection.currentDir = app.startingPDFDirectory()
+
+	if err := app.loadStudyList(); err != nil {
+		return nil, fmt.Errorf("failed to load study list: %w", err)
+	}
 
 	return app, nil
 }
@@ -90,13 +130,44 @@ func (a *App) Init() tea.Cmd {
 // Update handles messages and updates the application state
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		a.width = msg.Width
+		a.height = msg.Height
+		return a, nil
+	case testTickMsg:
+		return a.handleTestTick(msg)
+	case pdfExtractedMsg, pdfExtractProgressMsg:
+		// Delivered even if the user left the view while extraction was running
+		return a.updatePDFProcess(msg)
+	case pdfValidatedMsg:
+		return a.handlePDFValidated(msg)
+	case questionStreamMsg:
+		// Generation keeps running if the user leaves the view
+		return a.handleQuestionStream(msg)
+	case spinner.TickMsg:
+		return a.updateSpinners(msg)
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		if msg.String() == "ctrl+c" {
 			return a, tea.Quit
+		}
+		
+		// The help overlay takes every key until it is closed
+		if a.showHelp {
+			if msg.String() == "?" || msg.String() == "esc" {
+				a.showHelp = false
+			}
+			return a, nil
+		}
+		
+		switch msg.String() {
+		case "?":
+			if !a.typingText() {
+				a.showHelp = true
+				return a, nil
+			}
 		case "esc":
-			// Go back to main menu from any view
-			if a.currentView != MainMenuView {
+			// Go back to main menu from any view, unless the view uses esc itself
+			if a.currentView != MainMenuView && !a.escHandledByView() {
 				a.currentView = MainMenuView
 				return a, nil
 			}
@@ -121,13 +192,76 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.updateFileSelection(msg)
 	case QuestionGenView:
 		return a.updateQuestionGen(msg)
+	case SettingsView:
+		return a.updateSettings(msg)
+	case EditTestView:
+		return a.updateEditTest(msg)
+	case ImportView:
+		return a.updateImport(msg)
+	case StudyView:
+		return a.updateStudy(msg)
+	case StatsView:
+		return a.updateStats(msg)
 	default:
 		return a, nil
 	}
 }
 
-// View renders the current view
+// escHandledByView reports whether the current view uses esc to leave a mode
+// of its own, such as clearing a search, instead of returning to the main menu
+func (a *App) escHandledByView() bool {
+	switch a.currentView {
+	case EditTestView:
+		return a.editTest.searchMode || a.editTest.search != "" || a.editTest.inputMode
+	case TestSelectionView:
+		return a.testSelection.searchMode || a.testSelection.search != "" || a.testSelection.confirmAction != "" || a.testSelection.inputMode != ""
+	case TestResultsView:
+		return a.testResults.confirmDelete || a.testResults.inputMode
+	case SettingsView:
+		return a.settings.inputMode
+	case QuestionGenView:
+		return a.questionGen.status == "generating"
+	case PDFProcessView:
+		return a.pdfProcess.inputMode != ""
+	case TestTakingView:
+		return !a.testTaking.showResult
+	default:
+		return false
+	}
+}
+
+// updateSpinners advances the spinners of views that are waiting on a result.
+// Ticks are dropped once loading ends, which stops the spinner.
+func (a *App) updateSpinners(msg spinner.TickMsg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if a.pdfProcess.loading {
+		var cmd tea.Cmd
+		a.pdfProcess.spinner, cmd = a.pdfProcess.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if a.questionGen.status == "generating" {
+		var cmd tea.Cmd
+		a.questionGen.spinner, cmd = a.questionGen.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return a, tea.Batch(cmds...)
+}
+
+// View renders the current view, with the help overlay on top when it is open
 func (a *App) View() string {
+	if a.terminalTooSmall() {
+		return a.viewTerminalTooSmall()
+	}
+	
+	view := a.viewCurrent()
+	if a.showHelp {
+		return a.renderHelpOverlay(view)
+	}
+	return view
+}
+
+// viewCurrent renders the current view
+func (a *App) viewCurrent() string {
 	switch a.currentView {
 	case MainMenuView:
 		return a.viewMainMenu()
@@ -145,6 +279,16 @@ func (a *App) View() string {
 		return a.viewFileSelection()
 	case QuestionGenView:
 		return a.viewQuestionGen()
+	case SettingsView:
+		return a.viewSettings()
+	case EditTestView:
+		return a.viewEditTest()
+	case ImportView:
+		return a.viewImport()
+	case StudyView:
+		return a.viewStudy()
+	case StatsView:
+		return a.viewStats()
 	default:
 		return "Unknown view"
 	}
@@ -178,13 +322,106 @@ var (
 			Padding(1, 2)
 )
 
+// Palettes used for success/error styling. The color-blind-safe palette uses
+// blue/orange from the Okabe-Ito set instead of green/red.
+var (
+	defaultPalette    = palette{success: "#00FF00", failure: "#FF0000"}
+	colorBlindPalette = palette{success: "#0072B2", failure: "#E69F00"}
+)
+
+// palette holds the colors used to convey correct/incorrect and success/error
+type palette struct {
+	success string
+	failure string
+}
+
+// applyPalette updates the shared styles to use the configured palette
+func (a *App) applyPalette() {
+	p := defaultPalette
+	if a.settingBool(settingColorBlindPalette) {
+		p = colorBlindPalette
+	}
+	successStyle = successStyle.Foreground(lipgloss.Color(p.success))
+	errorStyle = errorStyle.Foreground(lipgloss.Color(p.failure))
+}
+
+// chromeLines is the number of lines taken by the header and footer of a view
+const chromeLines = 4
+
+// countLines returns the number of lines in a rendered string
+func countLines(s string) int {
+	return strings.Count(s, "\n")
+}
+
+// wrapToWidth wraps text to the terminal width so long lines break between
+// words instead of spilling over. Text is unchanged until the size is known.
+func (a *App) wrapToWidth(text string) string {
+	if a.width <= 0 {
+		return text
+	}
+	return lipgloss.NewStyle().Width(a.width).Render(text)
+}
+
+// defaultPageSize is how many items a list page holds until the terminal size is known
+const defaultPageSize = 10
+
+// itemsPerPage returns how many list items of linesPerItem lines fit on the
+// screen next to reservedLines lines of headings and key hints
+func (a *App) itemsPerPage(linesPerItem, reservedLines int) int {
+	if a.height <= 0 {
+		return defaultPageSize
+	}
+	return max((a.height-reservedLines)/linesPerItem, 1)
+}
+
+// lineCount returns how many screen lines rendered text takes. Lines wider
+// than the terminal are cut off rather than wrapped, so each counts once.
+func lineCount(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+}
+
+// listPage returns the page, counted from 0, that holds the item at cursor in a
+// list of total items with perPage items a page, the number of pages and the
+// range of item indexes on the page
+func listPage(cursor, total, perPage int) (page, pages, start, end int) {
+	pages = max((total+perPage-1)/perPage, 1)
+	page = min(cursor/perPage, pages-1)
+	start = page * perPage
+	end = min(start+perPage, total)
+	return page, pages, start, end
+}
+
+// turnPage moves a list cursor by delta pages, keeping its place on the page
+// where it can and wrapping from the last page to the first and back. The
+// cursor stays an index into the whole list.
+func turnPage(cursor, total, perPage, delta int) int {
+	if total == 0 {
+		return 0
+	}
+	page, pages, _, _ := listPage(cursor, total, perPage)
+	page = ((page+delta)%pages + pages) % pages
+	return min(page*perPage+cursor%perPage, total-1)
+}
+
+// renderPageIndicator shows which page of a list is on screen, or nothing when
+// the list fits on one page
+func renderPageIndicator(page, pages int) string {
+	if pages <= 1 {
+		return ""
+	}
+	return infoStyle.Render(fmt.Sprintf("Page %d of %d", page+1, pages)) + "\n"
+}
+
 // Helper functions
 func (a *App) renderHeader(title string) string {
 	return headerStyle.Render("📚 "+title) + "\n\n"
 }
 
 func (a *App) renderFooter() string {
-	return "\n" + infoStyle.Render("Press 'esc' to go back to main menu, 'ctrl+c' to quit")
+	return "\n" + infoStyle.Render("Press 'esc' to go back to main menu, '?' for help, 'ctrl+c' to quit")
 }
 
 func (a *App) renderError(err string) string {
@@ -195,6 +432,21 @@ func (a *App) renderSuccess(msg string) string {
 	return successStyle.Render("✅ "+msg) + "\n"
 }
 
+// terminalTooSmall reports whether the terminal is below the configured minimum size.
+// The settings view is always shown so a too-large minimum can be corrected.
+func (a *App) terminalTooSmall() bool {
+	if a.width == 0 || a.height == 0 || a.currentView == SettingsView {
+		return false
+	}
+	return a.width < a.settingInt(settingMinTerminalWidth) || a.height < a.settingInt(settingMinTerminalHeight)
+}
+
+// viewTerminalTooSmall renders the fallback shown instead of a cramped view
+func (a *App) viewTerminalTooSmall() string {
+	return fmt.Sprintf("Please enlarge your terminal (min %dx%d)",
+		a.settingInt(settingMinTerminalWidth), a.settingInt(settingMinTerminalHeight))
+}
+
 // Navigation helpers
 func (a *App) switchToView(view ViewType) tea.Cmd {
 	a.currentView = view
@@ -202,22 +454,70 @@ func (a *App) switchToView(view ViewType) tea.Cmd {
 }
 
 // File helper functions
-func (a *App) listPDFFiles(dir string) ([]string, error) {
-	var pdfFiles []string
+
+// FileEntry is a directory or file listed by file selection
+type FileEntry struct {
+	Path    string
+	Name    string // Shown in the list; ".." for the parent directory
+	IsDir   bool
+	Size    int64 // Files only
+	ModTime time.Time
+}
+
+// listDirectory returns the entries of dir that file selection can open: the
+// parent directory, then visible subdirectories, then the files with one of
+// the given extensions, e.g. ".pdf", most recently modified first
+func (a *App) listDirectory(dir string, exts []string) ([]FileEntry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
 	
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		
-		if !info.IsDir() && strings.ToLower(filepath.Ext(path)) == ".pdf" {
-			pdfFiles = append(pdfFiles, path)
+	var entries []FileEntry
+	if parent := filepath.Dir(dir); parent != dir {
+		entries = append(entries, FileEntry{Path: parent, Name: "..", IsDir: true})
+	}
+	
+	var files []FileEntry
+	for _, entry := range dirEntries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		switch {
+		case strings.HasPrefix(name, "."):
+			continue
+		case entry.IsDir():
+			entries = append(entries, FileEntry{Path: path, Name: name, IsDir: true})
+		case slices.Contains(exts, strings.ToLower(filepath.Ext(name))):
+			info, err := entry.Info()
+			if err != nil {
+				continue // Removed since the directory was read
+			}
+			files = append(files, FileEntry{Path: path, Name: name, Size: info.Size(), ModTime: info.ModTime()})
 		}
-		
-		return nil
+	}
+	
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
 	})
 	
-	return pdfFiles, err
+	return append(entries, files...), nil
+}
+
+// formatFileSize renders a size in bytes for display, e.g. "1.5 KB"
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return ""
 }
 
 // Question type helpers
@@ -229,11 +529,61 @@ func (a *App) getQuestionTypeDisplay(qType string) string {
 		return "True/False"
 	case "short_answer":
 		return "Short Answer"
+	case "multi_select":
+		return "Select All That Apply"
+	case "fill_blank":
+		return "Fill in the Blank"
 	default:
 		return "Unknown"
 	}
 }
 
+// normalizeChoiceAnswer converts a multiple choice correct answer to the option
+// letter the test taking view stores. It accepts a letter ("b", "B)", "B.") or
+// the text of one of the options, and errors if the answer matches no option.
+func normalizeChoiceAnswer(options []string, answer string) (string, error) {
+	answer = strings.TrimSpace(answer)
+	
+	letter := strings.ToUpper(strings.TrimRight(answer, ").:"))
+	if len(letter) == 1 && letter[0] >= 'A' && int(letter[0]-'A') < len(options) {
+		return letter, nil
+	}
+	
+	for i, option := range options {
+		if strings.EqualFold(strings.TrimSpace(option), answer) {
+			return string(rune('A' + i)), nil
+		}
+	}
+	
+	return "", fmt.Errorf("correct answer %q does not match any option", answer)
+}
+
+// choiceLetter resolves a multiple choice answer given as a letter or as
+// option text to the option letter, or returns it trimmed if it matches no option
+func choiceLetter(options []string, answer string) string {
+	if letter, err := normalizeChoiceAnswer(options, answer); err == nil {
+		return letter
+	}
+	return strings.TrimSpace(answer)
+}
+
+// validateOptions rejects options that repeat another non-empty option,
+// ignoring case and surrounding whitespace
+func validateOptions(options []string) error {
+	seen := make(map[string]int)
+	for i, option := range options {
+		key := strings.ToLower(strings.TrimSpace(option))
+		if key == "" {
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			return fmt.Errorf("options %c and %c are the same", 'A'+first, 'A'+i)
+		}
+		seen[key] = i
+	}
+	return nil
+}
+
 // Score calculation
 func (a *App) calculateScore(questions []*database.Question, answers map[int]string) (int, float64) {
 	correct := 0
@@ -245,11 +595,7 @@ func (a *App) calculateScore(questions []*database.Question, answers map[int]str
 			continue
 		}
 		
-		// Normalize answers for comparison
-		correctAnswer := strings.ToLower(strings.TrimSpace(q.CorrectAnswer))
-		userAnswer = strings.ToLower(strings.TrimSpace(userAnswer))
-		
-		if correctAnswer == userAnswer {
+		if a.isAnswerCorrect(q, userAnswer) {
 			correct++
 		}
 	}
@@ -262,6 +608,159 @@ func (a *App) calculateScore(questions []*database.Question, answers map[int]str
 	return correct, score
 }
 
+// isAnswerCorrect reports whether a user's answer to a question is correct
+func (a *App) isAnswerCorrect(q *database.Question, userAnswer string) bool {
+	if q.QuestionType == "multi_select" {
+		// All-or-nothing: the checked letters must match the correct letters exactly
+		return normalizeSelectionAnswer(userAnswer) == normalizeSelectionAnswer(q.CorrectAnswer)
+	}
+	
+	if q.QuestionType == "multiple_choice" {
+		// Older questions may store the option text rather than its letter
+		if strings.TrimSpace(userAnswer) == "" {
+			return false
+		}
+		return choiceLetter(q.Options, userAnswer) == choiceLetter(q.Options, q.CorrectAnswer)
+	}
+	
+	if isTypedAnswer(q.QuestionType) && strings.Contains(q.CorrectAnswer, "|") {
+		// Any of the accepted answers counts
+		userAnswer = normalizeTypedAnswer(userAnswer)
+		for _, accepted := range acceptedAnswers(q.CorrectAnswer) {
+			if normalizeTypedAnswer(accepted) == userAnswer {
+				return true
+			}
+		}
+		return false
+	}
+	
+	// Normalize answers for comparison
+	return normalizeTypedAnswer(q.CorrectAnswer) == normalizeTypedAnswer(userAnswer)
+}
+
+// normalizeTypedAnswer prepares a typed answer for comparison: it is lowercased,
+// runs of whitespace become single spaces and trailing punctuation is dropped,
+// so "George  Washington." matches "george washington". Punctuation inside the
+// answer is kept, so different answers are not made equal.
+func normalizeTypedAnswer(answer string) string {
+	answer = strings.Join(strings.Fields(strings.ToLower(answer)), " ")
+	return strings.TrimSpace(strings.TrimRight(answer, ".,;:!?"))
+}
+
+// normalizeSelectionAnswer converts a list of option letters such as "c, a" to
+// the sorted, uppercase comma list stored for multi select questions ("A,C")
+func normalizeSelectionAnswer(answer string) string {
+	letters := make([]string, 0, len(answer))
+	for letter := range letterSet(answer) {
+		letters = append(letters, letter)
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, ",")
+}
+
+// acceptedAnswers splits the pipe separated answers accepted for a short answer
+// or fill in the blank question, e.g. "Paris|City of Light", dropping empty entries
+func acceptedAnswers(answer string) []string {
+	var accepted []string
+	for _, part := range strings.Split(answer, "|") {
+		if part = strings.TrimSpace(part); part != "" {
+			accepted = append(accepted, part)
+		}
+	}
+	return accepted
+}
+
+// isDifficulty reports whether level is one of the difficulty levels a
+// question can be rated with
+func isDifficulty(level string) bool {
+	for _, known := range database.Difficulties {
+		if level == known {
+			return true
+		}
+	}
+	return false
+}
+
+// nextDifficulty returns the level after the given one, cycling from not rated
+// ("") through easy, medium and hard back to not rated
+func nextDifficulty(level string) string {
+	for i, known := range database.Difficulties {
+		if level == known {
+			if i == len(database.Difficulties)-1 {
+				return ""
+			}
+			return database.Difficulties[i+1]
+		}
+	}
+	return database.Difficulties[0]
+}
+
+// formatDifficulty renders a difficulty level for display, e.g. "Hard"
+func formatDifficulty(level string) string {
+	if level == "" {
+		return "Not rated"
+	}
+	return strings.ToUpper(level[:1]) + level[1:]
+}
+
+// isTypedAnswer reports whether a question type is answered by typing text
+func isTypedAnswer(questionType string) bool {
+	return questionType == "short_answer" || questionType == "fill_blank"
+}
+
+// checkSelectionLetters verifies that a select all that apply answer is a
+// non-empty list of letters of the given options
+func checkSelectionLetters(options []string, answer string) error {
+	letters := letterSet(answer)
+	if len(letters) == 0 {
+		return fmt.Errorf("correct answer %q is not a list of option letters", answer)
+	}
+	for letter := range letters {
+		if len(letter) != 1 || letter[0] < 'A' || int(letter[0]-'A') >= len(options) {
+			return fmt.Errorf("correct answer %q is not a list of option letters", answer)
+		}
+	}
+	return nil
+}
+
+// letterSet splits a comma separated list of option letters into a set
+func letterSet(answer string) map[string]bool {
+	set := make(map[string]bool)
+	for _, part := range strings.Split(answer, ",") {
+		letter := strings.ToUpper(strings.TrimSpace(part))
+		if letter != "" {
+			set[letter] = true
+		}
+	}
+	return set
+}
+
+// formatAnswer renders a stored answer for display. True/false answers are
+// shown as "True"/"False" however they were stored, the accepted answers of a
+// fill in the blank question are separated by " / " and a short answer shows
+// only its first, canonical phrasing; others are unchanged.
+func formatAnswer(questionType, answer string) string {
+	if isTypedAnswer(questionType) && strings.Contains(answer, "|") {
+		accepted := acceptedAnswers(answer)
+		if questionType == "short_answer" && len(accepted) > 0 {
+			return accepted[0]
+		}
+		return strings.Join(accepted, " / ")
+	}
+	if questionType != "true_false" {
+		return answer
+	}
+	
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "true", "t", "yes", "y":
+		return "True"
+	case "false", "f", "no", "n":
+		return "False"
+	default:
+		return answer
+	}
+}
+
 // Time formatting
 func (a *App) formatDuration(d time.Duration) string {
 	minutes := int(d.Minutes())
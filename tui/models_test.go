@@ -0,0 +1,418 @@
+package tui
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fakeGenerator returns canned questions without any network access
+type fakeGenerator struct {
+	questions []*chatgpt.GeneratedQuestion
+	err       error
+	disabled  bool // Stands in for a client without an API key
+
+	// Arguments of the last GenerateQuestions call
+	numQuestions  int
+	questionTypes []string
+	difficulty    string
+}
+
+func (f *fakeGenerator) Enabled() bool { return !f.disabled }
+
+func (f *fakeGenerator) Model() string { return "fake" }
+
+func (f *fakeGenerator) GenerateQuestions(text string, numQuestions int, questionTypes []string, difficulty string) ([]*chatgpt.GeneratedQuestion, *chatgpt.Usage, error) {
+	return f.GenerateQuestionsContext(context.Background(), text, numQuestions, questionTypes, difficulty)
+}
+
+func (f *fakeGenerator) GenerateQuestionsContext(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) ([]*chatgpt.GeneratedQuestion, *chatgpt.Usage, error) {
+	f.numQuestions, f.questionTypes, f.difficulty = numQuestions, questionTypes, difficulty
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return f.questions, nil, f.err
+}
+
+func (f *fakeGenerator) StreamQuestions(ctx context.Context, text string, numQuestions int, questionTypes []string, difficulty string) <-chan chatgpt.StreamEvent {
+	events := make(chan chatgpt.StreamEvent, len(f.questions)+1)
+	questions, _, err := f.GenerateQuestionsContext(ctx, text, numQuestions, questionTypes, difficulty)
+	for _, q := range questions {
+		events <- chatgpt.StreamEvent{Question: q}
+	}
+	events <- chatgpt.StreamEvent{Err: err, Done: true}
+	close(events)
+	return events
+}
+
+func (f *fakeGenerator) GenerateSimilarQuestions(ctx context.Context, existing []string, numQuestions int, questionTypes []string) ([]*chatgpt.GeneratedQuestion, *chatgpt.Usage, error) {
+	return f.GenerateQuestionsContext(ctx, "", numQuestions, questionTypes, "")
+}
+
+// newTestApp creates an app backed by a fresh database and a fake generator,
+// shuffling with a fixed seed
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	a, err := NewApp(filepath.Join(t.TempDir(), "test.db"), &fakeGenerator{})
+	if err != nil {
+		t.Fatalf("NewApp: %v", err)
+	}
+	t.Cleanup(func() { a.db.Close() })
+	a.rng = rand.New(rand.NewSource(1))
+	return a
+}
+
+// createTest saves a test with the given questions, filling in their IDs
+func createTest(t *testing.T, a *App, name string, questions ...*database.Question) *database.Test {
+	t.Helper()
+	test, err := a.db.CreateTest(name, "", "", 0)
+	if err != nil {
+		t.Fatalf("CreateTest: %v", err)
+	}
+	for i, q := range questions {
+		saved, err := a.db.CreateQuestion(test.ID, q.QuestionText, q.QuestionType, q.CorrectAnswer, q.Explanation, q.Options, q.SourceRef, q.Difficulty)
+		if err != nil {
+			t.Fatalf("CreateQuestion: %v", err)
+		}
+		questions[i] = saved
+	}
+	return test
+}
+
+// shortAnswers builds short answer questions with the given texts, each
+// answered by "answer"
+func shortAnswers(texts ...string) []*database.Question {
+	questions := make([]*database.Question, len(texts))
+	for i, text := range texts {
+		questions[i] = &database.Question{QuestionText: text, QuestionType: "short_answer", CorrectAnswer: "answer"}
+	}
+	return questions
+}
+
+// press sends a key to the app as if it were typed, returning the command
+// the app asks to run
+func press(a *App, key string) tea.Cmd {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "backspace":
+		msg = tea.KeyMsg{Type: tea.KeyBackspace}
+	case "up":
+		msg = tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	case "left":
+		msg = tea.KeyMsg{Type: tea.KeyLeft}
+	case "right":
+		msg = tea.KeyMsg{Type: tea.KeyRight}
+	case "pgup":
+		msg = tea.KeyMsg{Type: tea.KeyPgUp}
+	case "pgdown":
+		msg = tea.KeyMsg{Type: tea.KeyPgDown}
+	case "tab":
+		msg = tea.KeyMsg{Type: tea.KeyTab}
+	case " ":
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	_, cmd := a.Update(msg)
+	return cmd
+}
+
+// typeText types each character of text
+func typeText(a *App, text string) {
+	for _, r := range text {
+		press(a, string(r))
+	}
+}
+
+// runCmd runs cmd and every command its messages lead to, feeding the messages
+// to the app. Spinner ticks are dropped so animation does not keep it running.
+func runCmd(t *testing.T, a *App, cmd tea.Cmd) {
+	t.Helper()
+	queue := []tea.Cmd{cmd}
+	for steps := 0; len(queue) > 0; steps++ {
+		if steps > 1000 {
+			t.Fatal("commands did not settle")
+		}
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		case spinner.TickMsg, nil:
+		default:
+			_, cmd := a.Update(msg)
+			queue = append(queue, cmd)
+		}
+	}
+}
+
+func TestIsAnswerCorrectMultiSelect(t *testing.T) {
+	a := newTestApp(t)
+	q := &database.Question{QuestionType: "multi_select", Options: []string{"Saturn", "Earth", "Jupiter", "Mars"}, CorrectAnswer: "A,C"}
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"A,C", true},
+		{"c, a", true},   // order and case do not matter
+		{"A", false},     // partially correct counts as wrong
+		{"A,B,C", false}, // an extra option counts as wrong
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := a.isAnswerCorrect(q, tt.answer); got != tt.want {
+			t.Errorf("isAnswerCorrect(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+
+	questions := []*database.Question{{ID: 1, QuestionType: q.QuestionType, Options: q.Options, CorrectAnswer: q.CorrectAnswer}}
+	for answer, want := range map[string]int{"A,C": 1, "A": 0, "": 0} {
+		if correct, _ := a.calculateScore(questions, map[int]string{1: answer}); correct != want {
+			t.Errorf("calculateScore with %q = %d correct, want %d", answer, correct, want)
+		}
+	}
+}
+
+func TestChoiceLetterStoredForms(t *testing.T) {
+	a := newTestApp(t)
+	options := []string{"Paris", "Rome", "Madrid"}
+	for _, stored := range []string{"A", "a", "Paris", " paris "} {
+		if got := choiceLetter(options, stored); got != "A" {
+			t.Errorf("choiceLetter(%q) = %q, want A", stored, got)
+		}
+
+		q := &database.Question{QuestionType: "multiple_choice", Options: options, CorrectAnswer: stored}
+		if !a.isAnswerCorrect(q, "A") {
+			t.Errorf("answer A marked wrong against %q", stored)
+		}
+		if a.isAnswerCorrect(q, "B") {
+			t.Errorf("answer B marked right against %q", stored)
+		}
+	}
+	if got := choiceLetter(options, " Lisbon "); got != "Lisbon" {
+		t.Errorf("choiceLetter of an unknown answer = %q, want it trimmed", got)
+	}
+}
+
+func TestNormalizeTypedAnswer(t *testing.T) {
+	a := newTestApp(t)
+	q := &database.Question{QuestionType: "short_answer", CorrectAnswer: "George Washington"}
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"George Washington.", true},
+		{"george  washington", true},
+		{"  George\tWashington!  ", true},
+		{"George Washington Carver", false},
+		{"John Adams", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := a.isAnswerCorrect(q, tt.answer); got != tt.want {
+			t.Errorf("isAnswerCorrect(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+	if got := normalizeTypedAnswer("U.S.  Navy."); got != "u.s. navy" {
+		t.Errorf("normalizeTypedAnswer kept %q, want inner punctuation only", got)
+	}
+}
+
+func TestAcceptedAnswersSynonyms(t *testing.T) {
+	a := newTestApp(t)
+	q := &database.Question{QuestionType: "short_answer", CorrectAnswer: "United States|USA|U.S."}
+	for _, answer := range []string{"United States", "usa", "U.S."} {
+		if !a.isAnswerCorrect(q, answer) {
+			t.Errorf("synonym %q rejected", answer)
+		}
+	}
+	if a.isAnswerCorrect(q, "Canada") {
+		t.Error("unrelated answer accepted")
+	}
+	if got := formatAnswer(q.QuestionType, q.CorrectAnswer); got != "United States" {
+		t.Errorf("canonical answer shown as %q, want the first synonym", got)
+	}
+	if got := acceptedAnswers(" Paris | |City of Light"); len(got) != 2 || got[1] != "City of Light" {
+		t.Errorf("acceptedAnswers = %q, want the two non-empty entries", got)
+	}
+}
+
+func TestFillBlankAcceptedAnswers(t *testing.T) {
+	a := newTestApp(t)
+	questions := []*database.Question{{QuestionText: "The capital of France is ___.", QuestionType: "fill_blank", CorrectAnswer: "Paris|City of Light"}}
+	test := createTest(t, a, "Capitals", questions...)
+	q := questions[0]
+	if q.QuestionType != "fill_blank" || q.CorrectAnswer != "Paris|City of Light" {
+		t.Fatalf("fill in the blank question stored as %+v", q)
+	}
+
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"Paris", true},
+		{"  pARIS ", true},
+		{"city of  light", true},
+		{"Pari", false},
+		{"Paris, Texas", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := a.isAnswerCorrect(q, tt.answer); got != tt.want {
+			t.Errorf("isAnswerCorrect(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+
+	// Answered by typing, like a short answer
+	a.startTest(test, questions)
+	typeText(a, " paris ")
+	press(a, "enter")
+	if !a.testTaking.showResult || a.userAnswers[q.ID] != "paris" {
+		t.Fatalf("typed answer stored as %q, finished %v", a.userAnswers[q.ID], a.testTaking.showResult)
+	}
+	if correct, _ := a.calculateScore(a.currentQuestions, a.userAnswers); correct != 1 {
+		t.Errorf("typed answer scored %d correct, want 1", correct)
+	}
+}
+
+func TestWindowSizeWrapsLongText(t *testing.T) {
+	a := newTestApp(t)
+	long := strings.Repeat("Which organelle releases energy from glucose for the cell ", 5) + "?"
+	questions := shortAnswers(long)
+	questions[0].Explanation = strings.Repeat("Mitochondria carry out cellular respiration ", 5)
+	test := createTest(t, a, "Biology", questions...)
+	a.startTest(test, questions)
+	a.userAnswers[a.currentQuestions[0].ID] = "wrong"
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+
+	a.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	if a.width != 80 || a.height != 30 {
+		t.Fatalf("stored %dx%d, want 80x30", a.width, a.height)
+	}
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	a.testResults.viewMode = "detail"
+	a.testTaking.showResult = true
+	a.testTaking.reviewMode = true
+
+	for name, view := range map[string]string{
+		"results detail": a.viewTestResults(),
+		"answer review":  a.viewAnswerReview(),
+	} {
+		if !strings.Contains(view, "Which organelle") {
+			t.Fatalf("%s does not show the question:\n%s", name, view)
+		}
+		for _, line := range strings.Split(view, "\n") {
+			if w := lipgloss.Width(line); w > a.width {
+				t.Errorf("%s has a %d column line on an %d column terminal: %q", name, w, a.width, line)
+			}
+		}
+	}
+}
+
+func TestTerminalTooSmall(t *testing.T) {
+	a := newTestApp(t)
+	fallback := "Please enlarge your terminal (min 80x24)"
+
+	// Nothing is known before the first size arrives
+	if view := a.View(); view == fallback {
+		t.Fatal("fallback shown before the terminal size is known")
+	}
+
+	a.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if view := a.View(); view != fallback {
+		t.Errorf("60x20 terminal shows:\n%s", view)
+	}
+	a.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	if view := a.View(); view != fallback {
+		t.Errorf("100x20 terminal shows:\n%s", view)
+	}
+
+	a.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if view := a.View(); view == fallback || !strings.Contains(view, a.viewMainMenu()) {
+		t.Errorf("resizing to 100x30 did not restore the menu:\n%s", view)
+	}
+
+	// The minimum is configurable, and settings stay reachable to change it
+	a.saveSetting(settingMinTerminalWidth, "40")
+	a.saveSetting(settingMinTerminalHeight, "10")
+	a.Update(tea.WindowSizeMsg{Width: 60, Height: 20})
+	if view := a.View(); view == fallback || strings.Contains(view, "Please enlarge") {
+		t.Errorf("60x20 terminal with a 40x10 minimum shows:\n%s", view)
+	}
+	a.saveSetting(settingMinTerminalWidth, "200")
+	a.currentView = SettingsView
+	if view := a.View(); strings.Contains(view, "Please enlarge") {
+		t.Errorf("settings hidden on a terminal below the minimum:\n%s", view)
+	}
+}
+
+func TestFormatTrueFalseAnswer(t *testing.T) {
+	for _, tc := range []struct{ questionType, answer, want string }{
+		{"true_false", "true", "True"},
+		{"true_false", "T", "True"},
+		{"true_false", " yes ", "True"},
+		{"true_false", "FALSE", "False"},
+		{"true_false", "f", "False"},
+		{"true_false", "maybe", "maybe"},
+		{"short_answer", "true", "true"},
+		{"multiple_choice", "A", "A"},
+	} {
+		if got := formatAnswer(tc.questionType, tc.answer); got != tc.want {
+			t.Errorf("formatAnswer(%q, %q) = %q, want %q", tc.questionType, tc.answer, got, tc.want)
+		}
+	}
+}
+
+func TestTrueFalseAnswersShownConsistently(t *testing.T) {
+	a := newTestApp(t)
+	questions := []*database.Question{{QuestionText: "The sky is blue.", QuestionType: "true_false", CorrectAnswer: "T"}}
+	test := createTest(t, a, "Statements", questions...)
+	a.startTest(test, questions)
+	a.userAnswers[questions[0].ID] = "false"
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+
+	a.testTaking.showResult = true
+	a.testTaking.reviewMode = true
+	review := a.viewTestTaking()
+	for _, want := range []string{"Your answer: False", "Correct answer: True"} {
+		if !strings.Contains(review, want) {
+			t.Errorf("review does not show %q:\n%s", want, review)
+		}
+	}
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	press(a, "enter")
+	detail := a.viewResultsDetail()
+	for _, want := range []string{"Your Answer: False", "Correct Answer: True"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("details do not show %q:\n%s", want, detail)
+		}
+	}
+}
@@ -1,34 +1,58 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+	"pdf-test-generator/pdf"
+
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // PDFProcessModel represents the PDF processing state
 type PDFProcessModel struct {
 	selectedFile    string
+	password        string // Opens the selected PDF when it is encrypted
+	info            map[string]interface{} // Metadata of the selected PDF, nil if it could not be read
+	pageStart       int    // First page to extract, 0 for the whole document
+	pageEnd         int    // Last page to extract, 0 for the whole document
+	extractPage     int    // Page reached by the running extraction
+	extractTotal    int    // Pages the running extraction reads, 0 until it has started
 	extractedText   string
 	step           int // 0: extract, 1: configure, 2: generate
 	errorMsg       string
 	successMsg     string
 	loading        bool
+	spinner        spinner.Model // Animated while loading
 	
 	// Configuration
 	numQuestions   string
 	questionTypes  map[string]bool
 	testName       string
 	testDesc       string
+	timeLimit      int // in minutes, 0 for no limit
+	difficulty     string // Level requested for every question, "" to let ChatGPT rate each one
+	
+	// Set when regenerating an existing test from its source PDF
+	regenerateTestID int
 	
 	// Input mode
-	inputMode      string // "num_questions", "test_name", "test_desc", ""
+	inputMode      string // "num_questions", "test_name", "test_desc", "time_limit", "password", "page_range", ""
 	input          string
 	cursor         int
+	typeCursor     int // Highlighted row of the question types list when cursor is 1
 }
 
+// generationTypes lists the question types that can be generated from a PDF, in display order
+var generationTypes = []string{"multiple_choice", "multi_select", "true_false", "short_answer"}
+
 // NewPDFProcessModel creates a new PDF process model
 func NewPDFProcessModel() *PDFProcessModel {
 	return &PDFProcessModel{
@@ -36,16 +60,54 @@ func NewPDFProcessModel() *PDFProcessModel {
 		numQuestions: "5",
 		questionTypes: map[string]bool{
 			"multiple_choice": true,
+			"multi_select":   false,
 			"true_false":     false,
 			"short_answer":   false,
 		},
 		testName: "Generated Test",
 		testDesc: "Test generated from PDF",
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+}
+
+// pdfExtractedMsg delivers the result of extracting text from a PDF in the
+// background. stat describes the file when extraction started, nil if it
+// could not be read, and is used to cache the text.
+type pdfExtractedMsg struct {
+	path  string
+	text  string
+	err   error
+	stat  os.FileInfo
+	start int
+	end   int
+}
+
+// pdfExtractProgressMsg reports the page a background extraction has reached.
+// updates carries the extraction's further messages.
+type pdfExtractProgressMsg struct {
+	updates <-chan tea.Msg
+	path    string
+	page    int
+	total   int
+}
+
+// waitForExtraction waits for the next progress report or the result of a
+// background extraction
+func waitForExtraction(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
 	}
 }
 
 // updatePDFProcess handles PDF processing updates
 func (a *App) updatePDFProcess(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pdfExtractedMsg:
+		return a.handlePDFExtracted(msg)
+	case pdfExtractProgressMsg:
+		return a.handlePDFExtractProgress(msg)
+	}
+	
 	if a.pdfProcess.loading {
 		return a, nil // Ignore input while loading
 	}
@@ -61,13 +123,19 @@ func (a *App) updatePDFProcess(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "enter", " ":
 				return a.extractPDFText()
+			case "p":
+				a.pdfProcess.inputMode = "page_range"
+				a.pdfProcess.input = ""
 			}
 		case 1: // Configure step
 			return a.handleConfigureStep(msg)
 		case 2: // Generate step
 			switch msg.String() {
 			case "enter", " ":
-				return a.generateQuestions()
+				// Generation is unavailable without an API key; the view explains why
+				if a.chatGPT.Enabled() {
+					return a.generateQuestions()
+				}
 			case "b":
 				a.pdfProcess.step = 1
 			}
@@ -91,7 +159,10 @@ func (a *App) viewPDFProcess() string {
 	}
 	
 	if a.pdfProcess.loading {
-		s += "⏳ Processing... Please wait...\n\n"
+		s += a.pdfProcess.spinner.View() + " Processing... Please wait...\n\n"
+		if a.pdfProcess.extractTotal > 0 {
+			s += fmt.Sprintf("Page %d/%d\n\n", a.pdfProcess.extractPage, a.pdfProcess.extractTotal)
+		}
 		return s + a.renderFooter()
 	}
 	
@@ -110,24 +181,68 @@ func (a *App) viewPDFProcess() string {
 // viewExtractStep renders the text extraction step
 func (a *App) viewExtractStep() string {
 	s := fmt.Sprintf("Selected PDF: %s\n\n", a.pdfProcess.selectedFile)
+	s += a.renderPDFInfo()
+	
+	if a.pdfProcess.inputMode != "" {
+		return s + a.viewInputMode()
+	}
+	
+	s += fmt.Sprintf("📑 Pages: %s\n\n", formatPageRange(a.pdfProcess.pageStart, a.pdfProcess.pageEnd))
 	
 	if a.pdfProcess.extractedText == "" {
-		s += "Press Enter to extract text from the PDF\n"
+		s += "Press Enter to extract text from the PDF, 'p' to choose a page range\n"
 	} else {
 		s += "✅ Text extracted successfully!\n\n"
 		s += "Preview:\n"
 		preview := a.pdfProcessor.GetTextSummary(a.pdfProcess.extractedText, 200)
 		s += borderStyle.Render(preview) + "\n\n"
-		s += "Press Enter to continue to configuration\n"
+		s += "Press Enter to continue to configuration, 'p' to choose a different page range\n"
 	}
 	
 	return s
 }
 
+// loadPDFInfo reads the metadata of the selected PDF so the extract step can
+// show it. Files whose metadata cannot be read simply show none.
+func (a *App) loadPDFInfo() {
+	info, err := a.pdfProcessor.GetPDFInfo(a.pdfProcess.selectedFile)
+	if err != nil {
+		info = nil
+	}
+	a.pdfProcess.info = info
+}
+
+// renderPDFInfo renders the page count and the metadata fields the selected PDF sets
+func (a *App) renderPDFInfo() string {
+	if a.pdfProcess.info == nil {
+		return ""
+	}
+	
+	var s string
+	if pages, ok := a.pdfProcess.info["pages"].(int); ok {
+		s += fmt.Sprintf("📄 Pages: %d\n", pages)
+	}
+	for _, field := range []struct{ key, label string }{
+		{"title", "Title"},
+		{"author", "Author"},
+		{"subject", "Subject"},
+		{"keywords", "Keywords"},
+	} {
+		if value, _ := a.pdfProcess.info[field.key].(string); value != "" {
+			s += fmt.Sprintf("   %s: %s\n", field.label, value)
+		}
+	}
+	return s + "\n"
+}
+
 // viewConfigureStep renders the configuration step
 func (a *App) viewConfigureStep() string {
 	s := "Configure Question Generation:\n\n"
 	
+	if !a.chatGPT.Enabled() {
+		s += a.renderNoAPIKeyBanner()
+	}
+	
 	if a.pdfProcess.inputMode != "" {
 		return s + a.viewInputMode()
 	}
@@ -145,14 +260,19 @@ func (a *App) viewConfigureStep() string {
 		cursor = ">"
 	}
 	s += fmt.Sprintf("%s Question types:\n", cursor)
-	for qType, enabled := range a.pdfProcess.questionTypes {
+	for i, qType := range generationTypes {
 		status := "❌"
-		if enabled {
+		if a.pdfProcess.questionTypes[qType] {
 			status = "✅"
 		}
-		s += fmt.Sprintf("   %s %s\n", status, a.getQuestionTypeDisplay(qType))
+		line := fmt.Sprintf("%s %s", status, a.getQuestionTypeDisplay(qType))
+		if a.pdfProcess.cursor == 1 && a.pdfProcess.typeCursor == i {
+			s += fmt.Sprintf("  ► %s\n", selectedStyle.Render(line))
+		} else {
+			s += fmt.Sprintf("    %s\n", line)
+		}
 	}
-	s += "   (press 't' to toggle types)\n\n"
+	s += "   (press 't' to toggle the highlighted type)\n\n"
 	
 	// Test name
 	cursor = " "
@@ -166,33 +286,85 @@ func (a *App) viewConfigureStep() string {
 	if a.pdfProcess.cursor == 3 {
 		cursor = ">"
 	}
-	s += fmt.Sprintf("%s Test description: %s (press 'd' to edit)\n\n", cursor, a.pdfProcess.testDesc)
+	s += fmt.Sprintf("%s Test description: %s (press 'd' to edit)\n", cursor, a.pdfProcess.testDesc)
+	
+	// Time limit
+	cursor = " "
+	if a.pdfProcess.cursor == 4 {
+		cursor = ">"
+	}
+	s += fmt.Sprintf("%s Time limit: %s (press 'l' to edit)\n", cursor, formatTimeLimit(a.pdfProcess.timeLimit))
+	
+	// Difficulty
+	cursor = " "
+	if a.pdfProcess.cursor == 5 {
+		cursor = ">"
+	}
+	s += fmt.Sprintf("%s Difficulty: %s (press 'v' to change)\n\n", cursor, formatGenerationDifficulty(a.pdfProcess.difficulty))
 	
 	s += "Press Enter to generate questions, arrow keys to navigate\n"
 	
 	return s
 }
 
+// formatGenerationDifficulty renders the difficulty requested from ChatGPT
+func formatGenerationDifficulty(level string) string {
+	if level == "" {
+		return "Mixed (rated per question)"
+	}
+	return formatDifficulty(level)
+}
+
 // viewGenerateStep renders the generation step
 func (a *App) viewGenerateStep() string {
 	s := "Ready to Generate Questions:\n\n"
+	if a.pdfProcess.regenerateTestID != 0 {
+		s += errorStyle.Render("⚠️  This will replace all questions of the existing test. Results history is kept.") + "\n\n"
+	}
 	s += fmt.Sprintf("📄 PDF: %s\n", a.pdfProcess.selectedFile)
 	s += fmt.Sprintf("📝 Test: %s\n", a.pdfProcess.testName)
 	s += fmt.Sprintf("🔢 Questions: %s\n", a.pdfProcess.numQuestions)
+	s += fmt.Sprintf("⏱️  Time limit: %s\n", formatTimeLimit(a.pdfProcess.timeLimit))
+	s += fmt.Sprintf("🎚️  Difficulty: %s\n", formatGenerationDifficulty(a.pdfProcess.difficulty))
 	
 	var enabledTypes []string
-	for qType, enabled := range a.pdfProcess.questionTypes {
-		if enabled {
-			enabledTypes = append(enabledTypes, a.getQuestionTypeDisplay(qType))
-		}
+	for _, qType := range a.enabledQuestionTypes() {
+		enabledTypes = append(enabledTypes, a.getQuestionTypeDisplay(qType))
+	}
+	s += fmt.Sprintf("📋 Types: %s\n", strings.Join(enabledTypes, ", "))
+	s += a.renderGenerationEstimate() + "\n"
+	
+	if !a.chatGPT.Enabled() {
+		s += a.renderNoAPIKeyBanner()
+		s += "Press 'b' to go back\n"
+		return s
 	}
-	s += fmt.Sprintf("📋 Types: %s\n\n", strings.Join(enabledTypes, ", "))
 	
 	s += "Press Enter to generate questions, 'b' to go back\n"
 	
 	return s
 }
 
+// renderGenerationEstimate shows roughly how many tokens generating the
+// configured questions will use, and what that costs at the configured prices
+func (a *App) renderGenerationEstimate() string {
+	numQuestions, _ := strconv.Atoi(a.pdfProcess.numQuestions)
+	usage := chatgpt.EstimateUsage(a.pdfProcess.extractedText, numQuestions, a.enabledQuestionTypes(), a.pdfProcess.difficulty)
+	
+	line := fmt.Sprintf("💰 Estimate: ~%d tokens", usage.TotalTokens)
+	if _, local := a.chatGPT.(*chatgpt.OllamaClient); local {
+		return line + " (free, local model)\n"
+	}
+	return line + fmt.Sprintf(" (≈ $%.4f)\n", a.estimateCost(usage.PromptTokens, usage.CompletionTokens))
+}
+
+// renderNoAPIKeyBanner explains that question generation needs an API key
+func (a *App) renderNoAPIKeyBanner() string {
+	s := errorStyle.Render("⚠️  Question generation is unavailable: no OpenAI API key is set.") + "\n"
+	s += "Add OPENAI_API_KEY=sk-... to your .env file (or environment) and restart the app.\n\n"
+	return s
+}
+
 // viewInputMode renders input mode interface
 func (a *App) viewInputMode() string {
 	var prompt string
@@ -203,10 +375,21 @@ func (a *App) viewInputMode() string {
 		prompt = "Enter test name:"
 	case "test_desc":
 		prompt = "Enter test description:"
+	case "time_limit":
+		prompt = "Enter time limit in minutes (0 for no limit):"
+	case "password":
+		prompt = "This PDF is password-protected. Enter its password:"
+	case "page_range":
+		prompt = "Enter the pages to extract, such as 12-40 or 7 (empty for all):"
+	}
+	
+	input := a.pdfProcess.input
+	if a.pdfProcess.inputMode == "password" {
+		input = strings.Repeat("*", len([]rune(input)))
 	}
 	
 	s := prompt + "\n"
-	s += "> " + a.pdfProcess.input + "\n\n"
+	s += "> " + input + "\n\n"
 	s += "Press Enter to confirm, Esc to cancel\n"
 	
 	return s
@@ -216,12 +399,23 @@ func (a *App) viewInputMode() string {
 func (a *App) handleConfigureStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "up", "k":
-		if a.pdfProcess.cursor > 0 {
+		// Step through the question type rows before leaving the types list
+		if a.pdfProcess.cursor == 1 && a.pdfProcess.typeCursor > 0 {
+			a.pdfProcess.typeCursor--
+		} else if a.pdfProcess.cursor > 0 {
 			a.pdfProcess.cursor--
+			if a.pdfProcess.cursor == 1 {
+				a.pdfProcess.typeCursor = len(generationTypes) - 1
+			}
 		}
 	case "down", "j":
-		if a.pdfProcess.cursor < 3 {
+		if a.pdfProcess.cursor == 1 && a.pdfProcess.typeCursor < len(generationTypes)-1 {
+			a.pdfProcess.typeCursor++
+		} else if a.pdfProcess.cursor < 5 {
 			a.pdfProcess.cursor++
+			if a.pdfProcess.cursor == 1 {
+				a.pdfProcess.typeCursor = 0
+			}
 		}
 	case "n":
 		if a.pdfProcess.cursor == 0 {
@@ -242,6 +436,15 @@ func (a *App) handleConfigureStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.pdfProcess.inputMode = "test_desc"
 			a.pdfProcess.input = a.pdfProcess.testDesc
 		}
+	case "l":
+		if a.pdfProcess.cursor == 4 {
+			a.pdfProcess.inputMode = "time_limit"
+			a.pdfProcess.input = strconv.Itoa(a.pdfProcess.timeLimit)
+		}
+	case "v":
+		if a.pdfProcess.cursor == 5 {
+			a.pdfProcess.difficulty = nextDifficulty(a.pdfProcess.difficulty)
+		}
 	case "enter", " ":
 		a.pdfProcess.step = 2
 	}
@@ -252,6 +455,14 @@ func (a *App) handleConfigureStep(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) handlePDFInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
+		// Retry extraction with the entered password
+		if a.pdfProcess.inputMode == "password" {
+			a.pdfProcess.password = a.pdfProcess.input
+			a.pdfProcess.inputMode = ""
+			a.pdfProcess.input = ""
+			return a.extractPDFText()
+		}
+		
 		// Confirm input
 		switch a.pdfProcess.inputMode {
 		case "num_questions":
@@ -268,6 +479,22 @@ func (a *App) handlePDFInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		case "test_desc":
 			a.pdfProcess.testDesc = strings.TrimSpace(a.pdfProcess.input)
+		case "time_limit":
+			if minutes, err := parseTimeLimit(a.pdfProcess.input); err == nil {
+				a.pdfProcess.timeLimit = minutes
+			} else {
+				a.pdfProcess.errorMsg = err.Error()
+			}
+		case "page_range":
+			if start, end, err := parsePageRange(a.pdfProcess.input); err == nil {
+				if start != a.pdfProcess.pageStart || end != a.pdfProcess.pageEnd {
+					// Text from other pages must be extracted again
+					a.pdfProcess.extractedText = ""
+				}
+				a.pdfProcess.pageStart, a.pdfProcess.pageEnd = start, end
+			} else {
+				a.pdfProcess.errorMsg = err.Error()
+			}
 		}
 		a.pdfProcess.inputMode = ""
 		a.pdfProcess.input = ""
@@ -296,94 +523,304 @@ func (a *App) extractPDFText() (tea.Model, tea.Cmd) {
 		return a, nil
 	}
 	
+	path := a.pdfProcess.selectedFile
+	password := a.pdfProcess.password
+	start, end := a.pdfProcess.pageStart, a.pdfProcess.pageEnd
+	
+	// Reuse text extracted earlier from the same version of the file
+	stat, err := os.Stat(path)
+	if err != nil {
+		stat = nil
+	} else if text, ok := a.cachedPDFText(path, start, end, stat); ok {
+		a.pdfProcess.extractedText = text
+		a.pdfProcess.successMsg = "Text loaded from cache (the PDF has not changed since it was last extracted)"
+		a.pdfProcess.step = 1
+		return a, nil
+	}
+	
 	a.pdfProcess.loading = true
 	
-	// Extract text from PDF
-	text, err := a.pdfProcessor.ExtractText(a.pdfProcess.selectedFile)
+	// Extract text in the background so the view keeps painting and responding
+	// to Ctrl+C. Progress reports are dropped while the view is busy; the
+	// result is always delivered.
+	processor := a.pdfProcessor
+	updates := make(chan tea.Msg)
+	go func() {
+		progress := func(page, total int) {
+			select {
+			case updates <- pdfExtractProgressMsg{updates: updates, path: path, page: page, total: total}:
+			default:
+			}
+		}
+		text, err := processor.ExtractTextPagesWithPassword(path, password, start, end, progress)
+		updates <- pdfExtractedMsg{path: path, text: text, err: err, stat: stat, start: start, end: end}
+	}()
+	
+	a.pdfProcess.extractPage = 0
+	a.pdfProcess.extractTotal = 0
+	return a, tea.Batch(waitForExtraction(updates), a.pdfProcess.spinner.Tick)
+}
+
+// cachedPDFText returns text extracted earlier from pages start to end of the
+// file at path, if the file is unchanged since. Cache errors count as a miss.
+func (a *App) cachedPDFText(path string, start, end int, stat os.FileInfo) (string, bool) {
+	text, ok, err := a.db.GetCachedText(absPath(path), start, end, stat.ModTime(), stat.Size())
 	if err != nil {
-		a.pdfProcess.errorMsg = fmt.Sprintf("Failed to extract text: %v", err)
-		a.pdfProcess.loading = false
+		return "", false
+	}
+	return text, ok
+}
+
+// absPath returns path made absolute, or path itself if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// handlePDFExtractProgress shows the page a background extraction has reached
+// and waits for its next update
+func (a *App) handlePDFExtractProgress(msg pdfExtractProgressMsg) (tea.Model, tea.Cmd) {
+	if msg.path == a.pdfProcess.selectedFile {
+		a.pdfProcess.extractPage = msg.page
+		a.pdfProcess.extractTotal = msg.total
+	}
+	return a, waitForExtraction(msg.updates)
+}
+
+// handlePDFExtracted stores the text extracted in the background
+func (a *App) handlePDFExtracted(msg pdfExtractedMsg) (tea.Model, tea.Cmd) {
+	// Ignore results for a file that is no longer selected
+	if msg.path != a.pdfProcess.selectedFile {
 		return a, nil
 	}
 	
-	a.pdfProcess.extractedText = text
-	a.pdfProcess.successMsg = "Text extracted successfully!"
 	a.pdfProcess.loading = false
+	
+	// Ask for the password of an encrypted PDF, again if the last one was wrong
+	if errors.Is(msg.err, pdf.ErrPasswordRequired) {
+		if a.pdfProcess.password != "" {
+			a.pdfProcess.errorMsg = "Wrong password, please try again"
+		}
+		a.pdfProcess.password = ""
+		a.pdfProcess.inputMode = "password"
+		return a, nil
+	}
+	
+	if msg.err != nil {
+		a.pdfProcess.errorMsg = fmt.Sprintf("Failed to extract text: %v", msg.err)
+		return a, nil
+	}
+	
+	a.pdfProcess.extractedText = msg.text
+	a.pdfProcess.successMsg = "Text extracted successfully!"
+	if msg.stat != nil {
+		if err := a.db.SaveCachedText(absPath(msg.path), msg.start, msg.end, msg.stat.ModTime(), msg.stat.Size(), msg.text); err != nil {
+			a.pdfProcess.errorMsg = fmt.Sprintf("Failed to cache extracted text: %v", err)
+		}
+	}
 	a.pdfProcess.step = 1
 	
 	return a, nil
 }
 
-// generateQuestions generates questions using ChatGPT
-func (a *App) generateQuestions() (tea.Model, tea.Cmd) {
-	a.pdfProcess.loading = true
+// parsePageRange parses a page range such as "12-40" or a single page such as
+// "7". An empty range selects the whole document and is returned as 0, 0.
+func parsePageRange(input string) (start, end int, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" || strings.EqualFold(input, "all") {
+		return 0, 0, nil
+	}
 	
-	// Get enabled question types
-	var questionTypes []string
-	for qType, enabled := range a.pdfProcess.questionTypes {
-		if enabled {
-			questionTypes = append(questionTypes, qType)
+	first, last, isRange := strings.Cut(input, "-")
+	start, err = strconv.Atoi(strings.TrimSpace(first))
+	if err == nil {
+		end = start
+		if isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(last))
 		}
 	}
+	if err != nil || start < 1 || end < start {
+		return 0, 0, fmt.Errorf("Please enter pages as a range such as 12-40, or a single page number")
+	}
+	return start, end, nil
+}
+
+// formatPageRange renders the pages selected for extraction
+func formatPageRange(start, end int) string {
+	switch {
+	case start == 0:
+		return "All"
+	case start == end:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d-%d", start, end)
+	}
+}
+
+// generateQuestions streams questions from ChatGPT into the generation view
+func (a *App) generateQuestions() (tea.Model, tea.Cmd) {
+	// Get enabled question types
+	questionTypes := a.enabledQuestionTypes()
 	
 	if len(questionTypes) == 0 {
 		a.pdfProcess.errorMsg = "Please select at least one question type"
-		a.pdfProcess.loading = false
 		a.pdfProcess.step = 1
 		return a, nil
 	}
 	
+	// Check the name before generating, so the questions aren't lost on saving
+	if a.pdfProcess.regenerateTestID == 0 {
+		if err := a.db.CheckTestName(a.pdfProcess.testName); err != nil {
+			if errors.Is(err, database.ErrDuplicateName) {
+				a.promptNewPDFTestName()
+			} else {
+				a.pdfProcess.errorMsg = err.Error()
+				a.pdfProcess.step = 1
+			}
+			return a, nil
+		}
+	}
+	
 	numQuestions, _ := strconv.Atoi(a.pdfProcess.numQuestions)
+	return a, a.startQuestionGeneration(a.pdfProcess.extractedText, numQuestions, questionTypes, a.pdfProcess.difficulty)
+}
+
+// promptNewPDFTestName returns to the generation settings and asks for another
+// name, as a test already has the chosen one
+func (a *App) promptNewPDFTestName() {
+	a.pdfProcess.errorMsg = fmt.Sprintf("A test named '%s' already exists, please enter a different name", a.pdfProcess.testName)
+	a.pdfProcess.step = 1
+	a.pdfProcess.cursor = 2
+	a.pdfProcess.inputMode = "test_name"
+	a.pdfProcess.input = a.pdfProcess.testName
+}
+
+// saveGeneratedQuestions stores generated questions in a new test, or in place
+// of the questions of the test being regenerated
+func (a *App) saveGeneratedQuestions(generatedQuestions []*chatgpt.GeneratedQuestion) error {
+	if err := normalizeGeneratedAnswers(generatedQuestions); err != nil {
+		return err
+	}
 	
-	// Generate questions using ChatGPT
-	generatedQuestions, err := a.chatGPT.GenerateQuestions(a.pdfProcess.extractedText, numQuestions, questionTypes)
-	if err != nil {
-		a.pdfProcess.errorMsg = fmt.Sprintf("Failed to generate questions: %v", err)
-		a.pdfProcess.loading = false
-		return a, nil
+	// Replace the questions of an existing test when regenerating from its source
+	if a.pdfProcess.regenerateTestID != 0 {
+		return a.replaceGeneratedQuestions(generatedQuestions)
 	}
 	
 	// Create test in database
-	test, err := a.db.CreateTest(a.pdfProcess.testName, a.pdfProcess.testDesc)
+	sourcePath, err := filepath.Abs(a.pdfProcess.selectedFile)
 	if err != nil {
-		a.pdfProcess.errorMsg = fmt.Sprintf("Failed to create test: %v", err)
-		a.pdfProcess.loading = false
-		return a, nil
+		sourcePath = a.pdfProcess.selectedFile
+	}
+	test, err := a.db.CreateTest(a.pdfProcess.testName, a.pdfProcess.testDesc, sourcePath, a.pdfProcess.timeLimit*60)
+	if err != nil {
+		return fmt.Errorf("failed to create test: %w", err)
 	}
 	
 	// Save questions to database
 	for _, gq := range generatedQuestions {
-		_, err := a.db.CreateQuestion(test.ID, gq.Question, gq.Type, gq.CorrectAnswer, gq.Explanation, gq.Options)
+		_, err := a.db.CreateQuestion(test.ID, gq.Question, gq.Type, gq.CorrectAnswer, gq.Explanation, gq.Options, gq.SourceRef, gq.Difficulty)
 		if err != nil {
-			a.pdfProcess.errorMsg = fmt.Sprintf("Failed to save question: %v", err)
-			a.pdfProcess.loading = false
-			return a, nil
+			return fmt.Errorf("failed to save question: %w", err)
 		}
 	}
 	
-	a.pdfProcess.loading = false
-	a.pdfProcess.successMsg = fmt.Sprintf("Successfully generated %d questions!", len(generatedQuestions))
+	return nil
+}
+
+// normalizeGeneratedAnswers makes sure multiple choice and select all that
+// apply answers are stored as option letters so they can be scored, and
+// rejects questions with repeated options
+func normalizeGeneratedAnswers(generatedQuestions []*chatgpt.GeneratedQuestion) error {
+	for i, gq := range generatedQuestions {
+		if gq.Type != "multiple_choice" && gq.Type != "multi_select" {
+			continue
+		}
+		if err := validateOptions(gq.Options); err != nil {
+			return fmt.Errorf("Generated question %d has duplicate options: %v", i+1, err)
+		}
+		if gq.Type == "multi_select" {
+			if err := checkSelectionLetters(gq.Options, gq.CorrectAnswer); err != nil {
+				return fmt.Errorf("Generated question %d is invalid: %v", i+1, err)
+			}
+			gq.CorrectAnswer = normalizeSelectionAnswer(gq.CorrectAnswer)
+			continue
+		}
+		letter, err := normalizeChoiceAnswer(gq.Options, gq.CorrectAnswer)
+		if err != nil {
+			return fmt.Errorf("Generated question %d is invalid: %v", i+1, err)
+		}
+		gq.CorrectAnswer = letter
+	}
+	return nil
+}
+
+// replaceGeneratedQuestions swaps the questions of the test being regenerated
+// for freshly generated ones, keeping the test and its results
+func (a *App) replaceGeneratedQuestions(generatedQuestions []*chatgpt.GeneratedQuestion) error {
+	questions := make([]*database.Question, len(generatedQuestions))
+	for i, gq := range generatedQuestions {
+		questions[i] = &database.Question{
+			QuestionText:  gq.Question,
+			QuestionType:  gq.Type,
+			Options:       gq.Options,
+			CorrectAnswer: gq.CorrectAnswer,
+			Explanation:   gq.Explanation,
+			SourceRef:     gq.SourceRef,
+			Difficulty:    gq.Difficulty,
+		}
+	}
 	
-	// Switch to main menu after success
-	a.currentView = MainMenuView
+	if err := a.db.ReplaceTestQuestions(a.pdfProcess.regenerateTestID, questions); err != nil {
+		return fmt.Errorf("failed to replace questions: %w", err)
+	}
 	
-	return a, nil
+	// The unfinished attempt was of the old questions and is gone with them
+	delete(a.testSelection.unfinished, a.pdfProcess.regenerateTestID)
+	a.pdfProcess.regenerateTestID = 0
+	return nil
 }
 
-// toggleQuestionTypes toggles question type selection
+// enabledQuestionTypes returns the question types enabled for generation, in display order
+func (a *App) enabledQuestionTypes() []string {
+	var questionTypes []string
+	for _, qType := range generationTypes {
+		if a.pdfProcess.questionTypes[qType] {
+			questionTypes = append(questionTypes, qType)
+		}
+	}
+	return questionTypes
+}
+
+// toggleQuestionTypes flips the highlighted question type, leaving the others
+// untouched. At least one type always stays enabled.
 func (a *App) toggleQuestionTypes() (tea.Model, tea.Cmd) {
-	// Simple toggle - cycle through enabling different types
-	types := []string{"multiple_choice", "true_false", "short_answer"}
+	qType := generationTypes[a.pdfProcess.typeCursor]
 	
-	// Find currently enabled type and move to next
-	for i, qType := range types {
-		if a.pdfProcess.questionTypes[qType] {
-			a.pdfProcess.questionTypes[qType] = false
-			nextType := types[(i+1)%len(types)]
-			a.pdfProcess.questionTypes[nextType] = true
-			break
+	if a.pdfProcess.questionTypes[qType] {
+		enabled := 0
+		for _, t := range generationTypes {
+			if a.pdfProcess.questionTypes[t] {
+				enabled++
+			}
+		}
+		if enabled == 1 {
+			a.pdfProcess.errorMsg = "At least one question type must be enabled"
+			return a, nil
 		}
 	}
 	
+	a.pdfProcess.questionTypes[qType] = !a.pdfProcess.questionTypes[qType]
 	return a, nil
+}
+// recordUsage persists the token usage of a generation, if the API reported it
+func (a *App) recordUsage(usage *chatgpt.Usage) {
+	if usage == nil {
+		return
+	}
+	if err := a.db.RecordUsage(usage.Model, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens); err != nil {
+		a.pdfProcess.errorMsg = fmt.Sprintf("Failed to record token usage: %v", err)
+	}
 }
\ No newline at end of file
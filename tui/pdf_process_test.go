@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/pdf"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParsePageRange(t *testing.T) {
+	tests := []struct {
+		input      string
+		start, end int
+		ok         bool
+	}{
+		{"12-40", 12, 40, true},
+		{" 3 - 5 ", 3, 5, true},
+		{"7", 7, 7, true},
+		{"", 0, 0, true},
+		{"All", 0, 0, true},
+		{"0-4", 0, 0, false},
+		{"9-3", 0, 0, false},
+		{"-2", 0, 0, false},
+		{"one-two", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, err := parsePageRange(tt.input)
+		if (err == nil) != tt.ok || start != tt.start || end != tt.end {
+			t.Errorf("parsePageRange(%q) = %d, %d, %v; want %d, %d, ok %v", tt.input, start, end, err, tt.start, tt.end, tt.ok)
+		}
+	}
+	if got := formatPageRange(7, 7); got != "7" {
+		t.Errorf("single page shown as %q", got)
+	}
+}
+
+// openConfigureStep shows the generation settings for some extracted text
+func openConfigureStep(a *App) {
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = "notes.pdf"
+	a.pdfProcess.extractedText = "Cells are the basic unit of life."
+	a.pdfProcess.step = 1
+}
+
+func TestToggleQuestionTypesIndependently(t *testing.T) {
+	a := newTestApp(t)
+	openConfigureStep(a)
+	enabled := func() string { return strings.Join(a.enabledQuestionTypes(), ",") }
+
+	// Down from the question count onto the type rows, then to True/False
+	press(a, "down")
+	press(a, "down")
+	press(a, "down")
+	if got := generationTypes[a.pdfProcess.typeCursor]; got != "true_false" {
+		t.Fatalf("highlighted %s, want true_false", got)
+	}
+	press(a, "t")
+	if got := enabled(); got != "multiple_choice,true_false" {
+		t.Fatalf("enabled %s after turning on True/False", got)
+	}
+
+	// Turning Multiple Choice off leaves True/False on, which then cannot be
+	// turned off as the last enabled type
+	press(a, "up")
+	press(a, "up")
+	press(a, "t")
+	if got := enabled(); got != "true_false" {
+		t.Fatalf("enabled %s after turning off Multiple Choice", got)
+	}
+	press(a, "down")
+	press(a, "down")
+	press(a, "t")
+	if got := enabled(); got != "true_false" || a.pdfProcess.errorMsg == "" {
+		t.Errorf("enabled %s after turning off the last type, error %q", got, a.pdfProcess.errorMsg)
+	}
+	press(a, "up")
+	press(a, "up")
+	press(a, "t")
+
+	press(a, "enter")
+	runCmd(t, a, press(a, "enter"))
+	generator := a.chatGPT.(*fakeGenerator)
+	if strings.Join(generator.questionTypes, ",") != "multiple_choice,true_false" {
+		t.Errorf("generated with types %v, want multiple_choice and true_false", generator.questionTypes)
+	}
+}
+
+func TestConfigureStepTypeOrderStable(t *testing.T) {
+	a := newTestApp(t)
+	openConfigureStep(a)
+	for _, qType := range generationTypes {
+		a.pdfProcess.questionTypes[qType] = true
+	}
+
+	first := a.viewConfigureStep()
+	last := -1
+	for _, qType := range generationTypes {
+		at := strings.Index(first, a.getQuestionTypeDisplay(qType))
+		if at < last {
+			t.Fatalf("%s is not listed in order:\n%s", qType, first)
+		}
+		last = at
+	}
+	for i := 0; i < 20; i++ {
+		if view := a.viewConfigureStep(); view != first {
+			t.Fatalf("render %d differs from the first:\n%s\nwant\n%s", i+2, view, first)
+		}
+	}
+	if got := strings.Join(a.enabledQuestionTypes(), ","); got != strings.Join(generationTypes, ",") {
+		t.Errorf("enabled types in order %s", got)
+	}
+}
+
+// awaitExtraction follows cmd through the progress reports of a background
+// extraction to the extracted text, which is returned without being delivered
+func awaitExtraction(t *testing.T, a *App, cmd tea.Cmd) pdfExtractedMsg {
+	t.Helper()
+	queue := []tea.Cmd{cmd}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		switch msg := next().(type) {
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		case pdfExtractProgressMsg:
+			_, cmd := a.Update(msg)
+			queue = append(queue, cmd)
+		case pdfExtractedMsg:
+			return msg
+		}
+	}
+	t.Fatal("the command never delivered a pdfExtractedMsg")
+	return pdfExtractedMsg{}
+}
+
+func TestExtractPDFTextInBackground(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = filepath.Join("testdata", "cells.pdf")
+
+	cmd := press(a, "enter")
+	if !a.pdfProcess.loading || cmd == nil {
+		t.Fatalf("extraction did not start in the background: loading %v", a.pdfProcess.loading)
+	}
+	if view := a.viewPDFProcess(); !strings.Contains(view, "Processing...") {
+		t.Errorf("the view does not show extraction in progress:\n%s", view)
+	}
+
+	extracted := awaitExtraction(t, a, cmd)
+	if extracted.err != nil || !strings.Contains(extracted.text, "Cells are the basic unit of life") {
+		t.Fatalf("extracted %q, %v", extracted.text, extracted.err)
+	}
+	if !a.pdfProcess.loading {
+		t.Error("loading ended before the text arrived")
+	}
+
+	a.Update(extracted)
+	if a.pdfProcess.loading || a.pdfProcess.step != 1 || a.pdfProcess.extractedText != extracted.text {
+		t.Errorf("after the text arrived: loading %v, step %d, text %q", a.pdfProcess.loading, a.pdfProcess.step, a.pdfProcess.extractedText)
+	}
+}
+
+func TestSpinnerAdvancesWhileLoading(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = PDFProcessView
+	a.pdfProcess.loading = true
+
+	before := a.pdfProcess.spinner.View()
+	_, cmd := a.Update(a.pdfProcess.spinner.Tick())
+	if a.pdfProcess.spinner.View() == before {
+		t.Error("a tick did not advance the spinner frame")
+	}
+	if cmd == nil {
+		t.Error("the spinner did not schedule its next tick")
+	}
+
+	// Once the result has arrived, ticks are dropped and the spinner stops
+	a.pdfProcess.loading = false
+	before = a.pdfProcess.spinner.View()
+	_, cmd = a.Update(a.pdfProcess.spinner.Tick())
+	if a.pdfProcess.spinner.View() != before || cmd != nil {
+		t.Error("the spinner kept running after loading ended")
+	}
+}
+
+func TestConfigureStepDifficulty(t *testing.T) {
+	a := newTestApp(t)
+	openConfigureStep(a)
+	for i := 0; a.pdfProcess.cursor != 5; i++ {
+		if i == 20 {
+			t.Fatal("never reached the difficulty row")
+		}
+		press(a, "down")
+	}
+
+	for _, want := range []string{"Easy", "Medium", "Hard"} {
+		press(a, "v")
+		if view := a.viewConfigureStep(); !strings.Contains(view, "Difficulty: "+want) {
+			t.Fatalf("difficulty row does not read %s:\n%s", want, view)
+		}
+	}
+
+	press(a, "enter")
+	runCmd(t, a, press(a, "enter"))
+	if got := a.chatGPT.(*fakeGenerator).difficulty; got != "hard" {
+		t.Errorf("generated with difficulty %q, want hard", got)
+	}
+}
+
+func TestGenerationEstimateUsesPrices(t *testing.T) {
+	a := newTestApp(t)
+	a.saveSetting(settingPromptPrice, "0.5")
+	a.saveSetting(settingCompletionPrice, "2")
+	if got := a.estimateCost(2000, 1000); got != 3 {
+		t.Errorf("2000 prompt and 1000 completion tokens cost $%.4f, want $3", got)
+	}
+
+	openConfigureStep(a)
+	a.pdfProcess.numQuestions = "2"
+	usage := chatgpt.EstimateUsage(a.pdfProcess.extractedText, 2, a.enabledQuestionTypes(), "")
+	want := fmt.Sprintf("Estimate: ~%d tokens (≈ $%.4f)", usage.TotalTokens, a.estimateCost(usage.PromptTokens, usage.CompletionTokens))
+	if view := a.viewGenerateStep(); !strings.Contains(view, want) {
+		t.Errorf("generate step does not show %q:\n%s", want, view)
+	}
+}
+
+func TestPasswordPromptForEncryptedPDF(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = filepath.Join("testdata", "cells.pdf")
+	locked := pdfExtractedMsg{path: a.pdfProcess.selectedFile, err: pdf.ErrPasswordRequired}
+
+	// Stand in for an opener that finds the file encrypted
+	a.pdfProcess.loading = true
+	a.Update(locked)
+	if a.pdfProcess.inputMode != "password" || a.pdfProcess.errorMsg != "" {
+		t.Fatalf("input mode %q with error %q, want a password prompt", a.pdfProcess.inputMode, a.pdfProcess.errorMsg)
+	}
+	view := a.viewPDFProcess()
+	if !strings.Contains(view, "This PDF is password-protected") {
+		t.Fatalf("no password prompt:\n%s", view)
+	}
+	typeText(a, "wrong")
+	if view := a.viewPDFProcess(); !strings.Contains(view, "> *****") || strings.Contains(view, "wrong") {
+		t.Errorf("the password is not masked:\n%s", view)
+	}
+
+	// A rejected password asks again
+	press(a, "enter")
+	if a.pdfProcess.password != "wrong" || !a.pdfProcess.loading {
+		t.Fatalf("extraction not retried with the password: password %q, loading %v", a.pdfProcess.password, a.pdfProcess.loading)
+	}
+	a.Update(locked)
+	if a.pdfProcess.inputMode != "password" || a.pdfProcess.errorMsg != "Wrong password, please try again" {
+		t.Fatalf("input mode %q with error %q after a wrong password", a.pdfProcess.inputMode, a.pdfProcess.errorMsg)
+	}
+
+	typeText(a, "secret")
+	extracted := awaitExtraction(t, a, press(a, "enter"))
+	a.Update(extracted)
+	if a.pdfProcess.step != 1 || !strings.Contains(a.pdfProcess.extractedText, "Cells are the basic unit of life") {
+		t.Errorf("step %d with text %q after the right password", a.pdfProcess.step, a.pdfProcess.extractedText)
+	}
+}
+
+func TestExtractStepShowsPageProgress(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = "big.pdf"
+	a.pdfProcess.loading = true
+
+	updates := make(chan tea.Msg)
+	a.Update(pdfExtractProgressMsg{updates: updates, path: "big.pdf", page: 12, total: 200})
+	if view := a.viewPDFProcess(); !strings.Contains(view, "Page 12/200") {
+		t.Errorf("view does not show the page reached:\n%s", view)
+	}
+
+	// Reports from a file that is no longer selected are ignored
+	a.Update(pdfExtractProgressMsg{updates: updates, path: "other.pdf", page: 1, total: 5})
+	if view := a.viewPDFProcess(); !strings.Contains(view, "Page 12/200") {
+		t.Errorf("progress of another file replaced the page reached:\n%s", view)
+	}
+}
+
+func TestExtractStepShowsPDFInfo(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = "biology.pdf"
+	a.pdfProcess.info = map[string]interface{}{
+		"pages":    12,
+		"title":    "Cell Biology",
+		"author":   "Jane Doe",
+		"subject":  "",
+		"keywords": "",
+	}
+
+	view := a.viewExtractStep()
+	for _, want := range []string{"📄 Pages: 12", "Title: Cell Biology", "Author: Jane Doe"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("extract step does not show %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Subject:") || strings.Contains(view, "Keywords:") {
+		t.Errorf("extract step shows empty metadata fields:\n%s", view)
+	}
+}
+
+func TestExtractRefusesLargeFile(t *testing.T) {
+	a := newTestApp(t)
+	// About 100 bytes, well below the fixture's size
+	a.saveSetting(settingMaxPDFSize, "0.0001")
+	a.currentView = PDFProcessView
+	a.pdfProcess.selectedFile = filepath.Join("testdata", "cells.pdf")
+
+	a.Update(awaitExtraction(t, a, press(a, "enter")))
+	if a.pdfProcess.step != 0 || a.pdfProcess.extractedText != "" {
+		t.Fatalf("step %d with text %q, want the file refused", a.pdfProcess.step, a.pdfProcess.extractedText)
+	}
+	if view := a.viewPDFProcess(); !strings.Contains(view, "file too large") {
+		t.Errorf("view does not explain the size limit:\n%s", view)
+	}
+}
+
+func TestNoAPIKeyBanner(t *testing.T) {
+	a := newTestApp(t)
+	generator := a.chatGPT.(*fakeGenerator)
+	generator.disabled = true
+	openConfigureStep(a)
+
+	const banner = "no OpenAI API key is set"
+	if view := a.viewConfigureStep(); !strings.Contains(view, banner) {
+		t.Errorf("configure step does not explain that generation is unavailable:\n%s", view)
+	}
+
+	a.pdfProcess.step = 2
+	view := a.viewGenerateStep()
+	if !strings.Contains(view, banner) || !strings.Contains(view, "OPENAI_API_KEY") {
+		t.Errorf("generate step does not explain how to enable generation:\n%s", view)
+	}
+	if strings.Contains(view, "Press Enter to generate") {
+		t.Errorf("generate step still offers Enter:\n%s", view)
+	}
+	if cmd := press(a, "enter"); cmd != nil || a.pdfProcess.step != 2 || generator.numQuestions != 0 {
+		t.Errorf("Enter started generating without an API key (step %d)", a.pdfProcess.step)
+	}
+
+	generator.disabled = false
+	if view := a.viewGenerateStep(); strings.Contains(view, banner) {
+		t.Errorf("banner shown with an API key:\n%s", view)
+	}
+}
+
+func TestGenerateRefusesDuplicateName(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Biology", shortAnswers("Q1")...)
+	generator := a.chatGPT.(*fakeGenerator)
+	openConfigureStep(a)
+	a.pdfProcess.testName = "biology"
+	a.pdfProcess.step = 2
+
+	if cmd := press(a, "enter"); cmd != nil {
+		t.Fatal("generation started under a taken name")
+	}
+	if a.pdfProcess.step != 1 || a.pdfProcess.inputMode != "test_name" || a.pdfProcess.input != "biology" {
+		t.Errorf("not asked for another name: step %d, mode %q, input %q", a.pdfProcess.step, a.pdfProcess.inputMode, a.pdfProcess.input)
+	}
+	if !strings.Contains(a.pdfProcess.errorMsg, "already exists") {
+		t.Errorf("error = %q, want the name is taken", a.pdfProcess.errorMsg)
+	}
+	if generator.numQuestions != 0 {
+		t.Error("generator called under a taken name")
+	}
+}
@@ -1,8 +1,15 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -15,12 +22,41 @@ type QuestionGenModel struct {
 	progress    string
 	generatedQuestions int
 	totalQuestions     int
+	spinner            spinner.Model // Animated while generating
+	
+	// Questions received so far from the stream being generated
+	questions []*chatgpt.GeneratedQuestion
+	stream    <-chan chatgpt.StreamEvent
+	cancel    context.CancelFunc
+	
+	// Test whose topics the questions are generated from, nil when they are
+	// generated from a PDF
+	similarTo *database.Test
 }
 
 // NewQuestionGenModel creates a new question generation model
 func NewQuestionGenModel() *QuestionGenModel {
 	return &QuestionGenModel{
-		status: "idle",
+		status:  "idle",
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+}
+
+// questionStreamMsg delivers one event of a streamed question generation.
+// stream identifies the generation, so events of a cancelled one are ignored.
+type questionStreamMsg struct {
+	stream <-chan chatgpt.StreamEvent
+	event  chatgpt.StreamEvent
+}
+
+// waitForQuestion waits for the next event of a question stream
+func waitForQuestion(stream <-chan chatgpt.StreamEvent) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-stream
+		if !ok {
+			event = chatgpt.StreamEvent{Err: fmt.Errorf("generation stopped"), Done: true}
+		}
+		return questionStreamMsg{stream: stream, event: event}
 	}
 }
 
@@ -31,11 +67,18 @@ func (a *App) updateQuestionGen(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "q":
 			a.currentView = MainMenuView
+		case "c", "esc":
+			// Stop a generation whose output looks wrong
+			if a.questionGen.status == "generating" {
+				a.cancelGeneration()
+			}
 		case "r":
 			// Restart generation if failed
 			if a.questionGen.status == "error" {
-				a.questionGen.status = "idle"
-				a.questionGen.errorMsg = ""
+				if a.questionGen.similarTo != nil {
+					return a, a.startSimilarGeneration(a.questionGen.similarTo)
+				}
+				return a.generateQuestions()
 			}
 		}
 	}
@@ -58,17 +101,24 @@ func (a *App) viewQuestionGen() string {
 	case "idle":
 		s += "Ready to generate questions...\n\n"
 	case "generating":
-		s += "Generating questions from PDF content...\n\n"
+		if a.questionGen.similarTo != nil {
+			s += a.questionGen.spinner.View() + fmt.Sprintf(" Generating questions on the topics of '%s'...\n\n", a.questionGen.similarTo.Name)
+		} else {
+			s += a.questionGen.spinner.View() + " Generating questions from PDF content...\n\n"
+		}
 		if a.questionGen.progress != "" {
 			s += a.questionGen.progress + "\n\n"
 		}
 		if a.questionGen.totalQuestions > 0 {
-			s += fmt.Sprintf("Progress: %d/%d questions generated\n\n", 
+			s += fmt.Sprintf("Progress: %d/%d questions generated\n\n",
 				a.questionGen.generatedQuestions, a.questionGen.totalQuestions)
 		}
+		s += a.renderStreamedQuestions()
+		s += "Press 'c' or esc to cancel without saving\n"
 	case "completed":
 		s += "Question generation completed successfully!\n\n"
 		s += fmt.Sprintf("Generated %d questions\n\n", a.questionGen.generatedQuestions)
+		s += a.renderStreamedQuestions()
 	case "error":
 		s += "Question generation failed.\n\n"
 		s += "Press 'r' to retry\n\n"
@@ -79,14 +129,115 @@ func (a *App) viewQuestionGen() string {
 	return s + a.renderFooter()
 }
 
-// startQuestionGeneration starts the question generation process
-func (a *App) startQuestionGeneration(text string, numQuestions int) {
+// renderStreamedQuestions lists the questions received so far, one line each
+func (a *App) renderStreamedQuestions() string {
+	var s string
+	for i, q := range a.questionGen.questions {
+		s += fmt.Sprintf("%d. [%s] %s\n", i+1, a.getQuestionTypeDisplay(q.Type), q.Question)
+	}
+	if s != "" {
+		s += "\n"
+	}
+	return s
+}
+
+// startQuestionGeneration starts streaming questions generated from text and
+// switches to the generation view. The returned command waits for the first
+// question and starts the spinner. The generation is abandoned once the
+// configured generation timeout has passed.
+func (a *App) startQuestionGeneration(text string, numQuestions int, questionTypes []string, difficulty string) tea.Cmd {
+	ctx, cancel := a.generationContext()
+	a.questionGen.similarTo = nil
+	return a.beginGeneration(a.chatGPT.StreamQuestions(ctx, text, numQuestions, questionTypes, difficulty), cancel, numQuestions)
+}
+
+// generationContext returns the context a generation runs in, which ends once
+// the configured generation timeout has passed
+func (a *App) generationContext() (context.Context, context.CancelFunc) {
+	if timeout := a.generationTimeout(); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// beginGeneration shows the generation view while the questions of stream
+// arrive. The returned command waits for the first question and starts the spinner.
+func (a *App) beginGeneration(stream <-chan chatgpt.StreamEvent, cancel context.CancelFunc, numQuestions int) tea.Cmd {
 	a.questionGen.status = "generating"
 	a.questionGen.generatedQuestions = 0
 	a.questionGen.totalQuestions = numQuestions
 	a.questionGen.progress = "Preparing to generate questions..."
 	a.questionGen.errorMsg = ""
 	a.questionGen.successMsg = ""
+	a.questionGen.questions = nil
+	a.questionGen.cancel = cancel
+	a.questionGen.stream = stream
+	a.currentView = QuestionGenView
+	
+	return tea.Batch(waitForQuestion(a.questionGen.stream), a.questionGen.spinner.Tick)
+}
+
+// handleQuestionStream adds a streamed question to the list, and saves the
+// questions once the stream has finished
+func (a *App) handleQuestionStream(msg questionStreamMsg) (tea.Model, tea.Cmd) {
+	if msg.stream != a.questionGen.stream {
+		return a, nil
+	}
+	
+	if msg.event.Question != nil {
+		a.questionGen.questions = append(a.questionGen.questions, msg.event.Question)
+		a.updateGenerationProgress(len(a.questionGen.questions), a.questionGen.totalQuestions, "Receiving questions...")
+		return a, waitForQuestion(msg.stream)
+	}
+	
+	a.stopGeneration()
+	a.recordUsage(msg.event.Usage)
+	
+	if msg.event.Err != nil {
+		a.failGeneration(msg.event.Err)
+		return a, nil
+	}
+	
+	save := a.saveGeneratedQuestions
+	if a.questionGen.similarTo != nil {
+		save = a.saveSimilarQuestions
+	}
+	if err := save(a.questionGen.questions); err != nil {
+		a.failGeneration(err)
+		return a, nil
+	}
+	
+	a.completeGeneration(len(a.questionGen.questions))
+	return a, nil
+}
+
+// cancelGeneration stops the running generation, discards the questions
+// received so far and returns to the generation settings, or to the test list
+// when generating a similar test
+func (a *App) cancelGeneration() {
+	a.stopGeneration()
+	a.questionGen.status = "idle"
+	a.questionGen.questions = nil
+	
+	if a.questionGen.similarTo != nil {
+		a.questionGen.similarTo = nil
+		a.testSelection.errorMsg = "Generation cancelled, nothing was saved"
+		a.currentView = TestSelectionView
+		return
+	}
+	
+	a.pdfProcess.errorMsg = "Generation cancelled, nothing was saved"
+	a.pdfProcess.step = 1
+	a.currentView = PDFProcessView
+}
+
+// stopGeneration releases the stream of the running generation
+func (a *App) stopGeneration() {
+	if a.questionGen.cancel != nil {
+		a.questionGen.cancel()
+	}
+	a.questionGen.cancel = nil
+	a.questionGen.stream = nil
 }
 
 // updateGenerationProgress updates the generation progress
@@ -106,5 +257,14 @@ func (a *App) completeGeneration(generated int) {
 // failGeneration marks generation as failed
 func (a *App) failGeneration(err error) {
 	a.questionGen.status = "error"
+	if errors.Is(err, context.DeadlineExceeded) {
+		a.questionGen.errorMsg = fmt.Sprintf("Generation timed out after %v; raise the timeout in Settings or generate fewer questions", a.generationTimeout())
+		return
+	}
 	a.questionGen.errorMsg = fmt.Sprintf("Generation failed: %v", err)
-}
\ No newline at end of file
+}
+
+// generationTimeout returns how long a question generation may run, or 0 for no limit
+func (a *App) generationTimeout() time.Duration {
+	return time.Duration(a.settingFloat(settingGenerationTimeout) * float64(time.Minute))
+}
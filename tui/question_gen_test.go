@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+)
+
+func TestSimilarTestGeneratesInBackground(t *testing.T) {
+	a := newTestApp(t)
+	test := createTest(t, a, "Biology", shortAnswers("What is a cell?", "What is DNA?")...)
+	generator := &fakeGenerator{questions: []*chatgpt.GeneratedQuestion{
+		{Question: "What is a ribosome?", Type: "short_answer", CorrectAnswer: "A protein factory"},
+		{Question: "What is RNA?", Type: "short_answer", CorrectAnswer: "Ribonucleic acid"},
+	}}
+	a.chatGPT = generator
+
+	cmd := a.startSimilarGeneration(test)
+	if a.currentView != QuestionGenView || a.questionGen.status != "generating" {
+		t.Fatalf("view %s with status %q, want the generation view while generating", a.currentView, a.questionGen.status)
+	}
+	runCmd(t, a, cmd)
+
+	if a.questionGen.status != "completed" {
+		t.Fatalf("status = %q (%s), want completed", a.questionGen.status, a.questionGen.errorMsg)
+	}
+	if generator.numQuestions != 2 || len(generator.questionTypes) != 1 || generator.questionTypes[0] != "short_answer" {
+		t.Errorf("asked for %d questions of %v, want 2 short_answer", generator.numQuestions, generator.questionTypes)
+	}
+
+	tests, err := a.db.GetAllTests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var similar *database.Test
+	for _, candidate := range tests {
+		if candidate.Name == "Biology (similar)" {
+			similar = candidate
+		}
+	}
+	if similar == nil {
+		t.Fatalf("no similar test among %d tests", len(tests))
+	}
+	if count, _ := a.db.CountQuestions(similar.ID); count != 2 {
+		t.Errorf("similar test has %d questions, want 2", count)
+	}
+}
+
+// blockingGenerator waits until its context ends before returning
+type blockingGenerator struct {
+	fakeGenerator
+}
+
+func (b *blockingGenerator) GenerateSimilarQuestions(ctx context.Context, existing []string, numQuestions int, questionTypes []string) ([]*chatgpt.GeneratedQuestion, *chatgpt.Usage, error) {
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+func TestSimilarTestCancel(t *testing.T) {
+	a := newTestApp(t)
+	test := createTest(t, a, "Biology", shortAnswers("What is a cell?")...)
+	a.chatGPT = &blockingGenerator{}
+
+	a.startSimilarGeneration(test)
+	press(a, "c")
+
+	if a.currentView != TestSelectionView {
+		t.Errorf("view after cancel = %s, want the test list", a.currentView)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 1 {
+		t.Errorf("cancelled generation left %d tests, want 1", len(tests))
+	}
+}
+
+func TestStreamedQuestionsShownAsTheyArrive(t *testing.T) {
+	a := newTestApp(t)
+	openConfigureStep(a)
+	a.pdfProcess.testName = "Streamed"
+
+	stream := make(chan chatgpt.StreamEvent, 1)
+	cancelled := false
+	cmd := a.beginGeneration(stream, func() { cancelled = true }, 2)
+	wait := waitForQuestion(stream)
+	if cmd == nil || a.currentView != QuestionGenView {
+		t.Fatalf("generation did not start, view %s", a.currentView)
+	}
+
+	stream <- chatgpt.StreamEvent{Question: &chatgpt.GeneratedQuestion{Question: "What is a cell?", Type: "short_answer", CorrectAnswer: "A unit of life"}}
+	_, next := a.Update(wait())
+	view := a.viewQuestionGen()
+	if !strings.Contains(view, "1. [Short Answer] What is a cell?") || !strings.Contains(view, "Progress: 1/2") {
+		t.Errorf("the first question is not shown as it arrives:\n%s", view)
+	}
+	if next == nil {
+		t.Fatal("the view stopped waiting for more questions")
+	}
+
+	stream <- chatgpt.StreamEvent{Question: &chatgpt.GeneratedQuestion{Question: "What is DNA?", Type: "short_answer", CorrectAnswer: "Genetic material"}}
+	a.Update(next())
+	press(a, "c")
+	if !cancelled || a.currentView != PDFProcessView {
+		t.Fatalf("cancel: called %v, view %s", cancelled, a.currentView)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 0 {
+		t.Errorf("a cancelled generation saved %d tests", len(tests))
+	}
+
+	// The end of the cancelled stream is ignored
+	stream <- chatgpt.StreamEvent{Done: true}
+	a.Update(waitForQuestion(stream)())
+	if a.questionGen.status != "idle" {
+		t.Errorf("status after a late end of stream = %q, want idle", a.questionGen.status)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 0 {
+		t.Errorf("the cancelled stream saved %d tests on ending", len(tests))
+	}
+}
+
+func TestGenerateQuestionsWithFakeGenerator(t *testing.T) {
+	a := newTestApp(t)
+	generator := &fakeGenerator{questions: []*chatgpt.GeneratedQuestion{
+		{Question: "What is a cell?", Type: "short_answer", CorrectAnswer: "A unit of life"},
+		{Question: "Cells have a nucleus.", Type: "true_false", CorrectAnswer: "True"},
+	}}
+	a.chatGPT = generator
+	openConfigureStep(a)
+	a.pdfProcess.testName = "Cells"
+	a.pdfProcess.numQuestions = "2"
+
+	_, cmd := a.generateQuestions()
+	runCmd(t, a, cmd)
+	if a.questionGen.status != "completed" {
+		t.Fatalf("status = %q (%s), want completed", a.questionGen.status, a.questionGen.errorMsg)
+	}
+	if generator.numQuestions != 2 {
+		t.Errorf("asked for %d questions, want 2", generator.numQuestions)
+	}
+	tests, err := a.db.GetAllTests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 1 || tests[0].Name != "Cells" {
+		t.Fatalf("saved tests %v, want Cells", tests)
+	}
+	if count, _ := a.db.CountQuestions(tests[0].ID); count != 2 {
+		t.Errorf("Cells has %d questions, want 2", count)
+	}
+
+	// A failing backend reports its error and saves nothing
+	a.chatGPT = &fakeGenerator{err: errors.New("backend unavailable")}
+	openConfigureStep(a)
+	a.pdfProcess.testName = "Broken"
+	_, cmd = a.generateQuestions()
+	runCmd(t, a, cmd)
+	if a.questionGen.status != "error" || !strings.Contains(a.questionGen.errorMsg, "backend unavailable") {
+		t.Errorf("status %q with error %q, want the backend error", a.questionGen.status, a.questionGen.errorMsg)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 1 {
+		t.Errorf("%d tests saved after the failure, want 1", len(tests))
+	}
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionStats accumulates the tests completed since the application started
+type SessionStats struct {
+	TestsTaken int
+	TotalScore float64 // Sum of percentage scores, for the average
+	TimeSpent  time.Duration
+}
+
+// recordSessionTest adds a completed test attempt to the session stats
+func (a *App) recordSessionTest(score float64, timeTaken time.Duration) {
+	a.session.TestsTaken++
+	a.session.TotalScore += score
+	a.session.TimeSpent += timeTaken
+}
+
+// SessionSummary returns a short summary of the tests taken this session, or
+// an empty string if none were taken or the summary is disabled in settings
+func (a *App) SessionSummary() string {
+	if a.session.TestsTaken == 0 || !a.settingBool(settingSessionSummary) {
+		return ""
+	}
+
+	average := a.session.TotalScore / float64(a.session.TestsTaken)
+
+	s := "📚 Session summary\n"
+	s += fmt.Sprintf("  Tests taken:   %d\n", a.session.TestsTaken)
+	s += fmt.Sprintf("  Average score: %.1f%%\n", average)
+	s += fmt.Sprintf("  Time spent:    %s\n", a.formatDuration(a.session.TimeSpent))
+	return s
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionSummary(t *testing.T) {
+	a := newTestApp(t)
+	if summary := a.SessionSummary(); summary != "" {
+		t.Errorf("summary before any test = %q, want none", summary)
+	}
+
+	questions := shortAnswers("Q1", "Q2")
+	test := createTest(t, a, "Biology", questions...)
+	for _, correct := range []int{2, 1} {
+		a.startTest(test, questions)
+		a.testStartTime = time.Now().Add(-90 * time.Second)
+		for i, q := range a.currentQuestions {
+			a.userAnswers[q.ID] = "wrong"
+			if i < correct {
+				a.userAnswers[q.ID] = "answer"
+			}
+		}
+		if err := a.recordTestResults(); err != nil {
+			t.Fatalf("recordTestResults: %v", err)
+		}
+	}
+
+	summary := a.SessionSummary()
+	for _, want := range []string{"Tests taken:   2", "Average score: 75.0%", "Time spent:    3:00"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary does not show %q:\n%s", want, summary)
+		}
+	}
+
+	a.saveSetting(settingSessionSummary, "false")
+	if summary := a.SessionSummary(); summary != "" {
+		t.Errorf("summary shown with the setting off:\n%s", summary)
+	}
+}
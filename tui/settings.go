@@ -0,0 +1,310 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Setting keys
+const (
+	settingPromptPrice       = "prompt_price_per_1k"
+	settingCompletionPrice   = "completion_price_per_1k"
+	settingMinTerminalWidth  = "min_terminal_width"
+	settingMinTerminalHeight = "min_terminal_height"
+	settingColorBlindPalette = "color_blind_palette"
+	settingHideMastered      = "hide_mastered_tests"
+	settingSessionSummary    = "session_summary"
+	settingDefaultPDFDir     = "default_pdf_directory"
+	settingReviewColumns     = "review_columns"
+	settingAutosaveInterval  = "autosave_interval"
+	settingGenerationTimeout = "generation_timeout"
+	settingMinLineLength     = "min_pdf_line_length"
+	settingMaxPDFSize        = "max_pdf_size_mb"
+
+	// Remembered state, stored with the settings but not shown in the settings view
+	settingLastPDFDir = "last_pdf_directory"
+)
+
+// settingDefinition describes a user-editable setting
+type settingDefinition struct {
+	key          string
+	label        string
+	kind         string // "toggle", "number", "text"
+	defaultValue string
+}
+
+// settingDefinitions lists the settings shown in the settings view, in display order
+var settingDefinitions = []settingDefinition{
+	{key: settingPromptPrice, label: "Prompt price per 1K tokens ($)", kind: "number", defaultValue: "0.0005"},
+	{key: settingCompletionPrice, label: "Completion price per 1K tokens ($)", kind: "number", defaultValue: "0.0015"},
+	{key: settingMinTerminalWidth, label: "Minimum terminal width", kind: "number", defaultValue: "80"},
+	{key: settingMinTerminalHeight, label: "Minimum terminal height", kind: "number", defaultValue: "24"},
+	{key: settingColorBlindPalette, label: "Color-blind-safe colors (blue/orange)", kind: "toggle", defaultValue: "false"},
+	{key: settingHideMastered, label: "Hide mastered tests (last 3 attempts at 100%)", kind: "toggle", defaultValue: "false"},
+	{key: settingSessionSummary, label: "Show session summary on exit", kind: "toggle", defaultValue: "true"},
+	{key: settingDefaultPDFDir, label: "Default PDF directory", kind: "text", defaultValue: ""},
+	{key: settingReviewColumns, label: "Answer review columns on wide terminals", kind: "number", defaultValue: "2"},
+	{key: settingAutosaveInterval, label: "Autosave interval during tests (seconds, 0 = off)", kind: "number", defaultValue: "30"},
+	{key: settingGenerationTimeout, label: "Question generation timeout (minutes, 0 = none)", kind: "number", defaultValue: "5"},
+	{key: settingMinLineLength, label: "Drop extracted PDF lines shorter than (characters)", kind: "number", defaultValue: "3"},
+	{key: settingMaxPDFSize, label: "Maximum PDF size (MB, 0 = no limit)", kind: "number", defaultValue: "50"},
+}
+
+// SettingsModel represents the settings and stats view state
+type SettingsModel struct {
+	cursor     int
+	inputMode  bool
+	input      string
+	errorMsg   string
+	successMsg string
+	usage      *database.UsageTotals
+}
+
+// NewSettingsModel creates a new settings model
+func NewSettingsModel() *SettingsModel {
+	return &SettingsModel{}
+}
+
+// updateSettings handles settings view updates
+func (a *App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if a.settings.inputMode {
+			return a.handleSettingsInputMode(msg)
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if a.settings.cursor > 0 {
+				a.settings.cursor--
+			}
+		case "down", "j":
+			if a.settings.cursor < len(settingDefinitions)-1 {
+				a.settings.cursor++
+			}
+		case "enter", " ":
+			return a.editSelectedSetting()
+		case "r":
+			a.loadUsageTotals()
+		case "q":
+			a.currentView = MainMenuView
+		}
+	}
+	return a, nil
+}
+
+// viewSettings renders the settings and stats view
+func (a *App) viewSettings() string {
+	s := a.renderHeader("Settings & Stats")
+
+	if a.settings.errorMsg != "" {
+		s += a.renderError(a.settings.errorMsg)
+		a.settings.errorMsg = ""
+	}
+
+	if a.settings.successMsg != "" {
+		s += a.renderSuccess(a.settings.successMsg)
+		a.settings.successMsg = ""
+	}
+
+	if a.settings.inputMode {
+		def := settingDefinitions[a.settings.cursor]
+		s += fmt.Sprintf("Enter %s:\n", strings.ToLower(def.label))
+		s += "> " + a.settings.input + "\n\n"
+		s += "Press Enter to confirm, Esc to cancel\n"
+		return s + a.renderFooter()
+	}
+
+	s += a.viewUsageStats() + "\n"
+
+	s += "Settings:\n\n"
+	for i, def := range settingDefinitions {
+		value := a.settingValue(def.key)
+		if def.kind == "toggle" {
+			value = "Off"
+			if a.settingBool(def.key) {
+				value = "On"
+			}
+		}
+		if def.kind == "text" && value == "" {
+			value = "Not set"
+		}
+
+		line := fmt.Sprintf("%s: %s", def.label, value)
+		if a.settings.cursor == i {
+			s += fmt.Sprintf("> %s\n", selectedStyle.Render(line))
+		} else {
+			s += fmt.Sprintf("  %s\n", line)
+		}
+	}
+
+	s += "\nPress Enter to change the selected setting, 'r' to refresh stats\n"
+
+	return s + a.renderFooter()
+}
+
+// viewUsageStats renders the accumulated API usage and estimated cost
+func (a *App) viewUsageStats() string {
+	if a.settings.usage == nil {
+		a.loadUsageTotals()
+	}
+
+	usage := a.settings.usage
+	if usage == nil {
+		return "API usage unavailable\n"
+	}
+
+	s := "API Usage:\n\n"
+	s += fmt.Sprintf("  Model:             %s\n", a.chatGPT.Model())
+	s += fmt.Sprintf("  Generations:       %d\n", usage.Generations)
+	s += fmt.Sprintf("  Prompt tokens:     %d\n", usage.PromptTokens)
+	s += fmt.Sprintf("  Completion tokens: %d\n", usage.CompletionTokens)
+	s += fmt.Sprintf("  Total tokens:      %d\n", usage.TotalTokens)
+	s += fmt.Sprintf("  Estimated cost:    $%.4f\n", a.estimateUsageCost(usage))
+	return s
+}
+
+// estimateUsageCost estimates the API spend for the given usage from the configured prices
+func (a *App) estimateUsageCost(usage *database.UsageTotals) float64 {
+	return a.estimateCost(usage.PromptTokens, usage.CompletionTokens)
+}
+
+// estimateCost prices a number of prompt and completion tokens at the configured rates
+func (a *App) estimateCost(promptTokens, completionTokens int) float64 {
+	promptPrice := a.settingFloat(settingPromptPrice)
+	completionPrice := a.settingFloat(settingCompletionPrice)
+	return float64(promptTokens)/1000*promptPrice + float64(completionTokens)/1000*completionPrice
+}
+
+// editSelectedSetting toggles or starts editing the highlighted setting
+func (a *App) editSelectedSetting() (tea.Model, tea.Cmd) {
+	def := settingDefinitions[a.settings.cursor]
+
+	if def.kind == "toggle" {
+		value := "true"
+		if a.settingBool(def.key) {
+			value = "false"
+		}
+		a.saveSetting(def.key, value)
+		return a, nil
+	}
+
+	a.settings.inputMode = true
+	a.settings.input = a.settingValue(def.key)
+	return a, nil
+}
+
+// handleSettingsInputMode handles input mode for editing a setting
+func (a *App) handleSettingsInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		def := settingDefinitions[a.settings.cursor]
+		value := strings.TrimSpace(a.settings.input)
+		if def.kind == "number" {
+			if num, err := strconv.ParseFloat(value, 64); err != nil || num < 0 {
+				a.settings.errorMsg = "Please enter a valid non-negative number"
+				a.settings.inputMode = false
+				a.settings.input = ""
+				return a, nil
+			}
+		}
+		if def.key == settingDefaultPDFDir && value != "" {
+			if info, err := os.Stat(expandHome(value)); err != nil || !info.IsDir() {
+				a.settings.errorMsg = "Directory does not exist"
+				a.settings.inputMode = false
+				a.settings.input = ""
+				return a, nil
+			}
+		}
+		a.saveSetting(def.key, value)
+		a.settings.inputMode = false
+		a.settings.input = ""
+	case "esc":
+		a.settings.inputMode = false
+		a.settings.input = ""
+	case "backspace":
+		if len(a.settings.input) > 0 {
+			a.settings.input = a.settings.input[:len(a.settings.input)-1]
+		}
+	default:
+		if len(msg.String()) == 1 {
+			a.settings.input += msg.String()
+		}
+	}
+	return a, nil
+}
+
+// loadUsageTotals loads accumulated API usage from database
+func (a *App) loadUsageTotals() {
+	usage, err := a.db.GetUsageTotals()
+	if err != nil {
+		a.settings.errorMsg = fmt.Sprintf("Failed to load usage: %v", err)
+		return
+	}
+	a.settings.usage = usage
+}
+
+// loadSettings loads stored settings from database
+func (a *App) loadSettings() error {
+	settings, err := a.db.GetSettings()
+	if err != nil {
+		return err
+	}
+	a.settingValues = settings
+	return nil
+}
+
+// saveSetting persists a setting and updates the in-memory copy
+func (a *App) saveSetting(key, value string) {
+	if err := a.db.SetSetting(key, value); err != nil {
+		a.settings.errorMsg = err.Error()
+		return
+	}
+	a.settingValues[key] = value
+	a.applySettings()
+}
+
+// applySettings applies settings that affect shared state such as styles
+func (a *App) applySettings() {
+	a.applyPalette()
+	a.pdfProcessor.MinLineLength = a.settingInt(settingMinLineLength)
+	a.pdfProcessor.MaxFileSize = int64(a.settingFloat(settingMaxPDFSize) * (1 << 20))
+}
+
+// settingValue returns the stored value for a setting, or its default
+func (a *App) settingValue(key string) string {
+	if value, ok := a.settingValues[key]; ok {
+		return value
+	}
+	for _, def := range settingDefinitions {
+		if def.key == key {
+			return def.defaultValue
+		}
+	}
+	return ""
+}
+
+// settingBool returns a toggle setting's value
+func (a *App) settingBool(key string) bool {
+	return a.settingValue(key) == "true"
+}
+
+// settingFloat returns a numeric setting's value, or 0 if it cannot be parsed
+func (a *App) settingFloat(key string) float64 {
+	value, err := strconv.ParseFloat(a.settingValue(key), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// settingInt returns a numeric setting's value truncated to an integer
+func (a *App) settingInt(key string) int {
+	return int(a.settingFloat(key))
+}
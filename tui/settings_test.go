@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"pdf-test-generator/chatgpt"
+)
+
+func TestUsageStats(t *testing.T) {
+	a := newTestApp(t)
+	a.saveSetting(settingPromptPrice, "1")
+	a.saveSetting(settingCompletionPrice, "2")
+
+	// Backends that report no usage record nothing
+	a.recordUsage(nil)
+	a.recordUsage(&chatgpt.Usage{Model: "gpt-test", PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	a.recordUsage(&chatgpt.Usage{Model: "gpt-test", PromptTokens: 2000, CompletionTokens: 0, TotalTokens: 2000})
+	if a.pdfProcess.errorMsg != "" {
+		t.Fatalf("recording usage: %s", a.pdfProcess.errorMsg)
+	}
+
+	view := a.viewUsageStats()
+	for _, want := range []string{
+		"Generations:       2",
+		"Prompt tokens:     3000",
+		"Completion tokens: 500",
+		"Total tokens:      3500",
+		"Estimated cost:    $4.0000",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("usage stats do not show %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestColorBlindPalette(t *testing.T) {
+	a := newTestApp(t)
+	t.Cleanup(func() { a.saveSetting(settingColorBlindPalette, "false") })
+	colors := func() (string, string) {
+		return fmt.Sprint(successStyle.GetForeground()), fmt.Sprint(errorStyle.GetForeground())
+	}
+
+	if success, failure := colors(); success != defaultPalette.success || failure != defaultPalette.failure {
+		t.Fatalf("default colors %s/%s, want %s/%s", success, failure, defaultPalette.success, defaultPalette.failure)
+	}
+	a.saveSetting(settingColorBlindPalette, "true")
+	if success, failure := colors(); success != colorBlindPalette.success || failure != colorBlindPalette.failure {
+		t.Errorf("color-blind colors %s/%s, want %s/%s", success, failure, colorBlindPalette.success, colorBlindPalette.failure)
+	}
+
+	// Right and wrong answers are marked by symbol as well as by color
+	finishTest(t, a, "Biology", 2, 1)
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	if detail := a.viewResultsDetail(); !strings.Contains(detail, "✓ Q1") || !strings.Contains(detail, "✗ Q2") {
+		t.Errorf("answers are not marked with symbols:\n%s", detail)
+	}
+
+	a.saveSetting(settingColorBlindPalette, "false")
+	if success, failure := colors(); success != defaultPalette.success || failure != defaultPalette.failure {
+		t.Errorf("colors after turning the palette off %s/%s, want %s/%s", success, failure, defaultPalette.success, defaultPalette.failure)
+	}
+}
+
+func TestEscCancelsSettingEdit(t *testing.T) {
+	a := newTestApp(t)
+	a.currentView = SettingsView
+	for i, def := range settingDefinitions {
+		if def.key == settingPromptPrice {
+			a.settings.cursor = i
+		}
+	}
+	before := a.settingValue(settingPromptPrice)
+
+	press(a, "enter")
+	if !a.settings.inputMode {
+		t.Fatal("enter did not start editing the setting")
+	}
+	typeText(a, "9")
+	press(a, "esc")
+	if a.currentView != SettingsView || a.settings.inputMode {
+		t.Errorf("esc left view %s with input mode %v, want the settings view", a.currentView, a.settings.inputMode)
+	}
+	if got := a.settingValue(settingPromptPrice); got != before {
+		t.Errorf("setting = %q after cancelling, want %q", got, before)
+	}
+
+	// Out of input mode, esc goes back to the menu as usual
+	press(a, "esc")
+	if a.currentView != MainMenuView {
+		t.Errorf("esc from settings moved to view %s", a.currentView)
+	}
+}
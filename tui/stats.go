@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StatsModel represents the study statistics dashboard state
+type StatsModel struct {
+	stats    *database.Stats
+	errorMsg string
+}
+
+// NewStatsModel creates a new statistics model
+func NewStatsModel() *StatsModel {
+	return &StatsModel{}
+}
+
+// openStats loads the latest statistics and shows the dashboard
+func (a *App) openStats() {
+	a.currentView = StatsView
+	a.loadStats()
+}
+
+// loadStats refreshes the statistics from the saved results
+func (a *App) loadStats() {
+	stats, err := a.db.GetStats()
+	if err != nil {
+		a.stats.errorMsg = err.Error()
+		a.stats.stats = nil
+		return
+	}
+	a.stats.stats = &stats
+}
+
+// updateStats handles statistics dashboard updates
+func (a *App) updateStats(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			a.loadStats()
+		case "q":
+			a.currentView = MainMenuView
+		}
+	}
+	return a, nil
+}
+
+// viewStats renders the statistics dashboard
+func (a *App) viewStats() string {
+	s := a.renderHeader("Study Statistics")
+
+	if a.stats.errorMsg != "" {
+		s += a.renderError(a.stats.errorMsg)
+		a.stats.errorMsg = ""
+	}
+
+	stats := a.stats.stats
+	if stats == nil {
+		s += "Statistics unavailable\n"
+		return s + a.renderFooter()
+	}
+
+	if stats.TestsTaken == 0 {
+		s += "No test results yet. Take a practice test to start tracking your progress.\n"
+		return s + a.renderFooter()
+	}
+
+	s += "Overall:\n\n"
+	s += fmt.Sprintf("  Tests taken:   %d\n", stats.TestsTaken)
+	s += fmt.Sprintf("  Average score: %.1f%%\n", stats.AverageScore)
+	s += fmt.Sprintf("  Time spent:    %s\n", a.formatDuration(time.Duration(stats.TotalTime)*time.Second))
+	if stats.Best != nil {
+		s += "  Best test:     " + successStyle.Render(fmt.Sprintf("%s (%.1f%%)", stats.Best.TestName, stats.Best.AverageScore)) + "\n"
+		s += "  Worst test:    " + errorStyle.Render(fmt.Sprintf("%s (%.1f%%)", stats.Worst.TestName, stats.Worst.AverageScore)) + "\n"
+	}
+
+	if len(stats.PerTest) > 0 {
+		s += "\nBy test:\n\n"
+		s += fmt.Sprintf("  %-32s %8s %8s %8s\n", "Test", "Attempts", "Average", "Best")
+		for _, test := range stats.PerTest {
+			name := test.TestName
+			if runes := []rune(name); len(runes) > 32 {
+				name = string(runes[:29]) + "..."
+			}
+			s += fmt.Sprintf("  %-32s %8d %7.1f%% %7.1f%%\n", name, test.Attempts, test.AverageScore, test.BestScore)
+		}
+	}
+
+	s += "\nPress 'r' to refresh, 'q' to return to main menu\n"
+
+	return s + a.renderFooter()
+}
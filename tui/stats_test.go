@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatsDashboard(t *testing.T) {
+	a := newTestApp(t)
+	a.openStats()
+	if view := a.viewStats(); !strings.Contains(view, "No test results yet") {
+		t.Errorf("empty dashboard does not say there are no results:\n%s", view)
+	}
+
+	finishTest(t, a, "Biology", 4, 4)
+	finishTest(t, a, "Chemistry", 4, 1)
+
+	// The dashboard shows what was loaded until refreshed
+	a.currentView = StatsView
+	if view := a.viewStats(); !strings.Contains(view, "No test results yet") {
+		t.Errorf("dashboard changed before refreshing:\n%s", view)
+	}
+	press(a, "r")
+	view := a.viewStats()
+	for _, want := range []string{
+		"Tests taken:   2",
+		"Average score: 62.5%",
+		"Best test:     Biology (100.0%)",
+		"Worst test:    Chemistry (25.0%)",
+		"Biology                                 1   100.0%   100.0%",
+		"Chemistry                               1    25.0%    25.0%",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("dashboard does not show %q:\n%s", want, view)
+		}
+	}
+
+	press(a, "q")
+	if a.currentView != MainMenuView {
+		t.Errorf("'q' moved to view %s, want the main menu", a.currentView)
+	}
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadStudyList loads the IDs of starred questions from database
+func (a *App) loadStudyList() error {
+	ids, err := a.db.GetStudyListIDs()
+	if err != nil {
+		return err
+	}
+	a.studyList = ids
+	return nil
+}
+
+// toggleStar adds a question to the study list, or removes it if already starred
+func (a *App) toggleStar(questionID int) error {
+	if a.studyList[questionID] {
+		if err := a.db.RemoveFromStudyList(questionID); err != nil {
+			return err
+		}
+		delete(a.studyList, questionID)
+		return nil
+	}
+
+	if err := a.db.AddToStudyList(questionID); err != nil {
+		return err
+	}
+	a.studyList[questionID] = true
+	return nil
+}
+
+// startStudyList starts a practice quiz over all starred questions
+func (a *App) startStudyList() (tea.Model, tea.Cmd) {
+	questions, err := a.db.GetStudyListQuestions()
+	if err != nil {
+		a.mainMenu.errorMsg = fmt.Sprintf("Failed to load study list: %v", err)
+		return a, nil
+	}
+
+	if len(questions) == 0 {
+		a.mainMenu.errorMsg = "No starred questions yet. Press 's' while reviewing answers to star them."
+		return a, nil
+	}
+
+	return a, a.startTest(&database.Test{Name: "Starred Questions"}, questions)
+}
+
+// startDueReview starts a practice quiz over the questions of every test that
+// are due for review today
+func (a *App) startDueReview() (tea.Model, tea.Cmd) {
+	questions, err := a.db.GetDueQuestions()
+	if err != nil {
+		a.mainMenu.errorMsg = fmt.Sprintf("Failed to load questions due for review: %v", err)
+		return a, nil
+	}
+
+	if len(questions) == 0 {
+		a.mainMenu.errorMsg = "No questions are due for review today. Answered questions come back here on their review date."
+		return a, nil
+	}
+
+	return a, a.startTest(&database.Test{Name: "Due for Review"}, questions)
+}
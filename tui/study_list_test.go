@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStarInReview(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2")
+	test := createTest(t, a, "Biology", questions...)
+	a.startTest(test, questions)
+	a.testTaking.showResult = true
+	a.testTaking.reviewMode = true
+
+	press(a, "s")
+	if !a.studyList[questions[0].ID] {
+		t.Fatal("'s' in review did not star the question")
+	}
+	if !strings.Contains(a.viewTestTaking(), "★") {
+		t.Errorf("starred question not marked in review:\n%s", a.viewTestTaking())
+	}
+
+	// Stars persist across sessions
+	if err := a.loadStudyList(); err != nil {
+		t.Fatalf("loadStudyList: %v", err)
+	}
+	if !a.studyList[questions[0].ID] {
+		t.Error("star not saved to the database")
+	}
+
+	press(a, "s")
+	if a.studyList[questions[0].ID] {
+		t.Error("'s' again did not unstar the question")
+	}
+	if err := a.loadStudyList(); err != nil {
+		t.Fatalf("loadStudyList: %v", err)
+	}
+	if a.studyList[questions[0].ID] {
+		t.Error("unstar not saved to the database")
+	}
+}
+
+func TestStarInResultDetails(t *testing.T) {
+	a := newTestApp(t)
+	finishTest(t, a, "Biology", 3, 3)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	press(a, "enter")
+	press(a, "down")
+	press(a, "s")
+
+	// Correct answers can be starred too
+	answer := a.testResults.selectedResult.Answers[1]
+	if !answer.IsCorrect || !a.studyList[answer.QuestionID] {
+		t.Fatalf("'s' in details did not star the second answer (correct %v)", answer.IsCorrect)
+	}
+	if detail := a.viewResultsDetail(); !strings.Contains(detail, "2. ✓ ★ ") {
+		t.Errorf("starred answer not marked in details:\n%s", detail)
+	}
+}
+
+func TestStudyListQuiz(t *testing.T) {
+	a := newTestApp(t)
+
+	a.startStudyList()
+	if a.currentView == TestTakingView || !strings.Contains(a.mainMenu.errorMsg, "No starred questions") {
+		t.Fatalf("empty study list started a quiz (error %q)", a.mainMenu.errorMsg)
+	}
+
+	biology := shortAnswers("B1", "B2")
+	createTest(t, a, "Biology", biology...)
+	chemistry := shortAnswers("C1")
+	createTest(t, a, "Chemistry", chemistry...)
+	for _, q := range []int{biology[1].ID, chemistry[0].ID} {
+		if err := a.toggleStar(q); err != nil {
+			t.Fatalf("toggleStar: %v", err)
+		}
+	}
+
+	a.mainMenu.errorMsg = ""
+	a.startStudyList()
+	if a.currentView != TestTakingView {
+		t.Fatalf("study list quiz not started, view %v", a.currentView)
+	}
+	if a.currentTest.Name != "Starred Questions" {
+		t.Errorf("quiz named %q, want Starred Questions", a.currentTest.Name)
+	}
+	var texts []string
+	for _, q := range a.currentQuestions {
+		texts = append(texts, q.QuestionText)
+	}
+	if strings.Join(texts, ",") != "B2,C1" {
+		t.Errorf("quiz questions %v, want the starred B2 and C1", texts)
+	}
+}
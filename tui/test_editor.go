@@ -0,0 +1,344 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EditTestModel represents the state of the view for managing a test's questions
+type EditTestModel struct {
+	test       *database.Test
+	questions  []*database.Question // Every question of the test
+	matches    []int                // Indexes into questions matching the search, in order
+	cursor     int                  // Index into matches
+	errorMsg   string
+	successMsg string
+
+	// Search-as-you-type filter over question text
+	searchMode bool
+	search     string
+
+	// Editing the selected question's text
+	inputMode bool
+	input     string
+
+	confirmDelete bool
+}
+
+// NewEditTestModel creates a new edit test model
+func NewEditTestModel() *EditTestModel {
+	return &EditTestModel{}
+}
+
+// startEditTest opens the edit view for the given test
+func (a *App) startEditTest(test *database.Test) (tea.Model, tea.Cmd) {
+	a.editTest = NewEditTestModel()
+	a.editTest.test = test
+	a.loadEditTestQuestions()
+	a.currentView = EditTestView
+	return a, nil
+}
+
+// updateEditTest handles edit test view updates
+func (a *App) updateEditTest(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if a.editTest.inputMode {
+			return a.handleEditQuestionInput(msg)
+		}
+
+		if a.editTest.searchMode {
+			return a.handleEditTestSearch(msg)
+		}
+
+		if a.editTest.confirmDelete {
+			switch msg.String() {
+			case "y":
+				a.editTest.confirmDelete = false
+				return a.deleteSelectedQuestion()
+			case "n":
+				a.editTest.confirmDelete = false
+			}
+			return a, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if a.editTest.cursor > 0 {
+				a.editTest.cursor--
+			}
+		case "down", "j":
+			if a.editTest.cursor < len(a.editTest.matches)-1 {
+				a.editTest.cursor++
+			}
+		case "/":
+			a.editTest.searchMode = true
+		case "esc":
+			// Only reached while a search filter is active, see escHandledByView
+			a.clearEditTestSearch()
+		case "e":
+			if q := a.selectedEditQuestion(); q != nil {
+				a.editTest.inputMode = true
+				a.editTest.input = q.QuestionText
+			}
+		case "d":
+			if a.selectedEditQuestion() != nil {
+				a.editTest.confirmDelete = true
+			}
+		case "K", "shift+up":
+			a.moveEditQuestion(-1)
+		case "J", "shift+down":
+			a.moveEditQuestion(1)
+		case "b":
+			a.currentView = TestSelectionView
+		}
+	}
+	return a, nil
+}
+
+// viewEditTest renders the edit test view
+func (a *App) viewEditTest() string {
+	s := a.renderHeader(fmt.Sprintf("Edit Test: %s", a.editTest.test.Name))
+
+	if a.editTest.errorMsg != "" {
+		s += a.renderError(a.editTest.errorMsg)
+		a.editTest.errorMsg = ""
+	}
+
+	if a.editTest.successMsg != "" {
+		s += a.renderSuccess(a.editTest.successMsg)
+		a.editTest.successMsg = ""
+	}
+
+	if a.editTest.inputMode {
+		s += "Enter question text:\n"
+		s += "> " + a.editTest.input + "\n\n"
+		s += "Press Enter to confirm, Esc to cancel\n"
+		return s + a.renderFooter()
+	}
+
+	if a.editTest.confirmDelete {
+		q := a.selectedEditQuestion()
+		s += fmt.Sprintf("Delete this question?\n\n  %s\n\n", q.QuestionText)
+		s += "Its recorded answers are deleted too.\n\n"
+		s += "Press 'y' to delete, 'n' to cancel\n"
+		return s + a.renderFooter()
+	}
+
+	if a.editTest.searchMode || a.editTest.search != "" {
+		s += fmt.Sprintf("Search: %s", a.editTest.search)
+		if a.editTest.searchMode {
+			s += "█"
+		}
+		s += fmt.Sprintf("  (%d of %d questions)\n\n", len(a.editTest.matches), len(a.editTest.questions))
+	} else {
+		s += fmt.Sprintf("%d questions\n\n", len(a.editTest.questions))
+	}
+
+	if len(a.editTest.questions) == 0 {
+		s += "This test has no questions.\n\n"
+	} else if len(a.editTest.matches) == 0 {
+		s += "No questions match the search.\n\n"
+	}
+
+	for i, index := range a.editTest.matches {
+		q := a.editTest.questions[index]
+		line := fmt.Sprintf("%d. [%s] %s", index+1, a.getQuestionTypeDisplay(q.QuestionType), q.QuestionText)
+		if a.editTest.cursor == i {
+			s += fmt.Sprintf("> %s\n", selectedStyle.Render(line))
+		} else {
+			s += fmt.Sprintf("  %s\n", line)
+		}
+	}
+
+	if a.editTest.searchMode {
+		s += "\nType to search • Enter to keep the filter • Esc to clear it\n"
+	} else {
+		s += "\nPress '/' to search, 'e' to edit the question text, 'd' to delete, 'K'/'J' to move up/down, 'b' to go back\n"
+	}
+
+	return s + a.renderFooter()
+}
+
+// moveEditQuestion moves the selected question up (-1) or down (1) in the
+// test's question order and saves the new order. Questions are only moved
+// while the whole list is shown, so the move is never across hidden questions.
+func (a *App) moveEditQuestion(delta int) {
+	if a.editTest.search != "" {
+		a.editTest.errorMsg = "Clear the search to reorder questions"
+		return
+	}
+	
+	questions := a.editTest.questions
+	from := a.editTest.cursor
+	to := from + delta
+	if len(questions) == 0 || to < 0 || to >= len(questions) {
+		return
+	}
+	
+	questions[from], questions[to] = questions[to], questions[from]
+	ids := make([]int, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	if err := a.db.ReorderQuestions(a.editTest.test.ID, ids); err != nil {
+		a.editTest.errorMsg = err.Error()
+		a.loadEditTestQuestions()
+		return
+	}
+	a.editTest.cursor = to
+}
+
+// handleEditTestSearch handles typing in the search box, refiltering on every key
+func (a *App) handleEditTestSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		a.editTest.searchMode = false
+	case "esc":
+		a.clearEditTestSearch()
+	case "backspace":
+		if len(a.editTest.search) > 0 {
+			a.editTest.search = a.editTest.search[:len(a.editTest.search)-1]
+			a.applyEditTestSearch()
+		}
+	default:
+		if len(msg.String()) == 1 {
+			a.editTest.search += msg.String()
+			a.applyEditTestSearch()
+		}
+	}
+	return a, nil
+}
+
+// handleEditQuestionInput handles input mode for editing a question's text
+func (a *App) handleEditQuestionInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		// Confirm edit
+		if err := a.validateInput(a.editTest.input, 5); err != nil {
+			a.editTest.errorMsg = err.Error()
+		} else {
+			q := a.selectedEditQuestion()
+			_, err := a.db.UpdateQuestion(q.ID, strings.TrimSpace(a.editTest.input), q.QuestionType, q.CorrectAnswer, q.Explanation, q.Options, q.Difficulty)
+			if err != nil {
+				a.editTest.errorMsg = fmt.Sprintf("Failed to update question: %v", err)
+			} else {
+				a.editTest.successMsg = "Question updated"
+				a.loadEditTestQuestions()
+			}
+		}
+		a.editTest.inputMode = false
+		a.editTest.input = ""
+	case "esc":
+		// Cancel edit
+		a.editTest.inputMode = false
+		a.editTest.input = ""
+	case "backspace":
+		// Remove last character
+		if len(a.editTest.input) > 0 {
+			a.editTest.input = a.editTest.input[:len(a.editTest.input)-1]
+		}
+	default:
+		// Add character to input
+		if len(msg.String()) == 1 {
+			a.editTest.input += msg.String()
+		}
+	}
+	return a, nil
+}
+
+// deleteSelectedQuestion deletes the highlighted question from the test
+func (a *App) deleteSelectedQuestion() (tea.Model, tea.Cmd) {
+	q := a.selectedEditQuestion()
+	if q == nil {
+		return a, nil
+	}
+
+	if err := a.db.DeleteQuestion(q.ID); err != nil {
+		a.editTest.errorMsg = fmt.Sprintf("Failed to delete question: %v", err)
+		return a, nil
+	}
+
+	delete(a.studyList, q.ID)
+	a.editTest.successMsg = "Question deleted"
+	a.loadEditTestQuestions()
+	a.testSelection.questionCounts[q.TestID] = len(a.editTest.questions)
+	return a, nil
+}
+
+// selectedEditQuestion returns the highlighted question, or nil if none is listed
+func (a *App) selectedEditQuestion() *database.Question {
+	if a.editTest.cursor < 0 || a.editTest.cursor >= len(a.editTest.matches) {
+		return nil
+	}
+	return a.editTest.questions[a.editTest.matches[a.editTest.cursor]]
+}
+
+// loadEditTestQuestions reloads the test's questions, keeping the cursor on
+// the same question by ID where it still exists
+func (a *App) loadEditTestQuestions() {
+	selectedID := 0
+	if q := a.selectedEditQuestion(); q != nil {
+		selectedID = q.ID
+	}
+
+	questions, err := a.db.GetQuestionsByTestID(a.editTest.test.ID)
+	if err != nil {
+		a.editTest.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return
+	}
+	a.editTest.questions = questions
+	a.filterEditTestQuestions()
+
+	for i, index := range a.editTest.matches {
+		if a.editTest.questions[index].ID == selectedID {
+			a.editTest.cursor = i
+			return
+		}
+	}
+	if a.editTest.cursor >= len(a.editTest.matches) {
+		a.editTest.cursor = len(a.editTest.matches) - 1
+	}
+	if a.editTest.cursor < 0 {
+		a.editTest.cursor = 0
+	}
+}
+
+// applyEditTestSearch refilters the questions after the search changed and
+// jumps to the first match
+func (a *App) applyEditTestSearch() {
+	a.filterEditTestQuestions()
+	a.editTest.cursor = 0
+}
+
+// clearEditTestSearch removes the search filter, keeping the selected question highlighted
+func (a *App) clearEditTestSearch() {
+	selected := a.selectedEditQuestion()
+
+	a.editTest.searchMode = false
+	a.editTest.search = ""
+	a.filterEditTestQuestions()
+
+	a.editTest.cursor = 0
+	for i, index := range a.editTest.matches {
+		if selected != nil && a.editTest.questions[index].ID == selected.ID {
+			a.editTest.cursor = i
+		}
+	}
+}
+
+// filterEditTestQuestions rebuilds the list of questions whose text contains the search
+func (a *App) filterEditTestQuestions() {
+	search := strings.ToLower(strings.TrimSpace(a.editTest.search))
+
+	a.editTest.matches = []int{}
+	for i, q := range a.editTest.questions {
+		if search == "" || strings.Contains(strings.ToLower(q.QuestionText), search) {
+			a.editTest.matches = append(a.editTest.matches, i)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+// editQuestionTexts lists the texts of the questions shown in the edit view
+func editQuestionTexts(a *App) string {
+	var texts []string
+	for _, index := range a.editTest.matches {
+		texts = append(texts, a.editTest.questions[index].QuestionText)
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestEditTestSearch(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Cell wall", "Mitosis", "Cell membrane", "Osmosis")
+	test := createTest(t, a, "Biology", questions...)
+	openTestList(t, a, "view_tests")
+	press(a, "m")
+	if a.currentView != EditTestView {
+		t.Fatalf("'m' opened %v", a.currentView)
+	}
+
+	press(a, "/")
+	typeText(a, "CELL")
+	if got := editQuestionTexts(a); got != "Cell wall,Cell membrane" {
+		t.Fatalf("matches for CELL = %s", got)
+	}
+	press(a, "backspace")
+	press(a, "backspace")
+	typeText(a, "ll m")
+	press(a, "enter")
+	if got := editQuestionTexts(a); got != "Cell membrane" || a.editTest.searchMode {
+		t.Fatalf("matches for cell m = %s, search mode %v", got, a.editTest.searchMode)
+	}
+
+	// Reordering is refused while filtered, deleting acts on the shown question
+	press(a, "J")
+	if a.editTest.errorMsg == "" {
+		t.Error("a question was moved while the list was filtered")
+	}
+	a.editTest.errorMsg = ""
+	press(a, "d")
+	press(a, "y")
+	if a.editTest.errorMsg != "" {
+		t.Fatalf("deleting: %s", a.editTest.errorMsg)
+	}
+	if q, _ := a.db.GetQuestion(questions[2].ID); q != nil {
+		t.Error("the filtered question was not deleted")
+	}
+	if got := editQuestionTexts(a); got != "" {
+		t.Errorf("matches after deleting = %s", got)
+	}
+	if n := a.testSelection.questionCounts[test.ID]; n != 3 {
+		t.Errorf("test list shows %d questions after deleting, want 3", n)
+	}
+
+	// Esc clears the filter and keeps the highlighted question selected
+	press(a, "esc")
+	press(a, "/")
+	typeText(a, "osis")
+	press(a, "enter")
+	press(a, "down")
+	if q := a.selectedEditQuestion(); q == nil || q.QuestionText != "Osmosis" {
+		t.Fatalf("selected %+v, want Osmosis", q)
+	}
+	press(a, "esc")
+	if a.editTest.search != "" || a.currentView != EditTestView {
+		t.Fatalf("esc left search %q in view %v", a.editTest.search, a.currentView)
+	}
+	if got := editQuestionTexts(a); got != "Cell wall,Mitosis,Osmosis" {
+		t.Errorf("questions after clearing the search = %s", got)
+	}
+	if q := a.selectedEditQuestion(); q == nil || q.QuestionText != "Osmosis" {
+		t.Errorf("selected %+v after clearing the search, want Osmosis", q)
+	}
+
+	// With the whole list shown, moving saves the new order
+	press(a, "K")
+	saved, err := a.db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 3 || saved[1].QuestionText != "Osmosis" || saved[2].QuestionText != "Mitosis" {
+		t.Errorf("saved order after moving Osmosis up = %s", editQuestionTexts(a))
+	}
+}
@@ -2,11 +2,18 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// minAnswerColumnWidth is the narrowest a column of answers may be in the detail view
+const minAnswerColumnWidth = 60
+
 // TestResultsModel represents the test results view state
 type TestResultsModel struct {
 	cursor      int
@@ -15,27 +22,44 @@ type TestResultsModel struct {
 	viewMode    string // "list", "detail"
 	errorMsg    string
 	successMsg  string
+	
+	// Scrolling through answers in detail mode
+	detailCursor int
+	detailOffset int
+	
+	// Editing the selected result's note
+	inputMode bool
+	input     string
+	
+	// Waiting for y/n before deleting the selected result
+	confirmDelete bool
 }
 
 // TestResultData represents a test result with details
 type TestResultData struct {
 	ID          int
+	TestID      int
 	TestName    string
-	Score       int
+	CorrectAnswers int
 	TotalQuestions int
-	Percentage  float64
+	Percentage  float64 // The stored score, already a percentage (0-100); never recomputed
 	TimeTaken   time.Duration
 	CompletedAt time.Time
+	Note        string
 	Answers     []AnswerData
+	History     []float64 // Scores of every attempt at the test, oldest first
 }
 
 // AnswerData represents an individual answer
 type AnswerData struct {
+	QuestionID    int
 	QuestionText  string
 	UserAnswer    string
 	CorrectAnswer string
 	IsCorrect     bool
 	Explanation   string
+	SourceRef     string
+	TimeSpent     time.Duration
 }
 
 // NewTestResultsModel creates a new test results model
@@ -49,10 +73,17 @@ func NewTestResultsModel() *TestResultsModel {
 func (a *App) updateTestResults(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if a.testResults.confirmDelete {
+			return a.handleResultDeleteConfirm(msg)
+		}
+		
 		switch a.testResults.viewMode {
 		case "list":
 			return a.handleResultsListInput(msg)
 		case "detail":
+			if a.testResults.inputMode {
+				return a.handleResultNoteInput(msg)
+			}
 			return a.handleResultsDetailInput(msg)
 		}
 	}
@@ -73,6 +104,14 @@ func (a *App) viewTestResults() string {
 		a.testResults.successMsg = ""
 	}
 	
+	if a.testResults.confirmDelete {
+		if result := a.targetResult(); result != nil {
+			s += fmt.Sprintf("Delete the result of '%s' from %s? (y/n)\n\n", result.TestName, result.CompletedAt.Format("Jan 2, 2006 3:04 PM"))
+			s += "Its recorded answers are deleted too. This cannot be undone.\n"
+			return s + a.renderFooter()
+		}
+	}
+	
 	switch a.testResults.viewMode {
 	case "list":
 		return s + a.viewResultsList() + a.renderFooter()
@@ -99,29 +138,39 @@ func (a *App) viewResultsList() string {
 	
 	s := fmt.Sprintf("Found %d test result(s):\n\n", len(a.testResults.results))
 	
-	// Display results
-	for i, result := range a.testResults.results {
+	// Display the page of results holding the cursor
+	page, pages, start, end := listPage(a.testResults.cursor, len(a.testResults.results), a.resultsPerPage())
+	for i := start; i < end; i++ {
+		result := a.testResults.results[i]
 		cursor := " "
 		if i == a.testResults.cursor {
 			cursor = ">"
 		}
 		
-		percentage := float64(result.Score) / float64(result.TotalQuestions) * 100
+		percentage := result.Percentage
 		grade := a.getGrade(percentage)
 		
 		s += fmt.Sprintf("%s %s\n", cursor, result.TestName)
 		s += fmt.Sprintf("   Score: %d/%d (%.1f%%) - %s\n", 
-			result.Score, result.TotalQuestions, percentage, grade)
+			result.CorrectAnswers, result.TotalQuestions, percentage, grade)
 		s += fmt.Sprintf("   Completed: %s\n", 
 			result.CompletedAt.Format("Jan 2, 2006 3:04 PM"))
 		if result.TimeTaken > 0 {
 			s += fmt.Sprintf("   Time: %s\n", a.formatDuration(result.TimeTaken))
 		}
+		if result.Note != "" {
+			s += fmt.Sprintf("   Note: %s\n", result.Note)
+		}
 		s += "\n"
 	}
 	
+	s += renderPageIndicator(page, pages)
+	if pages > 1 {
+		s += "Press 'n'/'p' or PgDn/PgUp for the next or previous page\n"
+	}
 	s += "Press Enter to view detailed results\n"
 	s += "Press 'd' to delete selected result\n"
+	s += "Press 'x' to export all results of the selected test to CSV\n"
 	s += "Press 'r' to refresh results\n"
 	s += "Use arrow keys to navigate\n"
 	
@@ -135,46 +184,195 @@ func (a *App) viewResultsDetail() string {
 	}
 	
 	result := a.testResults.selectedResult
-	percentage := float64(result.Score) / float64(result.TotalQuestions) * 100
+	percentage := result.Percentage
 	grade := a.getGrade(percentage)
 	
 	s := fmt.Sprintf("Test: %s\n", result.TestName)
 	s += fmt.Sprintf("Score: %d/%d (%.1f%%) - %s\n", 
-		result.Score, result.TotalQuestions, percentage, grade)
+		result.CorrectAnswers, result.TotalQuestions, percentage, grade)
 	s += fmt.Sprintf("Completed: %s\n", 
 		result.CompletedAt.Format("Jan 2, 2006 3:04 PM"))
 	if result.TimeTaken > 0 {
 		s += fmt.Sprintf("Time Taken: %s\n", a.formatDuration(result.TimeTaken))
 	}
+	if result.Note != "" {
+		s += fmt.Sprintf("Note: %s\n", result.Note)
+	}
+	if len(result.History) > 1 {
+		s += fmt.Sprintf("Trend: %s %.1f%% → %.1f%% over %d attempts\n", renderSparkline(result.History),
+			result.History[0], result.History[len(result.History)-1], len(result.History))
+	}
 	s += "\n"
 	
+	if a.testResults.inputMode {
+		s += "Enter a note for this result (leave empty to remove it):\n"
+		s += "> " + a.testResults.input + "\n\n"
+		s += "Press Enter to confirm, Esc to cancel\n"
+		return s
+	}
+	
+	footer := "Press 'b' to go back to results list\n"
+	footer += "Press 'n' to add or edit a note, 'd' to delete this result\n"
+	footer += "Press 'm' to export the test with its answer key to Markdown\n"
+	
 	if len(result.Answers) == 0 {
 		s += "No detailed answers available.\n"
-	} else {
-		s += "Question Details:\n\n"
+		return s + footer
+	}
+	
+	footer = "↑↓ Move between answers • PgUp/PgDn to page • 's' to star for study\n" + footer
+	
+	blocks := make([]string, len(result.Answers))
+	for i, answer := range result.Answers {
+		status := "✗"
+		if answer.IsCorrect {
+			status = "✓"
+		}
 		
-		for i, answer := range result.Answers {
-			status := "✗"
-			if answer.IsCorrect {
-				status = "✓"
-			}
-			
-			s += fmt.Sprintf("%d. %s %s\n", i+1, status, answer.QuestionText)
-			s += fmt.Sprintf("   Your Answer: %s\n", answer.UserAnswer)
-			if !answer.IsCorrect {
-				s += fmt.Sprintf("   Correct Answer: %s\n", answer.CorrectAnswer)
-			}
-			if answer.Explanation != "" {
-				s += fmt.Sprintf("   Explanation: %s\n", answer.Explanation)
+		cursor := " "
+		if i == a.testResults.detailCursor {
+			cursor = ">"
+		}
+		
+		star := ""
+		if a.studyList[answer.QuestionID] {
+			star = "★ "
+		}
+		
+		block := fmt.Sprintf("%s %d. %s %s%s\n", cursor, i+1, status, star, answer.QuestionText)
+		block += fmt.Sprintf("     Your Answer: %s\n", answer.UserAnswer)
+		if answer.TimeSpent > 0 {
+			block += fmt.Sprintf("     Time Spent: %s\n", a.formatDuration(answer.TimeSpent))
+		}
+		if !answer.IsCorrect {
+			block += fmt.Sprintf("     Correct Answer: %s\n", answer.CorrectAnswer)
+		}
+		if answer.Explanation != "" {
+			block += fmt.Sprintf("     Explanation: %s\n", answer.Explanation)
+		}
+		if answer.SourceRef != "" {
+			block += fmt.Sprintf("     Source: %s\n", answer.SourceRef)
+		}
+		blocks[i] = block + "\n"
+	}
+	
+	s += fmt.Sprintf("Question Details (answer %d of %d):\n\n", a.testResults.detailCursor+1, len(blocks))
+	
+	// Lines left for answers once the header, summary and footers are drawn
+	budget := 0
+	if a.height > 0 {
+		budget = a.height - countLines(s) - countLines(footer) - chromeLines
+	}
+	
+	// Scroll by rows so the layout scrolls the same way with one or more columns
+	columns := a.answerColumns()
+	rows := a.layoutAnswerRows(blocks, columns)
+	start, end := a.scrollAnswerWindow(rows, a.testResults.detailCursor/columns, budget)
+	for _, row := range rows[start:end] {
+		s += row
+	}
+	
+	return s + footer
+}
+
+// sparkLevels are the bars of a sparkline, from lowest to highest
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws percentages (0-100) as a row of bars, one per value.
+// Bars share a fixed 0-100 scale so trends compare across tests.
+func renderSparkline(values []float64) string {
+	var b strings.Builder
+	for _, value := range values {
+		if value < 0 {
+			value = 0
+		}
+		if value > 100 {
+			value = 100
+		}
+		level := int(value/100*float64(len(sparkLevels)-1) + 0.5)
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// answerColumns returns how many columns of answers fit the terminal, up to the
+// configured number of review columns
+func (a *App) answerColumns() int {
+	columns := a.settingInt(settingReviewColumns)
+	if columns < 1 {
+		columns = 1
+	}
+	for columns > 1 && a.width < columns*minAnswerColumnWidth {
+		columns--
+	}
+	return columns
+}
+
+// layoutAnswerRows arranges answer blocks left to right into rows of the given
+// number of columns. With a single column each block is a row, wrapped to the
+// terminal width so its line count matches what is drawn.
+func (a *App) layoutAnswerRows(blocks []string, columns int) []string {
+	if columns <= 1 {
+		rows := make([]string, len(blocks))
+		for i, block := range blocks {
+			rows[i] = a.wrapToWidth(strings.TrimRight(block, "\n")) + "\n\n"
+		}
+		return rows
+	}
+	
+	gap := "  "
+	columnWidth := (a.width - (columns-1)*len(gap)) / columns
+	columnStyle := lipgloss.NewStyle().Width(columnWidth)
+	
+	var rows []string
+	for start := 0; start < len(blocks); start += columns {
+		var cells []string
+		for i := start; i < start+columns && i < len(blocks); i++ {
+			if i > start {
+				cells = append(cells, gap)
 			}
-			s += "\n"
+			cells = append(cells, columnStyle.Render(strings.TrimRight(blocks[i], "\n")))
 		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...)+"\n\n")
+	}
+	return rows
+}
+
+// scrollAnswerWindow returns the range of answer blocks that fits in budget lines,
+// moving the scroll offset so the block at cursor stays visible. A budget of zero
+// or less shows every block.
+func (a *App) scrollAnswerWindow(blocks []string, cursor, budget int) (int, int) {
+	if budget <= 0 {
+		return 0, len(blocks)
 	}
 	
-	s += "Press 'b' to go back to results list\n"
-	s += "Press 'd' to delete this result\n"
+	if cursor < a.testResults.detailOffset {
+		a.testResults.detailOffset = cursor
+	}
 	
-	return s
+	for {
+		end := fitBlocks(blocks, a.testResults.detailOffset, budget)
+		if cursor < end || a.testResults.detailOffset >= cursor {
+			return a.testResults.detailOffset, end
+		}
+		a.testResults.detailOffset++
+	}
+}
+
+// fitBlocks returns the end index of the blocks starting at start that fit in
+// budget lines. At least one block is always included.
+func fitBlocks(blocks []string, start, budget int) int {
+	used := 0
+	end := start
+	for end < len(blocks) {
+		lines := countLines(blocks[end])
+		if end > start && used+lines > budget {
+			break
+		}
+		used += lines
+		end++
+	}
+	return end
 }
 
 // handleResultsListInput handles input in list mode
@@ -188,39 +386,174 @@ func (a *App) handleResultsListInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if a.testResults.cursor < len(a.testResults.results)-1 {
 			a.testResults.cursor++
 		}
+	case "n", "pgdown":
+		a.testResults.cursor = turnPage(a.testResults.cursor, len(a.testResults.results), a.resultsPerPage(), 1)
+	case "p", "pgup":
+		a.testResults.cursor = turnPage(a.testResults.cursor, len(a.testResults.results), a.resultsPerPage(), -1)
 	case "enter", " ":
 		if len(a.testResults.results) > 0 {
 			a.testResults.selectedResult = &a.testResults.results[a.testResults.cursor]
 			a.loadResultDetails(a.testResults.selectedResult)
 			a.testResults.viewMode = "detail"
+			a.testResults.detailCursor = 0
+			a.testResults.detailOffset = 0
 		}
 	case "d":
 		if len(a.testResults.results) > 0 {
-			return a.deleteTestResult()
+			a.testResults.confirmDelete = true
 		}
 	case "r":
 		a.loadTestResults()
 		a.testResults.successMsg = "Results refreshed"
+	case "x":
+		if len(a.testResults.results) > 0 {
+			a.exportResultsCSV()
+		}
 	case "q":
 		a.currentView = MainMenuView
 	}
 	return a, nil
 }
 
+// exportResultsCSV writes every result of the selected result's test to a
+// CSV file next to the database
+func (a *App) exportResultsCSV() {
+	selected := a.testResults.results[a.testResults.cursor]
+	path := filepath.Join(filepath.Dir(a.db.Path), exportFileName(selected.TestName, "-results.csv"))
+	
+	file, err := os.Create(path)
+	if err != nil {
+		a.testResults.errorMsg = fmt.Sprintf("Failed to create CSV file: %v", err)
+		return
+	}
+	defer file.Close()
+	
+	if err := a.db.ExportResultsCSV(selected.TestID, file); err != nil {
+		a.testResults.errorMsg = fmt.Sprintf("Failed to export results: %v", err)
+		return
+	}
+	
+	a.testResults.successMsg = fmt.Sprintf("Results of %s saved to %s", selected.TestName, path)
+}
+
 // handleResultsDetailInput handles input in detail mode
 func (a *App) handleResultsDetailInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	answerCount := 0
+	if a.testResults.selectedResult != nil {
+		answerCount = len(a.testResults.selectedResult.Answers)
+	}
+	pageSize := a.answersPerPage()
+	
 	switch msg.String() {
+	case "up", "k":
+		if a.testResults.detailCursor > 0 {
+			a.testResults.detailCursor--
+		}
+	case "down", "j":
+		if a.testResults.detailCursor < answerCount-1 {
+			a.testResults.detailCursor++
+		}
+	case "pgup":
+		a.testResults.detailCursor -= pageSize
+		if a.testResults.detailCursor < 0 {
+			a.testResults.detailCursor = 0
+		}
+	case "pgdown":
+		a.testResults.detailCursor += pageSize
+		if a.testResults.detailCursor > answerCount-1 {
+			a.testResults.detailCursor = answerCount - 1
+		}
+		if a.testResults.detailCursor < 0 {
+			a.testResults.detailCursor = 0
+		}
+	case "s":
+		if answerCount > 0 {
+			answer := a.testResults.selectedResult.Answers[a.testResults.detailCursor]
+			if err := a.toggleStar(answer.QuestionID); err != nil {
+				a.testResults.errorMsg = err.Error()
+			}
+		}
+	case "n":
+		a.testResults.inputMode = true
+		a.testResults.input = a.testResults.selectedResult.Note
 	case "b":
 		a.testResults.viewMode = "list"
 		a.testResults.selectedResult = nil
 	case "d":
-		return a.deleteTestResult()
+		a.testResults.confirmDelete = true
+	case "m":
+		a.exportResultTestMarkdown()
 	case "q":
 		a.currentView = MainMenuView
 	}
 	return a, nil
 }
 
+// exportResultTestMarkdown exports the test of the selected result as a
+// Markdown handout with its answer key
+func (a *App) exportResultTestMarkdown() {
+	test, err := a.db.GetTest(a.testResults.selectedResult.TestID)
+	if err != nil {
+		a.testResults.errorMsg = fmt.Sprintf("Failed to load test: %v", err)
+		return
+	}
+	
+	path, err := a.writeTestMarkdown(test, true)
+	if err != nil {
+		a.testResults.errorMsg = err.Error()
+		return
+	}
+	a.testResults.successMsg = fmt.Sprintf("Test saved to %s", path)
+}
+
+// handleResultNoteInput handles input mode for editing a result's note
+func (a *App) handleResultNoteInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		// Save the note; selectedResult points into the results list, so both views update
+		note := strings.TrimSpace(a.testResults.input)
+		if err := a.db.UpdateResultNote(a.testResults.selectedResult.ID, note); err != nil {
+			a.testResults.errorMsg = fmt.Sprintf("Failed to save note: %v", err)
+		} else {
+			a.testResults.selectedResult.Note = note
+			a.testResults.successMsg = "Note saved"
+		}
+		a.testResults.inputMode = false
+		a.testResults.input = ""
+	case "esc":
+		// Cancel note editing
+		a.testResults.inputMode = false
+		a.testResults.input = ""
+	case "backspace":
+		// Remove last character
+		if len(a.testResults.input) > 0 {
+			a.testResults.input = a.testResults.input[:len(a.testResults.input)-1]
+		}
+	default:
+		// Add character to input
+		if len(msg.String()) == 1 {
+			a.testResults.input += msg.String()
+		}
+	}
+	return a, nil
+}
+
+// resultsPerPage returns how many results a page of the list shows. Results
+// take up to six lines each, with a note and a blank line after them.
+func (a *App) resultsPerPage() int {
+	return a.itemsPerPage(6, 16)
+}
+
+// answersPerPage estimates how many answers a page holds in the detail view
+func (a *App) answersPerPage() int {
+	// Answer blocks take roughly four lines each
+	pageSize := (a.height - 12) / 4
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	return pageSize * a.answerColumns()
+}
+
 // loadTestResults loads test results from database
 func (a *App) loadTestResults() {
 	results, err := a.db.GetAllTestResults()
@@ -234,12 +567,14 @@ func (a *App) loadTestResults() {
 	for i, result := range results {
 		a.testResults.results[i] = TestResultData{
 			ID:             result.ID,
+			TestID:         result.TestID,
 			TestName:       result.TestName,
-			Score:          int(result.Score),
+			CorrectAnswers: result.CorrectAnswers,
 			TotalQuestions: result.TotalQuestions,
-			Percentage:     result.Score / float64(result.TotalQuestions) * 100,
+			Percentage:     result.Score,
 			TimeTaken:      time.Duration(result.TimeTaken) * time.Second,
 			CompletedAt:    result.CompletedAt,
+			Note:           result.Note,
 		}
 	}
 	
@@ -257,36 +592,46 @@ func (a *App) loadResultDetails(result *TestResultData) {
 		return
 	}
 	
-	// Convert database answers to display format
+	// Convert database answers to display format. Choice answers are stored
+	// in the test's option order, not the shuffled order of the attempt, so
+	// their letters refer to the options as the test lists them.
 	result.Answers = make([]AnswerData, len(answers))
 	for i, answer := range answers {
 		result.Answers[i] = AnswerData{
+			QuestionID:    answer.QuestionID,
 			QuestionText:  answer.QuestionText,
-			UserAnswer:    answer.UserAnswer,
-			CorrectAnswer: answer.CorrectAnswer,
+			UserAnswer:    formatAnswer(answer.QuestionType, answer.UserAnswer),
+			CorrectAnswer: formatAnswer(answer.QuestionType, answer.CorrectAnswer),
 			IsCorrect:     answer.IsCorrect,
 			Explanation:   answer.Explanation,
+			SourceRef:     answer.SourceRef,
+			TimeSpent:     time.Duration(answer.TimeSpent) * time.Second,
 		}
 	}
+	
+	// Every attempt at the same test, for the score trend
+	history, err := a.db.GetTestResults(result.TestID)
+	if err != nil {
+		a.testResults.errorMsg = fmt.Sprintf("Failed to load score history: %v", err)
+		return
+	}
+	result.History = make([]float64, len(history))
+	for i, attempt := range history {
+		// Results come newest first
+		result.History[len(history)-1-i] = attempt.Score
+	}
 }
 
 // deleteTestResult deletes the selected test result
 func (a *App) deleteTestResult() (tea.Model, tea.Cmd) {
-	var resultID int
-	var testName string
-	
-	if a.testResults.viewMode == "detail" && a.testResults.selectedResult != nil {
-		resultID = a.testResults.selectedResult.ID
-		testName = a.testResults.selectedResult.TestName
-	} else if a.testResults.viewMode == "list" && len(a.testResults.results) > 0 {
-		resultID = a.testResults.results[a.testResults.cursor].ID
-		testName = a.testResults.results[a.testResults.cursor].TestName
-	} else {
+	result := a.targetResult()
+	if result == nil {
 		a.testResults.errorMsg = "No result selected for deletion"
 		return a, nil
 	}
+	testName := result.TestName
 	
-	err := a.db.DeleteTestResult(resultID)
+	err := a.db.DeleteTestResult(result.ID)
 	if err != nil {
 		a.testResults.errorMsg = fmt.Sprintf("Failed to delete result: %v", err)
 		return a, nil
@@ -301,6 +646,30 @@ func (a *App) deleteTestResult() (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// targetResult returns the result actions apply to: the one being viewed in
+// detail mode, or the highlighted one in the list
+func (a *App) targetResult() *TestResultData {
+	if a.testResults.viewMode == "detail" {
+		return a.testResults.selectedResult
+	}
+	if a.testResults.viewMode == "list" && a.testResults.cursor < len(a.testResults.results) {
+		return &a.testResults.results[a.testResults.cursor]
+	}
+	return nil
+}
+
+// handleResultDeleteConfirm deletes the result on 'y' and cancels on 'n' or esc
+func (a *App) handleResultDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		a.testResults.confirmDelete = false
+		return a.deleteTestResult()
+	case "n", "esc":
+		a.testResults.confirmDelete = false
+	}
+	return a, nil
+}
+
 // getGrade returns a letter grade based on percentage
 func (a *App) getGrade(percentage float64) string {
 	switch {
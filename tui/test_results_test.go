@@ -0,0 +1,366 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// finishTest creates a test of total short answer questions, takes it with
+// the first correct of them answered right and the rest wrong, and records
+// the result
+func finishTest(t *testing.T, a *App, name string, total, correct int) {
+	t.Helper()
+	texts := make([]string, total)
+	for i := range texts {
+		texts[i] = "Q" + string(rune('1'+i))
+	}
+	questions := shortAnswers(texts...)
+	test := createTest(t, a, name, questions...)
+
+	a.startTest(test, questions)
+	for i, q := range a.currentQuestions {
+		if i < correct {
+			a.userAnswers[q.ID] = "answer"
+		} else {
+			a.userAnswers[q.ID] = "wrong"
+		}
+	}
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+}
+
+func TestResultPercentageNotConvertedTwice(t *testing.T) {
+	a := newTestApp(t)
+	finishTest(t, a, "Biology", 4, 3)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	if len(a.testResults.results) != 1 {
+		t.Fatalf("%d results loaded, want 1", len(a.testResults.results))
+	}
+	result := a.testResults.results[0]
+	if result.CorrectAnswers != 3 || result.TotalQuestions != 4 || result.Percentage != 75 {
+		t.Fatalf("loaded %d/%d at %.1f%%, want 3/4 at 75%%", result.CorrectAnswers, result.TotalQuestions, result.Percentage)
+	}
+
+	// The stored score is already a percentage; treating it as a count of
+	// correct answers would show 75/4*100
+	view := a.viewResultsList()
+	if !strings.Contains(view, "3/4 (75.0%)") || strings.Contains(view, "1875") {
+		t.Errorf("results list does not show 3/4 (75.0%%):\n%s", view)
+	}
+}
+
+func TestPerfectResultShowsHundredPercent(t *testing.T) {
+	a := newTestApp(t)
+	finishTest(t, a, "Biology", 5, 5)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	if len(a.testResults.results) != 1 {
+		t.Fatalf("%d results loaded, want 1", len(a.testResults.results))
+	}
+
+	list := a.viewResultsList()
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	detail := a.viewResultsDetail()
+	for name, view := range map[string]string{"list": list, "details": detail} {
+		if !strings.Contains(view, "5/5 (100.0%)") {
+			t.Errorf("%s does not show 5/5 (100.0%%):\n%s", name, view)
+		}
+		if strings.Contains(view, "2000") || strings.Contains(view, "(20.0%)") {
+			t.Errorf("%s shows a reconverted percentage:\n%s", name, view)
+		}
+	}
+}
+
+func TestResultDetailsShowSource(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Cited", "Uncited")
+	questions[0].SourceRef = "pp. 3-4"
+	test := createTest(t, a, "Biology", questions...)
+
+	a.startTest(test, questions)
+	for _, q := range a.currentQuestions {
+		a.userAnswers[q.ID] = "wrong"
+	}
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	detail := a.viewResultsDetail()
+	if strings.Count(detail, "Source:") != 1 || !strings.Contains(detail, "Source: pp. 3-4") {
+		t.Errorf("details do not show the one source:\n%s", detail)
+	}
+}
+
+func TestDeleteResultNeedsConfirmation(t *testing.T) {
+	for _, mode := range []string{"list", "detail"} {
+		t.Run(mode, func(t *testing.T) {
+			a := newTestApp(t)
+			finishTest(t, a, "Biology", 2, 1)
+			a.currentView = TestResultsView
+			a.loadTestResults()
+			if mode == "detail" {
+				press(a, "enter")
+			}
+
+			storedResults := func() int {
+				t.Helper()
+				results, err := a.db.GetAllTestResults()
+				if err != nil {
+					t.Fatalf("GetAllTestResults: %v", err)
+				}
+				return len(results)
+			}
+
+			for _, cancel := range []string{"n", "esc"} {
+				press(a, "d")
+				if view := a.viewTestResults(); !strings.Contains(view, "Delete the result of 'Biology'") {
+					t.Fatalf("no confirmation prompt:\n%s", view)
+				}
+				press(a, cancel)
+				if a.testResults.confirmDelete {
+					t.Errorf("%s left the prompt open", cancel)
+				}
+				if n := storedResults(); n != 1 {
+					t.Fatalf("after %s: %d results stored, want 1", cancel, n)
+				}
+				if a.currentView != TestResultsView || a.testResults.viewMode != mode {
+					t.Fatalf("%s left %s mode", cancel, mode)
+				}
+			}
+
+			press(a, "d")
+			press(a, "y")
+			if n := storedResults(); n != 0 {
+				t.Errorf("after y: %d results stored, want 0", n)
+			}
+			if len(a.testResults.results) != 0 || a.testResults.viewMode != "list" {
+				t.Errorf("after y: %d results listed in %s mode, want none in list mode", len(a.testResults.results), a.testResults.viewMode)
+			}
+		})
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	for _, tt := range []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{0, 50, 100}, "▁▅█"},
+		{[]float64{0, 14.3, 28.6, 42.9, 57.1, 71.4, 85.7, 100}, "▁▂▃▄▅▆▇█"},
+		{[]float64{-10, 150}, "▁█"},
+	} {
+		if got := renderSparkline(tt.values); got != tt.want {
+			t.Errorf("renderSparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestResultDetailsShowTrend(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3", "Q4")
+	test := createTest(t, a, "Biology", questions...)
+	for day, correct := range []int{1, 2, 4} {
+		a.startTest(test, questions)
+		for i, q := range a.currentQuestions {
+			a.userAnswers[q.ID] = "wrong"
+			if i < correct {
+				a.userAnswers[q.ID] = "answer"
+			}
+		}
+		if err := a.recordTestResults(); err != nil {
+			t.Fatalf("recordTestResults: %v", err)
+		}
+		// Attempts a day apart, so they order the same however fast the test runs
+		completed := time.Date(2026, 3, day+1, 10, 0, 0, 0, time.UTC)
+		if _, err := a.db.Exec(`UPDATE test_results SET completed_at = ? WHERE id = (SELECT MAX(id) FROM test_results)`, completed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	// The newest result is listed first
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	detail := a.viewResultsDetail()
+	if want := "Trend: ▃▅█ 25.0% → 100.0% over 3 attempts"; !strings.Contains(detail, want) {
+		t.Errorf("details do not show %q:\n%s", want, detail)
+	}
+}
+
+func TestResultDetailsScroll(t *testing.T) {
+	a := newTestApp(t)
+	a.width, a.height = 80, 30
+	finishTest(t, a, "Biology", 30, 10)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	press(a, "enter")
+
+	detail := a.viewResultsDetail()
+	if lines := countLines(detail); lines > a.height {
+		t.Errorf("details take %d lines, more than the %d line terminal", lines, a.height)
+	}
+	for _, want := range []string{"answer 1 of 30", "> 1.", "Press 'm' to export"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("details do not show %q:\n%s", want, detail)
+		}
+	}
+	if strings.Contains(detail, " 30. ") {
+		t.Errorf("the last answer shows on the first page:\n%s", detail)
+	}
+
+	press(a, "down")
+	if detail := a.viewResultsDetail(); !strings.Contains(detail, "answer 2 of 30") {
+		t.Errorf("down does not move to the second answer:\n%s", detail)
+	}
+
+	press(a, "pgdown")
+	if want := 1 + a.answersPerPage(); a.testResults.detailCursor != want {
+		t.Errorf("pgdown moved the cursor to %d, want %d", a.testResults.detailCursor, want)
+	}
+
+	for range 30 {
+		press(a, "pgdown")
+	}
+	detail = a.viewResultsDetail()
+	for _, want := range []string{"answer 30 of 30", "> 30."} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("details do not show %q after paging to the end:\n%s", want, detail)
+		}
+	}
+	if lines := countLines(detail); lines > a.height {
+		t.Errorf("details take %d lines after scrolling, more than %d", lines, a.height)
+	}
+
+	press(a, "pgup")
+	if a.testResults.detailCursor != 29-a.answersPerPage() {
+		t.Errorf("pgup moved the cursor to %d, want %d", a.testResults.detailCursor, 29-a.answersPerPage())
+	}
+}
+
+func TestAnswerColumns(t *testing.T) {
+	a := newTestApp(t)
+	for _, tc := range []struct {
+		setting string
+		width   int
+		want    int
+	}{
+		{"2", 80, 1},
+		{"2", 120, 2},
+		{"2", 300, 2},
+		{"3", 150, 2},
+		{"3", 180, 3},
+		{"1", 300, 1},
+		{"0", 300, 1},
+	} {
+		a.saveSetting(settingReviewColumns, tc.setting)
+		a.width = tc.width
+		if got := a.answerColumns(); got != tc.want {
+			t.Errorf("%s column(s) at width %d: got %d, want %d", tc.setting, tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestResultDetailsTwoColumns(t *testing.T) {
+	a := newTestApp(t)
+	a.width, a.height = 140, 30
+	finishTest(t, a, "Biology", 30, 10)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	press(a, "enter")
+
+	// Answers sit side by side on wide terminals
+	detail := a.viewResultsDetail()
+	sideBySide := false
+	for _, line := range strings.Split(detail, "\n") {
+		if strings.Contains(line, "> 1.") && strings.Contains(line, "  2.") {
+			sideBySide = true
+		}
+	}
+	if !sideBySide {
+		t.Errorf("answers 1 and 2 are not side by side:\n%s", detail)
+	}
+	if lines := countLines(detail); lines > a.height {
+		t.Errorf("details take %d lines, more than the %d line terminal", lines, a.height)
+	}
+
+	// Scrolling keeps the selected answer in view with two columns too
+	for range 30 {
+		press(a, "pgdown")
+	}
+	detail = a.viewResultsDetail()
+	if !strings.Contains(detail, "answer 30 of 30") || !strings.Contains(detail, "> 30.") {
+		t.Errorf("last answer not shown after paging to the end:\n%s", detail)
+	}
+	if lines := countLines(detail); lines > a.height {
+		t.Errorf("details take %d lines after scrolling, more than %d", lines, a.height)
+	}
+
+	// Narrow terminals fall back to one column
+	a.width = 80
+	a.testResults.detailCursor, a.testResults.detailOffset = 0, 0
+	for _, line := range strings.Split(a.viewResultsDetail(), "\n") {
+		if strings.Contains(line, "1.") && strings.Contains(line, "2.") {
+			t.Errorf("two answers on one line of a narrow terminal: %q", line)
+		}
+	}
+}
+
+func TestResultNote(t *testing.T) {
+	a := newTestApp(t)
+	finishTest(t, a, "Biology", 2, 1)
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	press(a, "enter")
+	press(a, "n")
+	if !a.testResults.inputMode {
+		t.Fatal("'n' did not start editing the note")
+	}
+	typeText(a, "was tired")
+	press(a, "enter")
+	if a.testResults.inputMode || a.testResults.successMsg != "Note saved" {
+		t.Fatalf("note not saved: %q", a.testResults.errorMsg)
+	}
+	if detail := a.viewResultsDetail(); !strings.Contains(detail, "Note: was tired") {
+		t.Errorf("details do not show the note:\n%s", detail)
+	}
+
+	// The note is saved, and shown in the list too
+	a.loadTestResults()
+	a.testResults.viewMode = "list"
+	if list := a.viewTestResults(); !strings.Contains(list, "Note: was tired") {
+		t.Errorf("list does not show the note:\n%s", list)
+	}
+
+	// Esc keeps the note, and saving an empty note removes it
+	press(a, "enter")
+	press(a, "n")
+	press(a, "backspace")
+	press(a, "esc")
+	if a.testResults.selectedResult.Note != "was tired" {
+		t.Errorf("note = %q after cancelling the edit", a.testResults.selectedResult.Note)
+	}
+	press(a, "n")
+	for range len("was tired") {
+		press(a, "backspace")
+	}
+	press(a, "enter")
+	a.loadTestResults()
+	if note := a.testResults.results[0].Note; note != "" {
+		t.Errorf("note = %q after saving it empty", note)
+	}
+}
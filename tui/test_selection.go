@@ -1,30 +1,97 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"time"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 
+	"pdf-test-generator/chatgpt"
 	"pdf-test-generator/database"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// masteredAttempts is how many recent perfect attempts mark a test as mastered
+const masteredAttempts = 3
+
 // TestSelectionModel represents the test selection state
 type TestSelectionModel struct {
-	tests    []*database.Test
-	cursor   int
-	purpose  string // "take_test" or "view_tests"
-	errorMsg string
-	loading  bool
+	allTests   []*database.Test // Every loaded test
+	tests      []*database.Test // Tests currently listed, after filtering
+	cursor     int
+	purpose    string // "take_test", "view_tests" or "study"
+	errorMsg   string
+	successMsg string
+	loading    bool
+	
+	// Mastered tests are hidden unless showMastered is set
+	mastered     map[int]bool
+	showMastered bool
+	
+	// Pending action awaiting y/n confirmation ("delete", "regenerate", "reset_results", "similar", "resume" or "")
+	confirmAction string
+	confirmCount  int // Number of rows the pending action affects
+	
+	// Tests with an unfinished attempt, and the attempt offered for resuming
+	unfinished map[int]bool
+	resume     *database.TestAttempt
+	
+	// Naming the selected test ("rename") or the copy made of it ("clone"),
+	// editing its tags ("tags") or choosing how many of its questions to take
+	// ("count"); "" when not typing
+	inputMode string
+	input     string
+	
+	// Questions of the selected test while choosing how many to take
+	pending []*database.Question
+	
+	// Shuffle the question order of the next attempt
+	shuffle bool
+	
+	// Drill only the questions of this difficulty ("" for every question)
+	difficulty string
+	
+	// Filtering the list by test name
+	searchMode bool
+	search     string
+	
+	// Tags of each loaded test, and the tag the list is limited to ("" for
+	// every test) with the IDs of the tests that have it
+	tags      map[int][]string
+	tagFilter string
+	tagged    map[int]bool
+	
+	// Order of the list ("date", "name" or "questions"), and the number of
+	// questions of each loaded test, counted once per load
+	sortMode       string
+	questionCounts map[int]int
 }
 
+// testSortModes lists the orders the test list cycles through, starting with the default
+var testSortModes = []string{"date", "name", "questions"}
+
 // NewTestSelectionModel creates a new test selection model
 func NewTestSelectionModel() *TestSelectionModel {
 	return &TestSelectionModel{
-		tests: []*database.Test{},
+		tests:    []*database.Test{},
+		sortMode: "date",
 	}
 }
 
+// openTestSelection shows the list of tests for the given purpose, starting
+// without a search filter
+func (a *App) openTestSelection(purpose string) {
+	a.currentView = TestSelectionView
+	a.testSelection.purpose = purpose
+	a.testSelection.searchMode = false
+	a.testSelection.search = ""
+	a.loadTests()
+}
+
 // updateTestSelection handles test selection updates
 func (a *App) updateTestSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if a.testSelection.loading {
@@ -32,12 +99,24 @@ func (a *App) updateTestSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	
 	// Load tests if not already loaded
-	if len(a.testSelection.tests) == 0 {
+	if len(a.testSelection.allTests) == 0 {
 		a.loadTests()
 	}
 	
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if a.testSelection.confirmAction != "" {
+			return a.handleTestSelectionConfirm(msg)
+		}
+		
+		if a.testSelection.inputMode != "" {
+			return a.handleTestRenameInput(msg)
+		}
+		
+		if a.testSelection.searchMode {
+			return a.handleTestSearch(msg)
+		}
+		
 		switch msg.String() {
 		case "up", "k":
 			if a.testSelection.cursor > 0 {
@@ -47,16 +126,99 @@ func (a *App) updateTestSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if a.testSelection.cursor < len(a.testSelection.tests)-1 {
 				a.testSelection.cursor++
 			}
+		case "pgdown", "pgup":
+			// Show the next or previous page of tests
+			delta := 1
+			if msg.String() == "pgup" {
+				delta = -1
+			}
+			a.testSelection.cursor = turnPage(a.testSelection.cursor, len(a.testSelection.tests), a.testsPerPage(), delta)
 		case "enter", " ":
 			return a.handleTestSelection()
+		case "/":
+			a.testSelection.searchMode = true
+		case "S":
+			// Cycle the order of the list
+			a.cycleTestSort()
+		case "esc":
+			// Only reached while a search filter is active, see escHandledByView
+			a.testSelection.search = ""
+			a.applyTestSearch()
 		case "d":
-			// Delete selected test
+			// Delete selected test, once confirmed
 			if len(a.testSelection.tests) > 0 {
-				return a.deleteSelectedTest()
+				a.testSelection.confirmAction = "delete"
 			}
 		case "r":
 			// Refresh test list
 			a.loadTests()
+		case "e":
+			// Rename selected test
+			if len(a.testSelection.tests) > 0 {
+				a.testSelection.inputMode = "rename"
+				a.testSelection.input = a.testSelection.tests[a.testSelection.cursor].Name
+			}
+		case "t":
+			// Edit the selected test's tags
+			if len(a.testSelection.tests) > 0 {
+				a.testSelection.inputMode = "tags"
+				a.testSelection.input = strings.Join(a.testSelection.tags[a.testSelection.tests[a.testSelection.cursor].ID], ", ")
+			}
+		case "T":
+			// Cycle the tag the list is limited to
+			a.cycleTagFilter()
+		case "c":
+			// Copy the selected test under a new name
+			if len(a.testSelection.tests) > 0 {
+				a.testSelection.inputMode = "clone"
+				a.testSelection.input = "Copy of " + a.testSelection.tests[a.testSelection.cursor].Name
+			}
+		case "o":
+			// Toggle shuffled question order for the next attempt
+			if a.testSelection.purpose == "take_test" {
+				a.testSelection.shuffle = !a.testSelection.shuffle
+			}
+		case "v":
+			// Cycle the difficulty the next attempt is limited to
+			if a.testSelection.purpose == "take_test" {
+				a.testSelection.difficulty = nextDifficulty(a.testSelection.difficulty)
+			}
+		case "tab":
+			// Switch between active and mastered tests
+			if a.settingBool(settingHideMastered) {
+				a.testSelection.showMastered = !a.testSelection.showMastered
+				a.applyTestFilter()
+			}
+		case "g":
+			// Regenerate selected test from its source PDF
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				return a.requestRegenerateTest()
+			}
+		case "w":
+			// Wipe the selected test's results, keeping its questions
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				return a.requestResetResults()
+			}
+		case "m":
+			// Manage the selected test's questions
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				return a.startEditTest(a.testSelection.tests[a.testSelection.cursor])
+			}
+		case "a":
+			// Export the selected test's answer key
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				return a.exportSelectedAnswerKey()
+			}
+		case "p", "P":
+			// Export the selected test as a printable handout, with the answer key on 'P'
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				a.exportSelectedTestMarkdown(msg.String() == "P")
+			}
+		case "s":
+			// Generate a new test on the same topics as the selected one
+			if a.testSelection.purpose == "view_tests" && len(a.testSelection.tests) > 0 {
+				a.testSelection.confirmAction = "similar"
+			}
 		}
 	}
 	return a, nil
@@ -65,31 +227,112 @@ func (a *App) updateTestSelection(msg tea.Msg) (tea.Model, tea.Cmd) {
 // viewTestSelection renders the test selection view
 func (a *App) viewTestSelection() string {
 	title := "Select Test"
-	if a.testSelection.purpose == "view_tests" {
+	switch a.testSelection.purpose {
+	case "view_tests":
 		title = "View Tests"
+	case "study":
+		title = "Select Test to Study"
 	}
 	
-	s := a.renderHeader(title)
+	s := a.renderHeader(fmt.Sprintf("%s • Sorted by %s", title, describeTestSort(a.testSelection.sortMode)))
 	
 	if a.testSelection.errorMsg != "" {
 		s += a.renderError(a.testSelection.errorMsg)
 		a.testSelection.errorMsg = ""
 	}
 	
+	if a.testSelection.successMsg != "" {
+		s += a.renderSuccess(a.testSelection.successMsg)
+		a.testSelection.successMsg = ""
+	}
+	
 	if a.testSelection.loading {
 		s += "⏳ Loading tests...\n\n"
 		return s + a.renderFooter()
 	}
 	
+	s += a.testListFilters()
+	
+	if len(a.testSelection.tests) == 0 && a.testSelection.search != "" {
+		s += "No tests match the search.\n\n"
+		s += "Press Esc to clear the search\n"
+		return s + a.renderFooter()
+	}
+	
+	if len(a.testSelection.tests) == 0 && len(a.testSelection.allTests) > 0 {
+		s += "No tests in this list.\n\n"
+		return s + a.renderFooter()
+	}
+	
 	if len(a.testSelection.tests) == 0 {
 		s += "No tests found. Create some tests first!\n\n"
 		s += "Press 'r' to refresh\n"
 		return s + a.renderFooter()
 	}
 	
+	if a.testSelection.inputMode != "" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		switch a.testSelection.inputMode {
+		case "clone":
+			s += fmt.Sprintf("Enter a name for the copy of '%s' (its results are not copied):\n", selectedTest.Name)
+		case "tags":
+			s += fmt.Sprintf("Enter the tags of '%s', separated by commas:\n", selectedTest.Name)
+		case "count":
+			s += fmt.Sprintf("How many of the %d questions of '%s' do you want to take? Enter a number or 'all'.\n", len(a.testSelection.pending), selectedTest.Name)
+			s += "A random subset is scored out of the questions taken.\n"
+		default:
+			s += fmt.Sprintf("Enter a new name for '%s':\n", selectedTest.Name)
+		}
+		s += "> " + a.testSelection.input + "\n\n"
+		s += "Press Enter to confirm, Esc to cancel\n"
+		return s + a.renderFooter()
+	}
+	
+	if a.testSelection.confirmAction == "delete" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		s += fmt.Sprintf("Delete '%s'? (y/n)\n\n", selectedTest.Name)
+		s += "Its questions and results are deleted too. This cannot be undone.\n"
+		return s + a.renderFooter()
+	}
+	
+	if a.testSelection.confirmAction == "regenerate" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		s += fmt.Sprintf("Regenerate '%s' from %s?\n\n", selectedTest.Name, selectedTest.SourcePath)
+		s += "All of its questions will be replaced. Past results are kept.\n\n"
+		s += "Press 'y' to continue, 'n' to cancel\n"
+		return s + a.renderFooter()
+	}
+	
+	if a.testSelection.confirmAction == "reset_results" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		s += fmt.Sprintf("Delete all %d result(s) for '%s'?\n\n", a.testSelection.confirmCount, selectedTest.Name)
+		s += "The test and its questions are kept.\n\n"
+		s += "Press 'y' to continue, 'n' to cancel\n"
+		return s + a.renderFooter()
+	}
+	
+	if a.testSelection.confirmAction == "resume" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		attempt := a.testSelection.resume
+		s += fmt.Sprintf("'%s' has an unfinished attempt: %d of %d questions answered, last saved %s.\n\n",
+			selectedTest.Name, len(attempt.Answers), a.testSelection.questionCounts[selectedTest.ID], attempt.UpdatedAt.Local().Format("Jan 2, 3:04 PM"))
+		s += "Press 'y' to resume it, 'n' to start over, Esc to cancel\n"
+		return s + a.renderFooter()
+	}
+	
+	if a.testSelection.confirmAction == "similar" {
+		selectedTest := a.testSelection.tests[a.testSelection.cursor]
+		s += fmt.Sprintf("Generate a new test covering the same topics as '%s'?\n\n", selectedTest.Name)
+		s += "Its questions are sent to ChatGPT as context; the original test is not changed.\n\n"
+		s += "Press 'y' to continue, 'n' to cancel\n"
+		return s + a.renderFooter()
+	}
+	
 	s += "Available Tests:\n\n"
 	
-	for i, test := range a.testSelection.tests {
+	page, pages, start, end := listPage(a.testSelection.cursor, len(a.testSelection.tests), a.testsPerPage())
+	for i := start; i < end; i++ {
+		test := a.testSelection.tests[i]
 		cursor := " "
 		if a.testSelection.cursor == i {
 			cursor = ">"
@@ -99,27 +342,105 @@ func (a *App) viewTestSelection() string {
 			s += fmt.Sprintf("%s %s\n", cursor, a.formatTestInfo(test))
 		}
 	}
+	if pages > 1 {
+		s += "\n" + renderPageIndicator(page, pages)
+	}
+	
+	s += a.testListHints(pages)
+	
+	return s + a.renderFooter()
+}
+
+// testListFilters renders the lines above the test list that say which tests
+// it shows
+func (a *App) testListFilters() string {
+	s := ""
+	if a.settingBool(settingHideMastered) {
+		if a.testSelection.showMastered {
+			s += "Showing mastered tests (press Tab for active tests)\n\n"
+		} else {
+			s += fmt.Sprintf("Hiding %d mastered test(s) (press Tab to show them)\n\n", len(a.testSelection.mastered))
+		}
+	}
+	
+	if a.testSelection.searchMode || a.testSelection.search != "" {
+		s += fmt.Sprintf("Search: %s", a.testSelection.search)
+		if a.testSelection.searchMode {
+			s += "█"
+		}
+		s += fmt.Sprintf("  (%d of %d tests)\n\n", len(a.testSelection.tests), len(a.testSelection.allTests))
+	}
+	
+	if a.testSelection.tagFilter != "" {
+		s += fmt.Sprintf("Tag: %s (press 'T' for the next tag)\n\n", a.testSelection.tagFilter)
+	}
+	return s
+}
+
+// testListHints renders the key hints below a test list of the given number of
+// pages. The less common keys of each purpose are only listed in the '?' help.
+func (a *App) testListHints(pages int) string {
+	if a.testSelection.searchMode {
+		return "\nType to filter by name • Enter to keep the filter • Esc to clear it\n"
+	}
 	
 	actionText := "take"
-	if a.testSelection.purpose == "view_tests" {
+	switch a.testSelection.purpose {
+	case "view_tests":
 		actionText = "view details for"
+	case "study":
+		actionText = "study"
 	}
 	
-	s += fmt.Sprintf("\nPress Enter to %s selected test, 'd' to delete, 'r' to refresh\n", actionText)
-	
-	return s + a.renderFooter()
+	s := fmt.Sprintf("\nPress Enter to %s selected test, '/' to search, 'S' to change the order, 'e' to rename, 'c' to copy, 'd' to delete, 'r' to refresh\n", actionText)
+	s += "Press 't' to tag the selected test, 'T' to show only tests with a tag\n"
+	if pages > 1 {
+		s += "Press PgUp/PgDn for the previous or next page\n"
+	}
+	if a.testSelection.purpose == "take_test" {
+		order := "database order"
+		if a.testSelection.shuffle {
+			order = "shuffled"
+		}
+		s += fmt.Sprintf("Question order: %s (press 'o' to toggle)\n", order)
+		difficulty := "all questions"
+		if a.testSelection.difficulty != "" {
+			difficulty = a.testSelection.difficulty + " questions only, as an unsaved drill"
+		}
+		s += fmt.Sprintf("Difficulty: %s (press 'v' to change)\n", difficulty)
+	}
+	if a.testSelection.purpose == "view_tests" {
+		s += "Press '?' to regenerate, wipe results, generate a similar test, edit questions or export\n"
+	}
+	return s
+}
+
+// testsPerPage returns how many tests a page of the list shows, leaving room
+// for the header, a status message, the filters, the page indicator and the
+// key hints of the current purpose
+func (a *App) testsPerPage() int {
+	reserved := lineCount(a.renderHeader("")) + 1 + lineCount(a.testListFilters()) +
+		lineCount("Available Tests:\n\n") + lineCount("\n"+renderPageIndicator(0, 2)) +
+		lineCount(a.testListHints(2)) + lineCount(a.renderFooter())
+	return a.itemsPerPage(1, reserved)
 }
 
 // formatTestInfo formats test information for display
 func (a *App) formatTestInfo(test *database.Test) string {
-	// Get question count
-	questions, _ := a.db.GetQuestionsByTestID(test.ID)
-	questionCount := len(questions)
+	// Question counts are loaded with the tests rather than queried on every render
+	questionCount := a.testSelection.questionCounts[test.ID]
 	
 	// Format creation date
 	createdDate := test.CreatedAt.Format("2006-01-02")
 	
-	return fmt.Sprintf("%s (%d questions) - Created: %s", test.Name, questionCount, createdDate)
+	info := fmt.Sprintf("%s (%d questions) - Created: %s", test.Name, questionCount, createdDate)
+	if tags := a.testSelection.tags[test.ID]; len(tags) > 0 {
+		info += " [" + strings.Join(tags, ", ") + "]"
+	}
+	if a.testSelection.purpose == "take_test" && a.testSelection.unfinished[test.ID] {
+		info += " ⏸ unfinished"
+	}
+	return info
 }
 
 // handleTestSelection processes test selection
@@ -133,6 +454,10 @@ func (a *App) handleTestSelection() (tea.Model, tea.Cmd) {
 	
 	switch a.testSelection.purpose {
 	case "take_test":
+		if a.testSelection.difficulty != "" {
+			return a.startDifficultyDrill(selectedTest, a.testSelection.difficulty)
+		}
+		
 		// Load questions and start test
 		questions, err := a.db.GetQuestionsByTestID(selectedTest.ID)
 		if err != nil {
@@ -145,13 +470,21 @@ func (a *App) handleTestSelection() (tea.Model, tea.Cmd) {
 			return a, nil
 		}
 		
-		a.currentQuestions = questions
-		a.userAnswers = make(map[int]string)
-		a.testStartTime = time.Now()
-		a.testTaking.currentQuestion = 0
-		a.testTaking.input = ""
-		a.currentView = TestTakingView
-		return a, nil
+		// Offer to pick up where an interrupted attempt left off
+		if a.testSelection.unfinished[selectedTest.ID] {
+			attempt, err := a.db.GetTestAttempt(selectedTest.ID)
+			if err != nil {
+				a.testSelection.errorMsg = fmt.Sprintf("Failed to load unfinished attempt: %v", err)
+				return a, nil
+			}
+			if attempt != nil {
+				a.testSelection.resume = attempt
+				a.testSelection.confirmAction = "resume"
+				return a, nil
+			}
+		}
+		
+		return a.promptQuestionCount(questions)
 		
 	case "view_tests":
 		// Show test results/details
@@ -159,11 +492,147 @@ func (a *App) handleTestSelection() (tea.Model, tea.Cmd) {
 		a.loadTestResults()
 		return a, nil
 		
+	case "study":
+		// Flip through the questions as flashcards
+		return a.startStudy(selectedTest)
+		
 	default:
 		return a, nil
 	}
 }
 
+// startSelectedTest starts an attempt at the selected test, resuming the given
+// saved progress, or from the beginning when attempt is nil
+func (a *App) startSelectedTest(test *database.Test, questions []*database.Question, attempt *database.TestAttempt) (tea.Model, tea.Cmd) {
+	// A resumed attempt keeps the questions and order it was saved with
+	if attempt != nil {
+		return a, a.resumeTest(test, questions, attempt)
+	}
+	
+	if a.testSelection.shuffle {
+		questions = a.shuffleQuestions(questions)
+	}
+	return a, a.startTest(test, questions)
+}
+
+// answerResumePrompt resumes the unfinished attempt at the selected test, or
+// discards it and starts over
+func (a *App) answerResumePrompt(resume bool) (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	attempt := a.testSelection.resume
+	a.testSelection.resume = nil
+	
+	questions, err := a.db.GetQuestionsByTestID(selectedTest.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return a, nil
+	}
+	
+	if !resume {
+		if err := a.db.DeleteTestAttempt(selectedTest.ID); err != nil {
+			a.testSelection.errorMsg = err.Error()
+			return a, nil
+		}
+		delete(a.testSelection.unfinished, selectedTest.ID)
+		return a.promptQuestionCount(questions)
+	}
+	return a.startSelectedTest(selectedTest, questions, attempt)
+}
+
+// promptQuestionCount asks how many of the selected test's questions to take,
+// defaulting to all of them
+func (a *App) promptQuestionCount(questions []*database.Question) (tea.Model, tea.Cmd) {
+	if len(questions) < 2 {
+		return a.startSelectedTest(a.testSelection.tests[a.testSelection.cursor], questions, nil)
+	}
+	
+	a.testSelection.pending = questions
+	a.testSelection.inputMode = "count"
+	a.testSelection.input = "all"
+	return a, nil
+}
+
+// parseQuestionCount reads how many of the available questions to take: a
+// number, clamped to the available count, or "all" (also when left empty)
+func parseQuestionCount(input string, available int) (int, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" || input == "all" {
+		return available, nil
+	}
+	
+	count, err := strconv.Atoi(input)
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("Please enter a number of questions or 'all'")
+	}
+	return min(count, available), nil
+}
+
+// startQuestionSubset starts the selected test with the number of questions
+// given in input. A random subset is still an attempt at the test: its result
+// is saved against the test, out of the questions taken.
+func (a *App) startQuestionSubset(input string) (tea.Model, tea.Cmd) {
+	questions := a.testSelection.pending
+	count, err := parseQuestionCount(input, len(questions))
+	if err != nil {
+		a.testSelection.errorMsg = err.Error()
+		return a, nil
+	}
+	
+	a.testSelection.pending = nil
+	a.testSelection.inputMode = ""
+	a.testSelection.input = ""
+	
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	return a.startSelectedTest(selectedTest, a.sampleQuestions(questions, count), nil)
+}
+
+// startDifficultyDrill starts a practice session with only the questions of a
+// test rated at the given difficulty. Like retrying incorrect answers, the
+// drill covers part of the test, so its results are not saved.
+func (a *App) startDifficultyDrill(test *database.Test, level string) (tea.Model, tea.Cmd) {
+	questions, err := a.db.GetQuestionsByDifficulty(test.ID, level)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return a, nil
+	}
+	
+	if len(questions) == 0 {
+		a.testSelection.errorMsg = fmt.Sprintf("This test has no %s questions", level)
+		return a, nil
+	}
+	
+	if a.testSelection.shuffle {
+		questions = a.shuffleQuestions(questions)
+	}
+	
+	drill := &database.Test{Name: fmt.Sprintf("%s (%s only)", test.Name, level)}
+	return a, a.startTest(drill, questions)
+}
+
+// sampleQuestions picks count distinct questions at random, keeping them in
+// the order of the test
+func (a *App) sampleQuestions(questions []*database.Question, count int) []*database.Question {
+	picked := a.rng.Perm(len(questions))[:count]
+	sort.Ints(picked)
+	
+	sample := make([]*database.Question, count)
+	for i, index := range picked {
+		sample[i] = questions[index]
+	}
+	return sample
+}
+
+// shuffleQuestions returns the questions in a random order. Answers are keyed
+// by question ID, so scoring and saved results do not depend on the order.
+func (a *App) shuffleQuestions(questions []*database.Question) []*database.Question {
+	shuffled := make([]*database.Question, len(questions))
+	copy(shuffled, questions)
+	a.rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
 // loadTests loads all tests from database
 func (a *App) loadTests() {
 	a.testSelection.loading = true
@@ -171,15 +640,175 @@ func (a *App) loadTests() {
 	tests, err := a.db.GetAllTests()
 	if err != nil {
 		a.testSelection.errorMsg = fmt.Sprintf("Failed to load tests: %v", err)
-		a.testSelection.tests = []*database.Test{}
+		a.testSelection.allTests = []*database.Test{}
 	} else {
-		a.testSelection.tests = tests
+		a.testSelection.allTests = tests
+	}
+	
+	a.testSelection.unfinished, err = a.db.GetTestAttemptIDs()
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load unfinished attempts: %v", err)
+	}
+	
+	a.testSelection.tags, err = a.db.GetTestTags()
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load tags: %v", err)
+	}
+	a.loadTaggedTests()
+	
+	a.testSelection.questionCounts = make(map[int]int, len(a.testSelection.allTests))
+	for _, test := range a.testSelection.allTests {
+		if count, err := a.db.CountQuestions(test.ID); err == nil {
+			a.testSelection.questionCounts[test.ID] = count
+		}
+	}
+	a.sortTests()
+	
+	a.testSelection.mastered = make(map[int]bool)
+	if a.settingBool(settingHideMastered) {
+		for _, test := range a.testSelection.allTests {
+			if a.isTestMastered(test.ID) {
+				a.testSelection.mastered[test.ID] = true
+			}
+		}
 	}
 	
+	a.applyTestFilter()
 	a.testSelection.cursor = 0
 	a.testSelection.loading = false
 }
 
+// isTestMastered reports whether the most recent attempts of a test were all perfect
+func (a *App) isTestMastered(testID int) bool {
+	results, err := a.db.GetTestResults(testID)
+	if err != nil || len(results) < masteredAttempts {
+		return false
+	}
+	
+	// Results are ordered newest first
+	for _, result := range results[:masteredAttempts] {
+		if result.TotalQuestions == 0 || result.CorrectAnswers < result.TotalQuestions {
+			return false
+		}
+	}
+	return true
+}
+
+// cycleTestSort switches the list to the next sort mode, keeping the selected test highlighted
+func (a *App) cycleTestSort() {
+	var selected *database.Test
+	if len(a.testSelection.tests) > 0 {
+		selected = a.testSelection.tests[a.testSelection.cursor]
+	}
+	
+	next := 0
+	for i, mode := range testSortModes {
+		if mode == a.testSelection.sortMode {
+			next = (i + 1) % len(testSortModes)
+		}
+	}
+	a.testSelection.sortMode = testSortModes[next]
+	a.sortTests()
+	a.applyTestFilter()
+	
+	for i, test := range a.testSelection.tests {
+		if test == selected {
+			a.testSelection.cursor = i
+		}
+	}
+}
+
+// sortTests orders all loaded tests by the current sort mode: newest first,
+// by name, or by most questions. Ties are broken by name.
+func (a *App) sortTests() {
+	tests := a.testSelection.allTests
+	counts := a.testSelection.questionCounts
+	
+	sort.SliceStable(tests, func(i, j int) bool {
+		switch a.testSelection.sortMode {
+		case "name":
+			return strings.ToLower(tests[i].Name) < strings.ToLower(tests[j].Name)
+		case "questions":
+			if counts[tests[i].ID] != counts[tests[j].ID] {
+				return counts[tests[i].ID] > counts[tests[j].ID]
+			}
+		default:
+			if !tests[i].CreatedAt.Equal(tests[j].CreatedAt) {
+				return tests[i].CreatedAt.After(tests[j].CreatedAt)
+			}
+		}
+		return strings.ToLower(tests[i].Name) < strings.ToLower(tests[j].Name)
+	})
+}
+
+// describeTestSort names a sort mode for the list header
+func describeTestSort(mode string) string {
+	switch mode {
+	case "name":
+		return "name"
+	case "questions":
+		return "question count"
+	default:
+		return "newest first"
+	}
+}
+
+// handleTestSearch handles typing in the search box, refiltering on every key
+func (a *App) handleTestSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		a.testSelection.searchMode = false
+	case "esc":
+		a.testSelection.searchMode = false
+		a.testSelection.search = ""
+		a.applyTestSearch()
+	case "backspace":
+		if len(a.testSelection.search) > 0 {
+			a.testSelection.search = a.testSelection.search[:len(a.testSelection.search)-1]
+			a.applyTestSearch()
+		}
+	default:
+		if len(msg.String()) == 1 {
+			a.testSelection.search += msg.String()
+			a.applyTestSearch()
+		}
+	}
+	return a, nil
+}
+
+// applyTestSearch refilters the tests after the search changed and jumps to the first match
+func (a *App) applyTestSearch() {
+	a.applyTestFilter()
+	a.testSelection.cursor = 0
+}
+
+// applyTestFilter rebuilds the listed tests from all loaded tests
+func (a *App) applyTestFilter() {
+	hideMastered := a.settingBool(settingHideMastered)
+	search := strings.ToLower(strings.TrimSpace(a.testSelection.search))
+	
+	a.testSelection.tests = []*database.Test{}
+	for _, test := range a.testSelection.allTests {
+		if hideMastered && a.testSelection.mastered[test.ID] != a.testSelection.showMastered {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(test.Name), search) {
+			continue
+		}
+		if a.testSelection.tagFilter != "" && !a.testSelection.tagged[test.ID] {
+			continue
+		}
+		a.testSelection.tests = append(a.testSelection.tests, test)
+	}
+	
+	if a.testSelection.cursor >= len(a.testSelection.tests) {
+		a.testSelection.cursor = 0
+		if len(a.testSelection.tests) > 0 {
+			a.testSelection.cursor = len(a.testSelection.tests) - 1
+		}
+	}
+}
+
 // deleteSelectedTest deletes the currently selected test
 func (a *App) deleteSelectedTest() (tea.Model, tea.Cmd) {
 	if len(a.testSelection.tests) == 0 {
@@ -195,12 +824,366 @@ func (a *App) deleteSelectedTest() (tea.Model, tea.Cmd) {
 	}
 	
 	// Remove from local list
-	a.testSelection.tests = append(a.testSelection.tests[:a.testSelection.cursor], a.testSelection.tests[a.testSelection.cursor+1:]...)
+	for i, test := range a.testSelection.allTests {
+		if test.ID == selectedTest.ID {
+			a.testSelection.allTests = append(a.testSelection.allTests[:i], a.testSelection.allTests[i+1:]...)
+			break
+		}
+	}
+	delete(a.testSelection.mastered, selectedTest.ID)
+	delete(a.testSelection.questionCounts, selectedTest.ID)
+	
+	// Rebuild the listed tests, adjusting the cursor if necessary
+	a.applyTestFilter()
+	a.testSelection.successMsg = fmt.Sprintf("Deleted '%s'", selectedTest.Name)
+	
+	return a, nil
+}
+
+// handleTestRenameInput handles input mode for renaming or copying the selected test
+func (a *App) handleTestRenameInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if a.testSelection.inputMode == "count" {
+			return a.startQuestionSubset(a.testSelection.input)
+		}
+		
+		// Confirm rename; tags may be left empty to remove them all
+		var err error
+		if a.testSelection.inputMode == "tags" {
+			a.setSelectedTestTags(a.testSelection.input)
+		} else if err = a.validateInput(a.testSelection.input, 1); err != nil {
+			a.testSelection.errorMsg = err.Error()
+		} else if a.testSelection.inputMode == "clone" {
+			err = a.cloneSelectedTest(strings.TrimSpace(a.testSelection.input))
+		} else {
+			err = a.renameSelectedTest(strings.TrimSpace(a.testSelection.input))
+		}
+		if errors.Is(err, database.ErrDuplicateName) {
+			// Keep the prompt open to pick a different name
+			a.testSelection.errorMsg = "A test with that name already exists, please enter a different name"
+			return a, nil
+		}
+		a.testSelection.inputMode = ""
+		a.testSelection.input = ""
+	case "esc":
+		// Cancel rename
+		a.testSelection.inputMode = ""
+		a.testSelection.input = ""
+		a.testSelection.pending = nil
+	case "backspace":
+		// Remove last character
+		if len(a.testSelection.input) > 0 {
+			a.testSelection.input = a.testSelection.input[:len(a.testSelection.input)-1]
+		}
+	default:
+		// Add character to input
+		if len(msg.String()) == 1 {
+			a.testSelection.input += msg.String()
+		}
+	}
+	return a, nil
+}
+
+// renameSelectedTest renames the selected test
+func (a *App) renameSelectedTest(name string) error {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	updated, err := a.db.UpdateTest(selectedTest.ID, name, selectedTest.Description)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to rename test: %v", err)
+		return err
+	}
+	
+	// Tests are shared between the full and filtered lists, so this updates both
+	*selectedTest = *updated
+	a.testSelection.successMsg = fmt.Sprintf("Renamed test to '%s'", updated.Name)
+	return nil
+}
+
+// cloneSelectedTest copies the selected test and its questions under a new
+// name and moves the cursor to the copy
+func (a *App) cloneSelectedTest(name string) error {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	clone, err := a.db.CloneTest(selectedTest.ID, name)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to copy test: %v", err)
+		return err
+	}
+	
+	a.loadTests()
+	for i, test := range a.testSelection.tests {
+		if test.ID == clone.ID {
+			a.testSelection.cursor = i
+		}
+	}
+	a.testSelection.successMsg = fmt.Sprintf("Copied '%s' to '%s'", selectedTest.Name, clone.Name)
+	return nil
+}
+
+// setSelectedTestTags replaces the tags of the selected test with the
+// comma-separated tags in input
+func (a *App) setSelectedTestTags(input string) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	
+	wanted := make(map[string]bool)
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			wanted[tag] = true
+		}
+	}
+	
+	for _, tag := range a.testSelection.tags[selectedTest.ID] {
+		if wanted[tag] {
+			delete(wanted, tag)
+			continue
+		}
+		if err := a.db.RemoveTag(selectedTest.ID, tag); err != nil {
+			a.testSelection.errorMsg = err.Error()
+			return
+		}
+	}
+	for tag := range wanted {
+		if err := a.db.AddTag(selectedTest.ID, tag); err != nil {
+			a.testSelection.errorMsg = err.Error()
+			return
+		}
+	}
+	
+	tags, err := a.db.GetTestTags()
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load tags: %v", err)
+		return
+	}
+	a.testSelection.tags = tags
+	a.loadTaggedTests()
+	a.applyTestFilter()
+	a.testSelection.successMsg = fmt.Sprintf("Updated the tags of '%s'", selectedTest.Name)
+}
+
+// cycleTagFilter limits the list to the next tag in alphabetical order, going
+// back to every test after the last one
+func (a *App) cycleTagFilter() {
+	tags, err := a.db.GetAllTags()
+	if err != nil {
+		a.testSelection.errorMsg = err.Error()
+		return
+	}
+	if len(tags) == 0 && a.testSelection.tagFilter == "" {
+		a.testSelection.errorMsg = "No tests are tagged yet. Press 't' to tag the selected test."
+		return
+	}
 	
-	// Adjust cursor if necessary
-	if a.testSelection.cursor >= len(a.testSelection.tests) && len(a.testSelection.tests) > 0 {
-		a.testSelection.cursor = len(a.testSelection.tests) - 1
+	next := ""
+	for _, tag := range tags {
+		if tag > a.testSelection.tagFilter {
+			next = tag
+			break
+		}
+	}
+	a.testSelection.tagFilter = next
+	a.loadTaggedTests()
+	a.applyTestSearch()
+}
+
+// loadTaggedTests looks up which tests have the tag the list is limited to
+func (a *App) loadTaggedTests() {
+	a.testSelection.tagged = nil
+	if a.testSelection.tagFilter == "" {
+		return
 	}
 	
+	tests, err := a.db.GetTestsByTag(a.testSelection.tagFilter)
+	if err != nil {
+		a.testSelection.errorMsg = err.Error()
+		return
+	}
+	a.testSelection.tagged = make(map[int]bool, len(tests))
+	for _, test := range tests {
+		a.testSelection.tagged[test.ID] = true
+	}
+}
+
+// requestRegenerateTest asks for confirmation before regenerating the selected test
+func (a *App) requestRegenerateTest() (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	
+	if selectedTest.SourcePath == "" {
+		a.testSelection.errorMsg = "This test was not generated from a PDF"
+		return a, nil
+	}
+	
+	if _, err := os.Stat(selectedTest.SourcePath); err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Source PDF not found: %s", selectedTest.SourcePath)
+		return a, nil
+	}
+	
+	a.testSelection.confirmAction = "regenerate"
 	return a, nil
-}
\ No newline at end of file
+}
+
+// handleTestSelectionConfirm handles the y/n answer to a pending confirmation
+func (a *App) handleTestSelectionConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action := a.testSelection.confirmAction
+	
+	// Both answers start the test; only esc cancels
+	if action == "resume" {
+		switch msg.String() {
+		case "y", "n":
+			a.testSelection.confirmAction = ""
+			return a.answerResumePrompt(msg.String() == "y")
+		case "esc":
+			a.testSelection.confirmAction = ""
+			a.testSelection.resume = nil
+		}
+		return a, nil
+	}
+	
+	switch msg.String() {
+	case "y":
+		a.testSelection.confirmAction = ""
+		switch action {
+		case "regenerate":
+			return a.startRegenerateTest()
+		case "reset_results":
+			return a.resetSelectedTestResults()
+		case "similar":
+			return a.generateSimilarTest()
+		case "delete":
+			return a.deleteSelectedTest()
+		}
+	case "n", "esc":
+		a.testSelection.confirmAction = ""
+	}
+	return a, nil
+}
+
+// startRegenerateTest opens the PDF flow for the selected test's source file
+func (a *App) startRegenerateTest() (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	
+	a.pdfProcess = NewPDFProcessModel()
+	a.pdfProcess.selectedFile = selectedTest.SourcePath
+	a.pdfProcess.regenerateTestID = selectedTest.ID
+	a.pdfProcess.testName = selectedTest.Name
+	a.pdfProcess.testDesc = selectedTest.Description
+	a.pdfProcess.timeLimit = selectedTest.TimeLimit / 60
+	a.loadPDFInfo()
+	a.currentView = PDFProcessView
+	
+	return a, nil
+}
+
+// requestResetResults asks for confirmation before wiping the selected test's results
+func (a *App) requestResetResults() (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	
+	results, err := a.db.GetTestResults(selectedTest.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load results: %v", err)
+		return a, nil
+	}
+	
+	if len(results) == 0 {
+		a.testSelection.errorMsg = "This test has no results to reset"
+		return a, nil
+	}
+	
+	a.testSelection.confirmAction = "reset_results"
+	a.testSelection.confirmCount = len(results)
+	return a, nil
+}
+
+// resetSelectedTestResults deletes all results of the selected test
+func (a *App) resetSelectedTestResults() (tea.Model, tea.Cmd) {
+	selectedTest := a.testSelection.tests[a.testSelection.cursor]
+	
+	deleted, err := a.db.DeleteResultsForTest(selectedTest.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to reset results: %v", err)
+		return a, nil
+	}
+	
+	// Refresh the results list so it no longer shows the deleted attempts
+	a.loadTestResults()
+	
+	// Without results the test is no longer mastered, so it moves back to the active list
+	delete(a.testSelection.mastered, selectedTest.ID)
+	a.applyTestFilter()
+	
+	a.testSelection.successMsg = fmt.Sprintf("Deleted %d result(s) for '%s'", deleted, selectedTest.Name)
+	return a, nil
+}
+
+// generateSimilarTest generates a new test with fresh questions on the same
+// topics as the selected test, using its questions as context for ChatGPT
+func (a *App) generateSimilarTest() (tea.Model, tea.Cmd) {
+	return a, a.startSimilarGeneration(a.testSelection.tests[a.testSelection.cursor])
+}
+
+// startSimilarGeneration starts generating as many questions as test has, of
+// the same types, in the background and switches to the generation view
+func (a *App) startSimilarGeneration(test *database.Test) tea.Cmd {
+	questions, err := a.db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		a.testSelection.errorMsg = fmt.Sprintf("Failed to load questions: %v", err)
+		return nil
+	}
+	
+	if len(questions) == 0 {
+		a.testSelection.errorMsg = "This test has no questions"
+		return nil
+	}
+	
+	// Ask for as many questions as the original, of the same types
+	var existing []string
+	var questionTypes []string
+	seenTypes := make(map[string]bool)
+	for _, q := range questions {
+		existing = append(existing, q.QuestionText)
+		if !seenTypes[q.QuestionType] {
+			seenTypes[q.QuestionType] = true
+			questionTypes = append(questionTypes, q.QuestionType)
+		}
+	}
+	
+	ctx, cancel := a.generationContext()
+	stream := chatgpt.StreamGenerated(ctx, func(ctx context.Context) ([]*chatgpt.GeneratedQuestion, *chatgpt.Usage, error) {
+		return a.chatGPT.GenerateSimilarQuestions(ctx, existing, len(questions), questionTypes)
+	})
+	a.questionGen.similarTo = test
+	return a.beginGeneration(stream, cancel, len(questions))
+}
+
+// saveSimilarQuestions stores questions generated from the topics of a test
+// in a new test named after it
+func (a *App) saveSimilarQuestions(generatedQuestions []*chatgpt.GeneratedQuestion) error {
+	source := a.questionGen.similarTo
+	if len(generatedQuestions) == 0 {
+		return fmt.Errorf("ChatGPT only returned questions already in '%s'", source.Name)
+	}
+	
+	if err := normalizeGeneratedAnswers(generatedQuestions); err != nil {
+		return err
+	}
+	
+	name, err := a.freeTestName(source.Name + " (similar)")
+	if err != nil {
+		return err
+	}
+	description := fmt.Sprintf("Generated from the topics of '%s'", source.Name)
+	test, err := a.db.CreateTest(name, description, "", source.TimeLimit)
+	if err != nil {
+		return fmt.Errorf("failed to create test: %w", err)
+	}
+	
+	for _, gq := range generatedQuestions {
+		_, err := a.db.CreateQuestion(test.ID, gq.Question, gq.Type, gq.CorrectAnswer, gq.Explanation, gq.Options, gq.SourceRef, gq.Difficulty)
+		if err != nil {
+			return fmt.Errorf("failed to save question: %w", err)
+		}
+	}
+	
+	a.loadTests()
+	a.testSelection.successMsg = fmt.Sprintf("Created '%s' with %d questions", name, len(generatedQuestions))
+	return nil
+}
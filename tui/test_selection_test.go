@@ -0,0 +1,724 @@
+package tui
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"pdf-test-generator/chatgpt"
+	"pdf-test-generator/database"
+)
+
+// openTestList opens the test list for purpose with the tests saved so far
+func openTestList(t *testing.T, a *App, purpose string) {
+	t.Helper()
+	a.openTestSelection(purpose)
+	a.currentView = TestSelectionView
+	if a.testSelection.errorMsg != "" {
+		t.Fatalf("opening the test list: %s", a.testSelection.errorMsg)
+	}
+}
+
+func TestClearingTagsWithEmptyInput(t *testing.T) {
+	a := newTestApp(t)
+	test := createTest(t, a, "Biology", shortAnswers("Q1")...)
+	if err := a.db.AddTag(test.ID, "science"); err != nil {
+		t.Fatal(err)
+	}
+	openTestList(t, a, "view_tests")
+
+	press(a, "t")
+	if a.testSelection.inputMode != "tags" || a.testSelection.input != "science" {
+		t.Fatalf("tag prompt %q with %q, want tags with science", a.testSelection.inputMode, a.testSelection.input)
+	}
+	for range "science" {
+		press(a, "backspace")
+	}
+	press(a, "enter")
+
+	if a.testSelection.inputMode != "" {
+		t.Errorf("prompt still open: %s", a.testSelection.errorMsg)
+	}
+	tags, err := a.db.GetTestTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags[test.ID]) != 0 {
+		t.Errorf("tags after clearing = %q, want none", tags[test.ID])
+	}
+}
+
+func TestTestListPages(t *testing.T) {
+	a := newTestApp(t)
+	for i := 1; i <= 30; i++ {
+		createTest(t, a, fmt.Sprintf("Test %02d", i), shortAnswers("Q1")...)
+	}
+	a.width, a.height = 120, 40
+
+	for _, purpose := range []string{"take_test", "view_tests", "study"} {
+		openTestList(t, a, purpose)
+		perPage := a.testsPerPage()
+		if perPage >= 30 {
+			t.Fatalf("%s: %d tests a page, want the list to need several pages", purpose, perPage)
+		}
+
+		press(a, "down")
+		press(a, "pgdown")
+		if want := perPage + 1; a.testSelection.cursor != want {
+			t.Errorf("%s: cursor after PgDn = %d, want %d", purpose, a.testSelection.cursor, want)
+		}
+		view := a.View()
+		if lines := lineCount(view); lines > a.height {
+			t.Errorf("%s: view is %d lines on a %d line terminal", purpose, lines, a.height)
+		}
+		selected := a.testSelection.tests[a.testSelection.cursor].Name
+		if !strings.Contains(view, "> "+selectedStyle.Render(selected)) || !strings.Contains(view, "Page 2 of") {
+			t.Errorf("%s: page 2 does not show %s selected:\n%s", purpose, selected, view)
+		}
+
+		press(a, "pgup")
+		press(a, "pgup")
+		last := (len(a.testSelection.tests) - 1) / perPage * perPage
+		if want := min(last+1, len(a.testSelection.tests)-1); a.testSelection.cursor != want {
+			t.Errorf("%s: cursor after wrapping back = %d, want %d", purpose, a.testSelection.cursor, want)
+		}
+	}
+}
+
+func TestSampleQuestionsDistinct(t *testing.T) {
+	a := newTestApp(t)
+	questions := make([]*database.Question, 10)
+	for i := range questions {
+		questions[i] = &database.Question{ID: i + 1}
+	}
+
+	for k := 1; k <= len(questions); k++ {
+		sample := a.sampleQuestions(questions, k)
+		if len(sample) != k {
+			t.Fatalf("sample of %d has %d questions", k, len(sample))
+		}
+		seen := make(map[int]bool)
+		for i, q := range sample {
+			if seen[q.ID] {
+				t.Errorf("sample of %d repeats question %d", k, q.ID)
+			}
+			if i > 0 && q.ID < sample[i-1].ID {
+				t.Errorf("sample of %d is out of test order: %d after %d", k, q.ID, sample[i-1].ID)
+			}
+			seen[q.ID] = true
+		}
+	}
+}
+
+func TestQuestionSubsetSavesResult(t *testing.T) {
+	a := newTestApp(t)
+	test := createTest(t, a, "Biology", shortAnswers("Q1", "Q2", "Q3", "Q4")...)
+	openTestList(t, a, "take_test")
+
+	press(a, "enter")
+	if a.testSelection.inputMode != "count" {
+		t.Fatalf("input mode = %q, want the question count prompt", a.testSelection.inputMode)
+	}
+	for range a.testSelection.input {
+		press(a, "backspace")
+	}
+	typeText(a, "2")
+	press(a, "enter")
+
+	if a.currentView != TestTakingView || a.currentTest.ID != test.ID || len(a.currentQuestions) != 2 {
+		t.Fatalf("started %+v with %d questions, want 2 questions of test %d", a.currentTest, len(a.currentQuestions), test.ID)
+	}
+	a.userAnswers[a.currentQuestions[0].ID] = "answer"
+	a.userAnswers[a.currentQuestions[1].ID] = "wrong"
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+
+	results, err := a.db.GetTestResults(test.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("%d results saved for the test, want 1", len(results))
+	}
+	if results[0].TotalQuestions != 2 || results[0].CorrectAnswers != 1 {
+		t.Errorf("result %d of %d, want 1 of 2", results[0].CorrectAnswers, results[0].TotalQuestions)
+	}
+	answers, err := a.db.GetTestResultAnswers(results[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 2 {
+		t.Errorf("%d answers saved, want 2", len(answers))
+	}
+}
+
+// questionIDs lists the IDs of questions in order
+func questionIDs(questions []*database.Question) []int {
+	ids := make([]int, len(questions))
+	for i, q := range questions {
+		ids[i] = q.ID
+	}
+	return ids
+}
+
+func TestShuffledQuestionOrder(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3", "Q4", "Q5", "Q6")
+	test := createTest(t, a, "Biology", questions...)
+	openTestList(t, a, "take_test")
+
+	press(a, "o")
+	press(a, "enter")
+	press(a, "enter") // Take every question
+	if a.currentView != TestTakingView {
+		t.Fatalf("view %s, want the test started", a.currentView)
+	}
+
+	original := fmt.Sprint(questionIDs(questions))
+	shuffled := questionIDs(a.currentQuestions)
+	if fmt.Sprint(shuffled) == original {
+		t.Errorf("questions not shuffled: %v", shuffled)
+	}
+	sorted := append([]int(nil), shuffled...)
+	sort.Ints(sorted)
+	if fmt.Sprint(sorted) != original {
+		t.Fatalf("shuffled IDs %v are not the test's %s", shuffled, original)
+	}
+
+	// The same seed gives the same order
+	a.rng = rand.New(rand.NewSource(1))
+	again := questionIDs(a.shuffleQuestions(questions))
+	a.rng = rand.New(rand.NewSource(1))
+	if got := questionIDs(a.shuffleQuestions(questions)); fmt.Sprint(got) != fmt.Sprint(again) {
+		t.Errorf("seeded shuffles differ: %v and %v", got, again)
+	}
+
+	// Answers are keyed by question, so the score is the same in either order
+	for _, q := range questions[:4] {
+		a.userAnswers[q.ID] = "answer"
+	}
+	correct, score := a.calculateScore(a.currentQuestions, a.userAnswers)
+	if wantCorrect, wantScore := a.calculateScore(questions, a.userAnswers); correct != wantCorrect || score != wantScore {
+		t.Errorf("shuffled score %d (%.1f%%), in order %d (%.1f%%)", correct, score, wantCorrect, wantScore)
+	}
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	results, _ := a.db.GetTestResults(test.ID)
+	if len(results) != 1 || results[0].CorrectAnswers != 4 {
+		t.Errorf("saved results %+v, want 4 correct", results)
+	}
+}
+
+// listedTests lists the names of the tests shown in the test list
+func listedTests(a *App) string {
+	var names []string
+	for _, test := range a.testSelection.tests {
+		names = append(names, test.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSearchTestList(t *testing.T) {
+	a := newTestApp(t)
+	for _, name := range []string{"Biology", "Chemistry", "Physics"} {
+		createTest(t, a, name, shortAnswers("Q1")...)
+	}
+	openTestList(t, a, "view_tests")
+	press(a, "down")
+	press(a, "down")
+
+	press(a, "/")
+	typeText(a, "BIO")
+	if got := listedTests(a); got != "Biology" {
+		t.Fatalf("typing BIO listed %s, want Biology", got)
+	}
+	if a.testSelection.cursor != 0 {
+		t.Errorf("cursor %d is outside the one match", a.testSelection.cursor)
+	}
+	press(a, "enter")
+	press(a, "down")
+	if a.testSelection.cursor != 0 || a.testSelection.tests[a.testSelection.cursor].Name != "Biology" {
+		t.Errorf("cursor moved past the filtered list to %d", a.testSelection.cursor)
+	}
+
+	press(a, "/")
+	press(a, "backspace")
+	press(a, "backspace")
+	press(a, "backspace")
+	typeText(a, "zzz")
+	if view := a.viewTestSelection(); len(a.testSelection.tests) != 0 || !strings.Contains(view, "No tests match the search.") {
+		t.Errorf("no match listed %s:\n%s", listedTests(a), view)
+	}
+
+	press(a, "esc")
+	if a.testSelection.search != "" || len(a.testSelection.tests) != 3 || a.currentView != TestSelectionView {
+		t.Errorf("esc left search %q with %s in view %s", a.testSelection.search, listedTests(a), a.currentView)
+	}
+}
+
+func TestSortTestList(t *testing.T) {
+	a := newTestApp(t)
+	tests := []struct {
+		name      string
+		questions int
+		created   string
+	}{
+		{"beta", 2, "2026-01-01 10:00:00"},
+		{"Alpha", 1, "2026-03-01 10:00:00"},
+		{"Gamma", 3, "2026-02-01 10:00:00"},
+	}
+	for _, tt := range tests {
+		texts := make([]string, tt.questions)
+		for i := range texts {
+			texts[i] = fmt.Sprintf("Q%d", i+1)
+		}
+		test := createTest(t, a, tt.name, shortAnswers(texts...)...)
+		if _, err := a.db.Exec(`UPDATE tests SET created_at = ? WHERE id = ?`, tt.created, test.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openTestList(t, a, "view_tests")
+
+	for _, want := range []struct{ header, order string }{
+		{"Sorted by newest first", "Alpha,Gamma,beta"},
+		{"Sorted by name", "Alpha,beta,Gamma"},
+		{"Sorted by question count", "Gamma,beta,Alpha"},
+		{"Sorted by newest first", "Alpha,Gamma,beta"},
+	} {
+		if got := listedTests(a); got != want.order {
+			t.Errorf("%s: listed %s, want %s", want.header, got, want.order)
+		}
+		if view := a.viewTestSelection(); !strings.Contains(view, want.header) {
+			t.Errorf("header does not read %q:\n%s", want.header, view)
+		}
+		press(a, "S")
+	}
+}
+
+func TestTestListRendersWithoutQuerying(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Biology", shortAnswers("Q1", "Q2", "Q3")...)
+	openTestList(t, a, "view_tests")
+
+	// Counts come from loadTests, so rendering must not need the database
+	a.db.Close()
+	if view := a.viewTestSelection(); !strings.Contains(view, "Biology (3 questions)") {
+		t.Errorf("question count missing after the database closed:\n%s", view)
+	}
+}
+
+func BenchmarkViewTestSelection(b *testing.B) {
+	a, err := NewApp(filepath.Join(b.TempDir(), "test.db"), &fakeGenerator{})
+	if err != nil {
+		b.Fatalf("NewApp: %v", err)
+	}
+	defer a.db.Close()
+	for i := range 50 {
+		test, err := a.db.CreateTest(fmt.Sprintf("Test %d", i), "", "", 0)
+		if err != nil {
+			b.Fatalf("CreateTest: %v", err)
+		}
+		for j := range 20 {
+			if _, err := a.db.CreateQuestion(test.ID, fmt.Sprintf("Q%d", j), "short_answer", "answer", "", nil, "", ""); err != nil {
+				b.Fatalf("CreateQuestion: %v", err)
+			}
+		}
+	}
+	a.openTestSelection("view_tests")
+
+	for b.Loop() {
+		a.viewTestSelection()
+	}
+}
+
+func TestDeleteTestNeedsConfirmation(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Biology", shortAnswers("Q1")...)
+	openTestList(t, a, "view_tests")
+
+	for _, cancel := range []string{"n", "esc"} {
+		press(a, "d")
+		if view := a.viewTestSelection(); !strings.Contains(view, "Delete 'Biology'? (y/n)") {
+			t.Fatalf("no confirmation prompt:\n%s", view)
+		}
+		press(a, cancel)
+		if a.testSelection.confirmAction != "" {
+			t.Errorf("%s left the prompt open", cancel)
+		}
+		if tests, err := a.db.GetAllTests(); err != nil || len(tests) != 1 {
+			t.Fatalf("after %s: %d tests stored (err %v), want 1", cancel, len(tests), err)
+		}
+		if a.currentView != TestSelectionView {
+			t.Fatalf("%s left the test list", cancel)
+		}
+	}
+
+	press(a, "d")
+	press(a, "y")
+	if tests, err := a.db.GetAllTests(); err != nil || len(tests) != 0 {
+		t.Errorf("after y: %d tests stored (err %v), want 0", len(tests), err)
+	}
+	if got := listedTests(a); got != "" {
+		t.Errorf("deleted test still listed: %s", got)
+	}
+}
+
+// selectListedTest moves the cursor of the test list onto the test named name
+func selectListedTest(t *testing.T, a *App, name string) {
+	t.Helper()
+	for i, test := range a.testSelection.tests {
+		if test.Name == name {
+			a.testSelection.cursor = i
+			return
+		}
+	}
+	t.Fatalf("%s is not listed: %s", name, listedTests(a))
+}
+
+func TestRegenerateTestFromSource(t *testing.T) {
+	a := newTestApp(t)
+	source, err := filepath.Abs(filepath.Join("testdata", "cells.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test, err := a.db.CreateTest("Cells", "Chapter 1", source, 600)
+	if err != nil {
+		t.Fatalf("CreateTest: %v", err)
+	}
+	saved, err := a.db.CreateQuestion(test.ID, "Old question", "short_answer", "answer", "", nil, "", "")
+	if err != nil {
+		t.Fatalf("CreateQuestion: %v", err)
+	}
+	a.startTest(test, []*database.Question{saved})
+	a.userAnswers[saved.ID] = "answer"
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	createTest(t, a, "Typed in", shortAnswers("Q1")...)
+
+	openTestList(t, a, "view_tests")
+	selectListedTest(t, a, "Cells")
+	press(a, "g")
+	if view := a.viewTestSelection(); !strings.Contains(view, "Regenerate 'Cells' from "+source+"?") {
+		t.Fatalf("no confirmation prompt:\n%s", view)
+	}
+	press(a, "n")
+	if a.testSelection.confirmAction != "" || a.currentView != TestSelectionView {
+		t.Fatalf("'n' left action %q in view %s", a.testSelection.confirmAction, a.currentView)
+	}
+
+	press(a, "g")
+	press(a, "y")
+	if a.currentView != PDFProcessView || a.pdfProcess.regenerateTestID != test.ID || a.pdfProcess.selectedFile != source {
+		t.Fatalf("regenerating opened view %s for test %d from %s", a.currentView, a.pdfProcess.regenerateTestID, a.pdfProcess.selectedFile)
+	}
+	if a.pdfProcess.testName != "Cells" || a.pdfProcess.timeLimit != 10 {
+		t.Errorf("settings carried over as %q with %d minutes", a.pdfProcess.testName, a.pdfProcess.timeLimit)
+	}
+
+	// An attempt of the old questions is dropped with them
+	if err := a.db.SaveTestAttempt(test.ID, 0, []int{saved.ID}, map[int]string{}, time.Now()); err != nil {
+		t.Fatalf("SaveTestAttempt: %v", err)
+	}
+	a.testSelection.unfinished[test.ID] = true
+
+	a.chatGPT = &fakeGenerator{questions: []*chatgpt.GeneratedQuestion{
+		{Question: "New question 1", Type: "short_answer", CorrectAnswer: "answer"},
+		{Question: "New question 2", Type: "short_answer", CorrectAnswer: "answer"},
+	}}
+	a.pdfProcess.extractedText = "Cells are the basic unit of life."
+	_, cmd := a.generateQuestions()
+	runCmd(t, a, cmd)
+	if a.questionGen.status != "completed" {
+		t.Fatalf("status = %q (%s), want completed", a.questionGen.status, a.questionGen.errorMsg)
+	}
+
+	if a.testSelection.unfinished[test.ID] {
+		t.Error("regenerated test still listed as unfinished")
+	}
+	if attempt, err := a.db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("attempt after regenerating = %+v, %v; want it deleted", attempt, err)
+	}
+
+	tests, err := a.db.GetAllTests()
+	if err != nil || len(tests) != 2 {
+		t.Fatalf("%d tests after regenerating (err %v), want the same 2", len(tests), err)
+	}
+	replaced, err := a.db.GetQuestionsByTestID(test.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, q := range replaced {
+		texts = append(texts, q.QuestionText)
+	}
+	if got := strings.Join(texts, ","); got != "New question 1,New question 2" {
+		t.Errorf("questions after regenerating: %s", got)
+	}
+	if results, err := a.db.GetTestResults(test.ID); err != nil || len(results) != 1 {
+		t.Errorf("%d results after regenerating (err %v), want the history kept", len(results), err)
+	}
+
+	// A test without a source file cannot be regenerated
+	openTestList(t, a, "view_tests")
+	selectListedTest(t, a, "Typed in")
+	press(a, "g")
+	if a.testSelection.confirmAction != "" || a.testSelection.errorMsg != "This test was not generated from a PDF" {
+		t.Errorf("regenerating a typed-in test: action %q, error %q", a.testSelection.confirmAction, a.testSelection.errorMsg)
+	}
+}
+
+func TestResetTestResults(t *testing.T) {
+	a := newTestApp(t)
+	finishTest(t, a, "Biology", 2, 1)
+	finishTest(t, a, "Chemistry", 1, 1)
+	// Take Chemistry a second time
+	chemistry := a.currentTest
+	a.startTest(chemistry, a.currentQuestions)
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	openTestList(t, a, "view_tests")
+	selectListedTest(t, a, "Chemistry")
+
+	press(a, "w")
+	if view := a.viewTestSelection(); !strings.Contains(view, "Delete all 2 result(s) for 'Chemistry'?") {
+		t.Fatalf("no confirmation with the count:\n%s", view)
+	}
+	press(a, "n")
+	if results, _ := a.db.GetTestResults(chemistry.ID); len(results) != 2 {
+		t.Fatalf("'n' left %d results, want 2", len(results))
+	}
+
+	press(a, "w")
+	press(a, "y")
+	if results, _ := a.db.GetTestResults(chemistry.ID); len(results) != 0 {
+		t.Errorf("'y' left %d results", len(results))
+	}
+	if a.testSelection.successMsg != "Deleted 2 result(s) for 'Chemistry'" {
+		t.Errorf("summary = %q", a.testSelection.successMsg)
+	}
+	if count, _ := a.db.CountQuestions(chemistry.ID); count != 1 {
+		t.Errorf("Chemistry has %d questions after the reset, want 1", count)
+	}
+	if len(a.testResults.results) != 1 || a.testResults.results[0].TestName != "Biology" {
+		t.Errorf("results list not refreshed: %+v", a.testResults.results)
+	}
+
+	press(a, "w")
+	if a.testSelection.confirmAction != "" || a.testSelection.errorMsg != "This test has no results to reset" {
+		t.Errorf("resetting again: action %q, error %q", a.testSelection.confirmAction, a.testSelection.errorMsg)
+	}
+}
+
+func TestHideMasteredTests(t *testing.T) {
+	a := newTestApp(t)
+	attempts := map[string][]bool{
+		"Biology":   {false, true, true, true},
+		"Chemistry": {true, true},
+		"Physics":   {true, true, true, false},
+	}
+	day := 0
+	for _, name := range []string{"Biology", "Chemistry", "Physics"} {
+		questions := shortAnswers("Q1", "Q2")
+		test := createTest(t, a, name, questions...)
+		for _, perfect := range attempts[name] {
+			a.startTest(test, questions)
+			a.userAnswers[questions[0].ID] = "answer"
+			a.userAnswers[questions[1].ID] = "wrong"
+			if perfect {
+				a.userAnswers[questions[1].ID] = "answer"
+			}
+			if err := a.recordTestResults(); err != nil {
+				t.Fatalf("recordTestResults: %v", err)
+			}
+			day++
+			if _, err := a.db.Exec(`UPDATE test_results SET completed_at = ? WHERE id = (SELECT MAX(id) FROM test_results)`,
+				time.Date(2026, 3, day, 10, 0, 0, 0, time.UTC)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Nothing is hidden until the setting is on
+	openTestList(t, a, "take_test")
+	if got := listedTests(a); got != "Biology,Chemistry,Physics" {
+		t.Errorf("listed %q with the setting off, want every test", got)
+	}
+
+	a.saveSetting(settingHideMastered, "true")
+	openTestList(t, a, "take_test")
+	if got := listedTests(a); got != "Chemistry,Physics" {
+		t.Errorf("listed %q, want Biology hidden as mastered", got)
+	}
+	if view := a.viewTestSelection(); !strings.Contains(view, "Hiding 1 mastered test(s)") {
+		t.Errorf("list does not say a test is hidden:\n%s", view)
+	}
+
+	press(a, "tab")
+	if got := listedTests(a); got != "Biology" {
+		t.Errorf("mastered filter lists %q, want Biology", got)
+	}
+	press(a, "tab")
+	if got := listedTests(a); got != "Chemistry,Physics" {
+		t.Errorf("tab back lists %q, want the active tests", got)
+	}
+}
+
+func TestRenameTest(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Biolgy", shortAnswers("Q1")...)
+	createTest(t, a, "Chemistry", shortAnswers("Q1")...)
+	openTestList(t, a, "view_tests")
+	selectListedTest(t, a, "Biolgy")
+
+	press(a, "e")
+	if a.testSelection.inputMode != "rename" || a.testSelection.input != "Biolgy" {
+		t.Fatalf("'e' opened input %q with %q, want rename with the current name", a.testSelection.inputMode, a.testSelection.input)
+	}
+
+	// A name taken by another test keeps the prompt open
+	for range len("Biolgy") {
+		press(a, "backspace")
+	}
+	typeText(a, "chemistry")
+	press(a, "enter")
+	if a.testSelection.inputMode != "rename" {
+		t.Fatalf("duplicate name closed the prompt")
+	}
+	if view := a.viewTestSelection(); !strings.Contains(view, "already exists") {
+		t.Errorf("duplicate name not explained:\n%s", view)
+	}
+
+	for range len("chemistry") {
+		press(a, "backspace")
+	}
+	typeText(a, "Biology")
+	press(a, "enter")
+	if a.testSelection.inputMode != "" || a.testSelection.successMsg != "Renamed test to 'Biology'" {
+		t.Fatalf("rename not saved: mode %q, error %q", a.testSelection.inputMode, a.testSelection.errorMsg)
+	}
+	if a.testSelection.tests[a.testSelection.cursor].Name != "Biology" {
+		t.Errorf("list shows %q after renaming", a.testSelection.tests[a.testSelection.cursor].Name)
+	}
+
+	openTestList(t, a, "view_tests")
+	if got := listedTests(a); !strings.Contains(got, "Biology") || strings.Contains(got, "Biolgy") {
+		t.Errorf("reloaded list %q does not have the new name", got)
+	}
+}
+
+func TestDifficultyDrill(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Easy 1", "Hard 1", "Hard 2")
+	for i, level := range []string{"easy", "hard", "hard"} {
+		questions[i].Difficulty = level
+	}
+	createTest(t, a, "Biology", questions...)
+	createTest(t, a, "Chemistry", shortAnswers("Unrated")...)
+	openTestList(t, a, "take_test")
+	selectListedTest(t, a, "Biology")
+
+	press(a, "v")
+	press(a, "v")
+	press(a, "v")
+	if a.testSelection.difficulty != "hard" {
+		t.Fatalf("difficulty = %q after three presses of 'v', want hard", a.testSelection.difficulty)
+	}
+	if view := a.viewTestSelection(); !strings.Contains(view, "Difficulty: hard questions only") {
+		t.Errorf("list does not show the drill difficulty:\n%s", view)
+	}
+
+	press(a, "enter")
+	if a.currentView != TestTakingView {
+		t.Fatalf("drill not started: %s", a.testSelection.errorMsg)
+	}
+	if a.currentTest.ID != 0 || a.currentTest.Name != "Biology (hard only)" {
+		t.Errorf("drill is %+v, want an unsaved Biology (hard only)", a.currentTest)
+	}
+	if got, want := fmt.Sprint(questionIDs(a.currentQuestions)), fmt.Sprint(questionIDs(questions[1:])); got != want {
+		t.Errorf("drill questions %s, want the two hard ones %s", got, want)
+	}
+
+	// A test without questions of the level stays on the list with an error
+	openTestList(t, a, "take_test")
+	a.testSelection.difficulty = "hard"
+	selectListedTest(t, a, "Chemistry")
+	press(a, "enter")
+	if a.currentView != TestSelectionView {
+		t.Errorf("drill of a test with no hard questions moved to view %s", a.currentView)
+	}
+	if view := a.viewTestSelection(); !strings.Contains(view, "This test has no hard questions") {
+		t.Errorf("missing questions not explained:\n%s", view)
+	}
+}
+
+func TestCloneTestFromList(t *testing.T) {
+	a := newTestApp(t)
+	createTest(t, a, "Biology", shortAnswers("Q1", "Q2")...)
+	openTestList(t, a, "view_tests")
+	selectListedTest(t, a, "Biology")
+
+	press(a, "c")
+	if a.testSelection.inputMode != "clone" || a.testSelection.input != "Copy of Biology" {
+		t.Fatalf("'c' opened input %q with %q, want clone with a suggested name", a.testSelection.inputMode, a.testSelection.input)
+	}
+	press(a, "enter")
+	if a.testSelection.inputMode != "" {
+		t.Fatalf("copy not made: %s", a.testSelection.errorMsg)
+	}
+
+	selected := a.testSelection.tests[a.testSelection.cursor]
+	if selected.Name != "Copy of Biology" {
+		t.Errorf("cursor on %q after copying, want the copy", selected.Name)
+	}
+	if got := listedTests(a); !strings.Contains(got, "Biology") || !strings.Contains(got, "Copy of Biology") {
+		t.Errorf("listed %q, want the original and the copy", got)
+	}
+	if count, err := a.db.CountQuestions(selected.ID); err != nil || count != 2 {
+		t.Errorf("copy has %d questions (%v), want 2", count, err)
+	}
+}
+
+func TestResetMasteredTestReturnsToActiveList(t *testing.T) {
+	a := newTestApp(t)
+	a.saveSetting(settingHideMastered, "true")
+	questions := shortAnswers("Q1")
+	test := createTest(t, a, "Biology", questions...)
+	createTest(t, a, "Chemistry", shortAnswers("Q1")...)
+	for range masteredAttempts {
+		a.startTest(test, questions)
+		a.userAnswers[questions[0].ID] = "answer"
+		if err := a.recordTestResults(); err != nil {
+			t.Fatalf("recordTestResults: %v", err)
+		}
+	}
+
+	openTestList(t, a, "view_tests")
+	press(a, "tab")
+	if got := listedTests(a); got != "Biology" {
+		t.Fatalf("mastered filter lists %q, want Biology", got)
+	}
+	press(a, "w")
+	press(a, "y")
+	if results, _ := a.db.GetTestResults(test.ID); len(results) != 0 {
+		t.Fatalf("reset left %d results", len(results))
+	}
+
+	if a.testSelection.mastered[test.ID] {
+		t.Error("Biology still marked mastered after resetting its results")
+	}
+	if got := listedTests(a); got != "" {
+		t.Errorf("mastered filter lists %q after the reset, want nothing", got)
+	}
+	press(a, "tab")
+	if got := listedTests(a); got != "Biology,Chemistry" {
+		t.Errorf("active list is %q, want Biology back with Chemistry", got)
+	}
+}
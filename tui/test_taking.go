@@ -2,11 +2,14 @@ package tui
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"pdf-test-generator/database"
 
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -18,10 +21,34 @@ type TestTakingModel struct {
 	showResult      bool
 	resultMsg       string
 	cursor          int // For multiple choice options
+	selections      map[int]bool // Option indexes checked on a multi select question
 	errorMsg        string
+	focusMode       bool // Hide header, footer and timer while answering
+	confirmQuit     bool // Esc was pressed mid-test; waiting for y/n to abandon it
+	// Time spent on each question by ID, over every visit, and when the
+	// current question was shown
+	timeSpent map[int]time.Duration
+	shownAt   time.Time
+	// Grid of question numbers for jumping straight to a question
+	navigator       bool
+	navigatorCursor int
+	// Questions flagged to revisit by ID, and whether submitting is waiting
+	// for confirmation because some are still flagged
+	marked        map[int]bool
+	confirmSubmit bool
+	lastAutosave    time.Time
+	// Option order of each choice question in this attempt, by question ID:
+	// optionOrder[id][i] is the stored index of the option shown at position i
+	optionOrder map[int][]int
+	// Scrollable body of the question or answer under review; scrollKey
+	// names the content it shows so a new question starts at the top
+	viewport  viewport.Model
+	scrollKey string
 	// Answer review functionality
 	reviewMode     bool
 	reviewQuestion int
+	showHistory    bool
+	history        []*database.QuestionAnswer
 }
 
 // NewTestTakingModel creates a new test taking model
@@ -29,6 +56,139 @@ func NewTestTakingModel() *TestTakingModel {
 	return &TestTakingModel{}
 }
 
+// startTest begins a new attempt at the given questions. A test with ID 0 is
+// a practice session whose results are not saved. The returned command drives
+// the ticker used for autosaving and the time limit countdown.
+func (a *App) startTest(test *database.Test, questions []*database.Question) tea.Cmd {
+	a.currentTest = test
+	a.userAnswers = make(map[int]string)
+	a.testStartTime = time.Now()
+	a.testTaking = NewTestTakingModel()
+	a.testTaking.timeSpent = make(map[int]time.Duration)
+	a.testTaking.marked = make(map[int]bool)
+	a.testTaking.shownAt = a.testStartTime
+	a.currentView = TestTakingView
+	
+	// Shuffle the options of choice questions so the answer is not always in the same slot
+	a.testTaking.optionOrder = make(map[int][]int)
+	a.currentQuestions = make([]*database.Question, len(questions))
+	for i, q := range questions {
+		if q.QuestionType == "multiple_choice" || q.QuestionType == "multi_select" {
+			a.currentQuestions[i], a.testTaking.optionOrder[q.ID] = a.shuffleOptions(q)
+		} else {
+			a.currentQuestions[i] = q
+		}
+	}
+
+	if _, timed := a.timeRemaining(); !timed && !a.autosaveEnabled() {
+		return nil
+	}
+	return testTick(a.testStartTime)
+}
+
+// shuffleOptions returns a copy of a choice question with its options in a
+// random order and its correct answer remapped to the new letters, along with
+// the stored index of the option shown at each position
+func (a *App) shuffleOptions(q *database.Question) (*database.Question, []int) {
+	order := a.rng.Perm(len(q.Options))
+	position := make([]int, len(order))
+	
+	shuffled := *q
+	shuffled.Options = make([]string, len(q.Options))
+	for i, index := range order {
+		shuffled.Options[i] = q.Options[index]
+		position[index] = i
+	}
+	correct := q.CorrectAnswer
+	if q.QuestionType == "multiple_choice" {
+		correct = choiceLetter(q.Options, correct)
+	}
+	shuffled.CorrectAnswer = mapLetters(correct, position)
+	
+	return &shuffled, order
+}
+
+// storedAnswer converts an answer given in this attempt's option order back
+// to the letters of the options as they are stored
+func (a *App) storedAnswer(questionID int, answer string) string {
+	order, ok := a.testTaking.optionOrder[questionID]
+	if !ok {
+		return answer
+	}
+	return mapLetters(answer, order)
+}
+
+// storedAnswers returns all answers of this attempt in stored option order
+func (a *App) storedAnswers() map[int]string {
+	answers := make(map[int]string, len(a.userAnswers))
+	for id, answer := range a.userAnswers {
+		answers[id] = a.storedAnswer(id, answer)
+	}
+	return answers
+}
+
+// mapLetters rewrites the option letters of an answer, moving the option at
+// index i to index mapping[i]. Answers that are not option letters, such as
+// option text, are returned unchanged.
+func mapLetters(answer string, mapping []int) string {
+	var letters []string
+	for letter := range letterSet(answer) {
+		if len(letter) != 1 || letter[0] < 'A' || int(letter[0]-'A') >= len(mapping) {
+			return answer
+		}
+		letters = append(letters, string(rune('A'+mapping[letter[0]-'A'])))
+	}
+	if len(letters) == 0 {
+		return answer
+	}
+	sort.Strings(letters)
+	return strings.Join(letters, ",")
+}
+
+// maxTimeLimitMinutes caps the time limit that can be set on a test
+const maxTimeLimitMinutes = 600
+
+// parseTimeLimit reads a time limit in minutes, where 0 means no limit
+func parseTimeLimit(input string) (int, error) {
+	minutes, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || minutes < 0 || minutes > maxTimeLimitMinutes {
+		return 0, fmt.Errorf("Please enter a time limit between 0 and %d minutes (0 for no limit)", maxTimeLimitMinutes)
+	}
+	return minutes, nil
+}
+
+// formatTimeLimit renders a time limit given in minutes
+func formatTimeLimit(minutes int) string {
+	if minutes == 0 {
+		return "No limit"
+	}
+	return fmt.Sprintf("%d min", minutes)
+}
+
+// timeRemaining returns the time left in the current attempt. timed is false
+// when the test has no time limit.
+func (a *App) timeRemaining() (remaining time.Duration, timed bool) {
+	if a.currentTest == nil || a.currentTest.TimeLimit <= 0 {
+		return 0, false
+	}
+	remaining = time.Duration(a.currentTest.TimeLimit)*time.Second - time.Since(a.testStartTime)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// expireTest ends an attempt whose time limit ran out. Unanswered questions
+// have no answer and are scored as wrong.
+func (a *App) expireTest() {
+	a.recordQuestionTime()
+	a.testTaking.showResult = true
+	a.testTaking.focusMode = false
+	a.testTaking.confirmQuit = false
+	a.testTaking.confirmSubmit = false
+	a.testTaking.resultMsg = "⏰ Time's up! Unanswered questions are counted as wrong."
+}
+
 // updateTestTaking handles test taking updates
 func (a *App) updateTestTaking(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if len(a.currentQuestions) == 0 {
@@ -38,20 +198,196 @@ func (a *App) updateTestTaking(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if a.scrollTestView(msg) {
+			return a, nil
+		}
+
 		if a.testTaking.showResult {
 			return a.handleResultView(msg)
 		}
 
+		if a.testTaking.confirmQuit {
+			return a.handleQuitConfirm(msg)
+		}
+		if a.testTaking.confirmSubmit {
+			return a.handleSubmitConfirm(msg)
+		}
+		if a.testTaking.navigator {
+			return a.handleNavigator(msg)
+		}
+		if msg.String() == "esc" {
+			a.testTaking.confirmQuit = true
+			return a, nil
+		}
+
 		currentQ := a.currentQuestions[a.testTaking.currentQuestion]
 
+		// F toggles focus mode, except where it is typed as part of a short answer
+		if msg.String() == "ctrl+f" || (msg.String() == "F" && !isTypedAnswer(currentQ.QuestionType)) {
+			a.testTaking.focusMode = !a.testTaking.focusMode
+			return a, nil
+		}
+
+		// G opens the question navigator; g does too, except while typing a short answer
+		if msg.String() == "ctrl+g" || (msg.String() == "g" && !isTypedAnswer(currentQ.QuestionType)) {
+			a.testTaking.navigator = true
+			a.testTaking.navigatorCursor = a.testTaking.currentQuestion
+			return a, nil
+		}
+
+		// M flags the question for review; m does too, except while typing a short answer
+		if msg.String() == "ctrl+b" || (msg.String() == "m" && !isTypedAnswer(currentQ.QuestionType)) {
+			a.toggleMarked(currentQ.ID)
+			return a, nil
+		}
+
+		// Left goes back a question; h and p do too, except while typing a short answer
+		if msg.String() == "left" || ((msg.String() == "h" || msg.String() == "p") && !isTypedAnswer(currentQ.QuestionType)) {
+			return a.previousQuestion()
+		}
+
 		switch currentQ.QuestionType {
 		case "multiple_choice":
 			return a.handleMultipleChoice(msg)
 		case "true_false":
 			return a.handleTrueFalse(msg)
-		case "short_answer":
+		case "short_answer", "fill_blank":
 			return a.handleShortAnswer(msg)
+		case "multi_select":
+			return a.handleMultiSelect(msg)
+		}
+	}
+	return a, nil
+}
+
+// navigatorColumns is the number of question numbers on each row of the navigator
+const navigatorColumns = 10
+
+// handleNavigator moves around the question navigator, jumping to the
+// highlighted question on Enter. Esc or 'g' closes it.
+func (a *App) handleNavigator(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cursor := a.testTaking.navigatorCursor
+	switch msg.String() {
+	case "left", "h":
+		cursor--
+	case "right", "l":
+		cursor++
+	case "up", "k":
+		cursor -= navigatorColumns
+	case "down", "j":
+		cursor += navigatorColumns
+	case "enter", " ":
+		a.jumpToQuestion(cursor)
+		return a, nil
+	case "esc", "g", "ctrl+g":
+		a.testTaking.navigator = false
+		return a, nil
+	}
+	if cursor >= 0 && cursor < len(a.currentQuestions) {
+		a.testTaking.navigatorCursor = cursor
+	}
+	return a, nil
+}
+
+// jumpToQuestion closes the navigator and shows the question at index,
+// with its stored answer if it has one
+func (a *App) jumpToQuestion(index int) {
+	a.testTaking.navigator = false
+	a.recordQuestionTime()
+	a.testTaking.currentQuestion = index
+	a.restoreAnswer()
+}
+
+// viewNavigator renders the question numbers as a grid, marking which have
+// been answered
+func (a *App) viewNavigator() string {
+	s := "Jump to question:\n\n"
+	for i, q := range a.currentQuestions {
+		status, style := "○", errorStyle
+		if _, answered := a.userAnswers[q.ID]; answered {
+			status, style = "●", successStyle
+		}
+		flag := " "
+		if a.testTaking.marked[q.ID] {
+			flag = "⚑"
+		}
+		cell := fmt.Sprintf("%3d %s%s", i+1, status, flag)
+		if i == a.testTaking.navigatorCursor {
+			cell = selectedStyle.Render("[" + cell + "]")
+		} else {
+			cell = " " + style.Render(cell) + " "
+		}
+		s += cell
+		if (i+1)%navigatorColumns == 0 {
+			s += "\n"
+		}
+	}
+	if len(a.currentQuestions)%navigatorColumns != 0 {
+		s += "\n"
+	}
+	
+	s += fmt.Sprintf("\n● answered (%d)  ○ unanswered (%d)  ⚑ marked for review (%d)\n\n",
+		len(a.userAnswers), len(a.currentQuestions)-len(a.userAnswers), len(a.testTaking.marked))
+	s += "Arrow keys to move • Enter to jump to the question • Esc or 'g' to close\n"
+	return s
+}
+
+// toggleMarked flags a question for review, or clears its flag
+func (a *App) toggleMarked(questionID int) {
+	if a.testTaking.marked[questionID] {
+		delete(a.testTaking.marked, questionID)
+	} else {
+		a.testTaking.marked[questionID] = true
+	}
+}
+
+// markedNumbers returns the question numbers of the flagged questions, in order
+func (a *App) markedNumbers() []string {
+	var numbers []string
+	for i, q := range a.currentQuestions {
+		if a.testTaking.marked[q.ID] {
+			numbers = append(numbers, strconv.Itoa(i+1))
+		}
+	}
+	return numbers
+}
+
+// handleSubmitConfirm submits the test on 'y' despite flagged questions, or
+// opens the navigator on the first flagged question on 'n' or esc
+func (a *App) handleSubmitConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		a.testTaking.confirmSubmit = false
+		a.testTaking.showResult = true
+	case "n", "esc":
+		a.testTaking.confirmSubmit = false
+		a.testTaking.navigator = true
+		a.testTaking.navigatorCursor = a.testTaking.currentQuestion
+		for i, q := range a.currentQuestions {
+			if a.testTaking.marked[q.ID] {
+				a.testTaking.navigatorCursor = i
+				break
+			}
+		}
+	}
+	return a, nil
+}
+
+// handleQuitConfirm abandons the test on 'y', discarding its saved progress,
+// and returns to the question on 'n' or esc
+func (a *App) handleQuitConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		if a.currentTest.ID != 0 {
+			if err := a.db.DeleteTestAttempt(a.currentTest.ID); err != nil {
+				a.testTaking.errorMsg = err.Error()
+				return a, nil
+			}
 		}
+		a.testTaking.confirmQuit = false
+		a.currentView = MainMenuView
+	case "n", "esc":
+		a.testTaking.confirmQuit = false
 	}
 	return a, nil
 }
@@ -62,7 +398,17 @@ func (a *App) viewTestTaking() string {
 		return "No questions available"
 	}
 
-	s := a.renderHeader(fmt.Sprintf("Taking Test: %s", a.currentTest.Name))
+	if a.testTaking.showResult && a.testTaking.reviewMode {
+		return a.viewAnswerReview()
+	}
+
+	// Focus mode shows only the question and its answer options
+	focus := a.testTaking.focusMode && !a.testTaking.showResult
+
+	s := ""
+	if !focus {
+		s = a.renderHeader(fmt.Sprintf("Taking Test: %s", a.currentTest.Name))
+	}
 
 	if a.testTaking.errorMsg != "" {
 		s += a.renderError(a.testTaking.errorMsg)
@@ -73,47 +419,153 @@ func (a *App) viewTestTaking() string {
 		return s + a.viewTestComplete() + a.renderFooter()
 	}
 
-	// Progress indicator
-	progress := fmt.Sprintf("Question %d of %d", a.testTaking.currentQuestion+1, len(a.currentQuestions))
-	elapsed := time.Since(a.testStartTime)
-	s += fmt.Sprintf("%s | Time: %s\n\n", progress, a.formatDuration(elapsed))
+	if a.testTaking.navigator {
+		return s + a.viewNavigator()
+	}
+
+	if a.testTaking.confirmSubmit {
+		numbers := a.markedNumbers()
+		s += fmt.Sprintf("%d question(s) still marked for review: %s\n\n", len(numbers), strings.Join(numbers, ", "))
+		s += "Submit the test anyway? (y/n)\n"
+		s += "Press 'n' to pick a question to revisit\n"
+		return s
+	}
+
+	if a.testTaking.confirmQuit {
+		s += fmt.Sprintf("Answered %d of %d questions.\n\n", len(a.userAnswers), len(a.currentQuestions))
+		s += "Quit test? Progress will be lost (y/n)\n"
+		return s
+	}
 
 	currentQ := a.currentQuestions[a.testTaking.currentQuestion]
-	s += fmt.Sprintf("Q%d: %s\n\n", a.testTaking.currentQuestion+1, currentQ.QuestionText)
+
+	if !focus {
+		// Progress indicator
+		progress := fmt.Sprintf("Question %d of %d", a.testTaking.currentQuestion+1, len(a.currentQuestions))
+		// Answers are keyed by question, so changing an answer does not count it twice
+		progress += fmt.Sprintf(" | Answered: %d/%d", len(a.userAnswers), len(a.currentQuestions))
+		timer := "Time: " + a.formatDuration(time.Since(a.testStartTime))
+		if remaining, timed := a.timeRemaining(); timed {
+			timer = "Time left: " + a.formatDuration(remaining)
+		}
+		focusKey, jumpKey, markKey := "F", "g", "m"
+		if isTypedAnswer(currentQ.QuestionType) {
+			focusKey, jumpKey, markKey = "Ctrl+F", "Ctrl+G", "Ctrl+B"
+		}
+		if a.testTaking.marked[currentQ.ID] {
+			progress += " ⚑"
+		}
+		if len(a.testTaking.marked) > 0 {
+			progress += fmt.Sprintf(" | Marked: %d", len(a.testTaking.marked))
+		}
+		s += fmt.Sprintf("%s | %s | %s: focus mode | %s: jump | %s: mark%s\n\n", progress, timer, focusKey, jumpKey, markKey, a.renderAutosaveIndicator())
+	}
+
+	body := a.wrapToWidth(fmt.Sprintf("Q%d: %s", a.testTaking.currentQuestion+1, currentQ.QuestionText)) + "\n\n"
 
 	switch currentQ.QuestionType {
 	case "multiple_choice":
-		s += a.viewMultipleChoice(currentQ)
+		body += a.viewMultipleChoice(currentQ)
 	case "true_false":
-		s += a.viewTrueFalse()
-	case "short_answer":
-		s += a.viewShortAnswer()
+		body += a.viewTrueFalse()
+	case "short_answer", "fill_blank":
+		body += a.viewShortAnswer(currentQ)
+	case "multi_select":
+		body += a.viewMultiSelect(currentQ)
+	}
+
+	footer := ""
+	if !focus {
+		footer = a.renderFooter()
+	}
+	return a.renderScrollable(fmt.Sprintf("question %d", a.testTaking.currentQuestion), s, body, footer)
+}
+
+// renderScrollable renders a header and footer that stay in place around a
+// body shown in the test viewport, so pgup/pgdown can reach the end of long
+// questions and explanations. The viewport returns to the top whenever key
+// changes. Until the terminal size is known the body is shown in full.
+func (a *App) renderScrollable(key, header, body, footer string) string {
+	height := a.height - countLines(header) - countLines(footer) - 2
+	if a.height <= 0 || height < 1 {
+		return header + body + footer
+	}
+
+	vp := &a.testTaking.viewport
+	vp.Width = a.width
+	vp.Height = height
+	vp.SetContent(strings.TrimRight(body, "\n"))
+	if key != a.testTaking.scrollKey {
+		a.testTaking.scrollKey = key
+		vp.GotoTop()
+	}
+	return header + vp.View() + "\n" + footer
+}
+
+// scrollTestView scrolls the test viewport and reports whether the key was
+// used. Page keys always scroll; the arrow keys only do so in answer review,
+// where they do not move an option cursor.
+func (a *App) scrollTestView(msg tea.KeyMsg) bool {
+	vp := &a.testTaking.viewport
+	switch msg.String() {
+	case "pgup":
+		vp.PageUp()
+	case "pgdown":
+		vp.PageDown()
+	case "up", "k", "down", "j":
+		if !a.testTaking.showResult || !a.testTaking.reviewMode {
+			return false
+		}
+		if msg.String() == "up" || msg.String() == "k" {
+			vp.ScrollUp(1)
+		} else {
+			vp.ScrollDown(1)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// viewMultiSelect renders a select-all-that-apply question
+func (a *App) viewMultiSelect(question *database.Question) string {
+	s := "Select ALL correct options (more than one may apply):\n\n"
+
+	for i, option := range question.Options {
+		check := "[ ]"
+		if a.testTaking.selections[i] {
+			check = "[x]"
+		}
+
+		cursor := "  "
+		if a.testTaking.cursor == i {
+			cursor = "► "
+			s += fmt.Sprintf("%s%s %c) %s\n", cursor, check, 'A'+i, selectedStyle.Render(option))
+		} else {
+			s += fmt.Sprintf("%s%s %c) %s\n", cursor, check, 'A'+i, option)
+		}
 	}
 
-	return s + a.renderFooter()
+	s += "\n↑↓ Navigate • Space to check/uncheck • Enter to submit • ← Previous question\n"
+	return s
 }
 
 // viewMultipleChoice renders multiple choice question
 func (a *App) viewMultipleChoice(question *database.Question) string {
 	s := "Choose the correct answer:\n\n"
 
-	letters := []string{"A", "B", "C", "D"}
 	for i, option := range question.Options {
-		if i >= len(letters) {
-			break
-		}
-
 		cursor := "  "
 		if a.testTaking.cursor == i {
 			cursor = "► "
 			style := selectedStyle
-			s += fmt.Sprintf("%s%s) %s\n", cursor, letters[i], style.Render(option))
+			s += fmt.Sprintf("%s%c) %s\n", cursor, 'A'+i, style.Render(option))
 		} else {
-			s += fmt.Sprintf("%s%s) %s\n", cursor, letters[i], option)
+			s += fmt.Sprintf("%s%c) %s\n", cursor, 'A'+i, option)
 		}
 	}
 
-	s += "\n↑↓ Navigate • Enter/Space to select\n"
+	s += "\n↑↓ Navigate • Enter/Space to select • ← Previous question\n"
 	return s
 }
 
@@ -133,24 +585,23 @@ func (a *App) viewTrueFalse() string {
 		}
 	}
 
-	s += "\n↑↓ Navigate • Enter/Space to select\n"
+	s += "\n↑↓ Navigate • Enter/Space to select • ← Previous question\n"
 	return s
 }
 
-// viewShortAnswer renders short answer question
-func (a *App) viewShortAnswer() string {
+// viewShortAnswer renders a short answer or fill in the blank question
+func (a *App) viewShortAnswer(question *database.Question) string {
 	s := "Enter your answer:\n\n"
+	if question.QuestionType == "fill_blank" {
+		s = "Fill in the blank:\n\n"
+	}
 	s += "> " + a.testTaking.input + "\n\n"
-	s += "Type your answer and press Enter to confirm\n"
+	s += "Type your answer and press Enter to confirm • ← Previous question\n"
 	return s
 }
 
 // viewTestComplete renders test completion screen
 func (a *App) viewTestComplete() string {
-	if a.testTaking.reviewMode {
-		return a.viewAnswerReview()
-	}
-
 	correct, score := a.calculateScore(a.currentQuestions, a.userAnswers)
 	total := len(a.currentQuestions)
 	elapsed := time.Since(a.testStartTime)
@@ -163,8 +614,13 @@ func (a *App) viewTestComplete() string {
 		s += a.testTaking.resultMsg + "\n\n"
 	}
 
-	s += "Press Enter to save results and return to main menu\n"
+	if a.currentTest.ID == 0 {
+		s += "Press Enter to return to main menu (practice results are not saved)\n"
+	} else {
+		s += "Press Enter to save results and return to main menu\n"
+	}
 	s += "Press 'r' to review answers\n"
+	s += "Press 'w' to retry only the questions you got wrong\n"
 
 	return s
 }
@@ -184,12 +640,9 @@ func (a *App) handleMultipleChoice(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "enter", " ":
 		if len(currentQ.Options) > a.testTaking.cursor {
-			// Store answer as letter (A, B, C, D)
-			letters := []string{"A", "B", "C", "D"}
-			if a.testTaking.cursor < len(letters) {
-				a.userAnswers[currentQ.ID] = letters[a.testTaking.cursor]
-				return a.nextQuestion()
-			}
+			// Store answer as the option letter (A, B, C, ...)
+			a.userAnswers[currentQ.ID] = string(rune('A' + a.testTaking.cursor))
+			return a.nextQuestion()
 		}
 	}
 	return a, nil
@@ -245,6 +698,43 @@ func (a *App) handleShortAnswer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// handleMultiSelect handles select-all-that-apply input
+func (a *App) handleMultiSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	currentQ := a.currentQuestions[a.testTaking.currentQuestion]
+
+	switch msg.String() {
+	case "up", "k":
+		if a.testTaking.cursor > 0 {
+			a.testTaking.cursor--
+		}
+	case "down", "j":
+		if a.testTaking.cursor < len(currentQ.Options)-1 {
+			a.testTaking.cursor++
+		}
+	case " ", "x":
+		if a.testTaking.selections == nil {
+			a.testTaking.selections = make(map[int]bool)
+		}
+		a.testTaking.selections[a.testTaking.cursor] = !a.testTaking.selections[a.testTaking.cursor]
+	case "enter":
+		var letters []string
+		for i := range currentQ.Options {
+			if a.testTaking.selections[i] {
+				letters = append(letters, string(rune('A'+i)))
+			}
+		}
+		if len(letters) == 0 {
+			a.testTaking.errorMsg = "Select at least one option"
+			return a, nil
+		}
+		// Store answer as a sorted comma list of letters (A,C)
+		a.userAnswers[currentQ.ID] = strings.Join(letters, ",")
+		a.testTaking.selections = nil
+		return a.nextQuestion()
+	}
+	return a, nil
+}
+
 // handleResultView handles input in result view
 func (a *App) handleResultView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if a.testTaking.reviewMode {
@@ -259,25 +749,110 @@ func (a *App) handleResultView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Start answer review
 		a.testTaking.reviewMode = true
 		a.testTaking.reviewQuestion = 0
+	case "w":
+		// Drill the questions answered wrong
+		return a.retryIncorrect()
 	}
 	return a, nil
 }
 
+// incorrectQuestions returns the questions of the current attempt whose
+// answer was wrong or missing, in the order they were asked
+func (a *App) incorrectQuestions() []*database.Question {
+	var incorrect []*database.Question
+	for _, q := range a.currentQuestions {
+		if !a.isAnswerCorrect(q, a.userAnswers[q.ID]) {
+			incorrect = append(incorrect, q)
+		}
+	}
+	return incorrect
+}
+
+// retryIncorrect saves the finished attempt and starts a practice session
+// with only the questions it got wrong
+func (a *App) retryIncorrect() (tea.Model, tea.Cmd) {
+	incorrect := a.incorrectQuestions()
+	if len(incorrect) == 0 {
+		a.testTaking.resultMsg = "🌟 No incorrect answers to retry, well done!"
+		return a, nil
+	}
+	
+	if err := a.recordTestResults(); err != nil {
+		a.testTaking.errorMsg = err.Error()
+		return a, nil
+	}
+	
+	retry := &database.Test{Name: a.currentTest.Name + " (incorrect only)"}
+	return a, a.startTest(retry, incorrect)
+}
+
 // nextQuestion moves to the next question or completes the test
 func (a *App) nextQuestion() (tea.Model, tea.Cmd) {
-	a.testTaking.cursor = 0
-
+	a.recordQuestionTime()
 	if a.testTaking.currentQuestion < len(a.currentQuestions)-1 {
 		// Move to next question
 		a.testTaking.currentQuestion++
+		a.restoreAnswer()
+	} else if len(a.testTaking.marked) > 0 {
+		// Check before submitting with questions still flagged for review
+		a.testTaking.confirmSubmit = true
 	} else {
 		// Test complete
 		a.testTaking.showResult = true
 	}
+	a.saveAnsweredProgress()
+
+	return a, nil
+}
 
+// previousQuestion moves back a question so its answer can be changed
+func (a *App) previousQuestion() (tea.Model, tea.Cmd) {
+	if a.testTaking.currentQuestion > 0 {
+		a.recordQuestionTime()
+		a.testTaking.currentQuestion--
+		a.restoreAnswer()
+	}
 	return a, nil
 }
 
+// recordQuestionTime adds the time since the current question was shown to
+// its total, so revisiting a question adds to the time already spent on it
+func (a *App) recordQuestionTime() {
+	now := time.Now()
+	currentQ := a.currentQuestions[a.testTaking.currentQuestion]
+	a.testTaking.timeSpent[currentQ.ID] += now.Sub(a.testTaking.shownAt)
+	a.testTaking.shownAt = now
+}
+
+// restoreAnswer puts the cursor, input or checked options back to the stored
+// answer of the current question, or resets them if it has not been answered
+func (a *App) restoreAnswer() {
+	currentQ := a.currentQuestions[a.testTaking.currentQuestion]
+	answer := a.userAnswers[currentQ.ID]
+
+	a.testTaking.cursor = 0
+	a.testTaking.input = ""
+	a.testTaking.selections = nil
+
+	switch currentQ.QuestionType {
+	case "multiple_choice":
+		if len(answer) == 1 && answer[0] >= 'A' && int(answer[0]-'A') < len(currentQ.Options) {
+			a.testTaking.cursor = int(answer[0] - 'A')
+		}
+	case "true_false":
+		if answer == "false" {
+			a.testTaking.cursor = 1
+		}
+	case "short_answer", "fill_blank":
+		a.testTaking.input = answer
+	case "multi_select":
+		a.testTaking.selections = make(map[int]bool)
+		for letter := range letterSet(answer) {
+			a.testTaking.selections[int(letter[0]-'A')] = true
+		}
+	}
+}
+
 // viewAnswerReview renders the answer review screen
 func (a *App) viewAnswerReview() string {
 	if len(a.currentQuestions) == 0 {
@@ -287,41 +862,67 @@ func (a *App) viewAnswerReview() string {
 	currentQ := a.currentQuestions[a.testTaking.reviewQuestion]
 	userAnswer := a.userAnswers[currentQ.ID]
 	correctAnswer := currentQ.CorrectAnswer
-	isCorrect := strings.EqualFold(userAnswer, correctAnswer)
+	isCorrect := a.isAnswerCorrect(currentQ, userAnswer)
 
-	s := a.renderHeader(fmt.Sprintf("Answer Review - Question %d of %d", a.testTaking.reviewQuestion+1, len(a.currentQuestions)))
+	header := a.renderHeader(fmt.Sprintf("Answer Review - Question %d of %d", a.testTaking.reviewQuestion+1, len(a.currentQuestions)))
+	if a.testTaking.errorMsg != "" {
+		header += a.renderError(a.testTaking.errorMsg)
+		a.testTaking.errorMsg = ""
+	}
 
 	// Question
-	s += fmt.Sprintf("Q%d: %s\n\n", a.testTaking.reviewQuestion+1, currentQ.QuestionText)
+	s := fmt.Sprintf("Q%d: %s\n", a.testTaking.reviewQuestion+1, currentQ.QuestionText)
+	if a.studyList[currentQ.ID] {
+		s += infoStyle.Render("★ Starred for study") + "\n"
+	}
+	s += "\n"
 
 	// Show options for multiple choice
 	if currentQ.QuestionType == "multiple_choice" {
-		letters := []string{"A", "B", "C", "D"}
+		correctAnswer = choiceLetter(currentQ.Options, correctAnswer)
 		for i, option := range currentQ.Options {
-			if i >= len(letters) {
-				break
-			}
+			letter := string(rune('A' + i))
 
-			prefix := fmt.Sprintf("  %s) ", letters[i])
-			if letters[i] == userAnswer {
+			prefix := fmt.Sprintf("  %s) ", letter)
+			if letter == userAnswer {
 				if isCorrect {
-					prefix = fmt.Sprintf("✓ %s) ", letters[i])
+					prefix = fmt.Sprintf("✓ %s) ", letter)
 					s += successStyle.Render(prefix+option) + "\n"
 				} else {
-					prefix = fmt.Sprintf("✗ %s) ", letters[i])
+					prefix = fmt.Sprintf("✗ %s) ", letter)
 					s += errorStyle.Render(prefix+option) + "\n"
 				}
-			} else if letters[i] == correctAnswer {
-				prefix = fmt.Sprintf("✓ %s) ", letters[i])
+			} else if letter == correctAnswer {
+				prefix = fmt.Sprintf("✓ %s) ", letter)
 				s += successStyle.Render(prefix+option) + "\n"
 			} else {
 				s += prefix + option + "\n"
 			}
 		}
+	} else if currentQ.QuestionType == "multi_select" {
+		selected := letterSet(userAnswer)
+		correctSet := letterSet(correctAnswer)
+		for i, option := range currentQ.Options {
+			letter := string(rune('A' + i))
+			check := "[ ]"
+			if selected[letter] {
+				check = "[x]"
+			}
+
+			line := fmt.Sprintf("%s %s) %s", check, letter, option)
+			switch {
+			case correctSet[letter]:
+				s += successStyle.Render("✓ "+line) + "\n"
+			case selected[letter]:
+				s += errorStyle.Render("✗ "+line) + "\n"
+			default:
+				s += "  " + line + "\n"
+			}
+		}
 	} else {
-		// For true/false and short answer
-		s += fmt.Sprintf("Your answer: %s\n", userAnswer)
-		s += fmt.Sprintf("Correct answer: %s\n", correctAnswer)
+		// For true/false, short answer and fill in the blank
+		s += fmt.Sprintf("Your answer: %s\n", formatAnswer(currentQ.QuestionType, userAnswer))
+		s += fmt.Sprintf("Correct answer: %s\n", formatAnswer(currentQ.QuestionType, correctAnswer))
 	}
 
 	s += "\n"
@@ -338,11 +939,24 @@ func (a *App) viewAnswerReview() string {
 		s += "Explanation:\n"
 		s += infoStyle.Render(currentQ.Explanation) + "\n\n"
 	}
+	
+	if currentQ.SourceRef != "" {
+		s += fmt.Sprintf("Source: %s\n\n", currentQ.SourceRef)
+	}
+
+	if spent := a.testTaking.timeSpent[currentQ.ID]; spent > 0 {
+		s += fmt.Sprintf("Time spent: %s\n\n", a.formatDuration(spent))
+	}
+
+	if a.testTaking.showHistory {
+		s += "History across attempts:\n"
+		s += a.renderAnswerTimeline(a.testTaking.history) + "\n"
+	}
 
 	// Navigation instructions
-	s += "← → Navigate questions • Esc to return to results\n"
+	footer := a.wrapToWidth("← → Navigate questions • ↑ ↓ PgUp PgDn scroll • 's' star for study • 't' toggle answer history • Esc to return to results") + "\n"
 
-	return s + a.renderFooter()
+	return a.renderScrollable(fmt.Sprintf("review %d", a.testTaking.reviewQuestion), header, a.wrapToWidth(s), footer+a.renderFooter())
 }
 
 // handleAnswerReview handles input during answer review
@@ -351,41 +965,128 @@ func (a *App) handleAnswerReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "left", "h":
 		if a.testTaking.reviewQuestion > 0 {
 			a.testTaking.reviewQuestion--
+			a.loadReviewHistory()
 		}
 	case "right", "l":
 		if a.testTaking.reviewQuestion < len(a.currentQuestions)-1 {
 			a.testTaking.reviewQuestion++
+			a.loadReviewHistory()
+		}
+	case "t":
+		a.testTaking.showHistory = !a.testTaking.showHistory
+		a.loadReviewHistory()
+	case "s":
+		currentQ := a.currentQuestions[a.testTaking.reviewQuestion]
+		if err := a.toggleStar(currentQ.ID); err != nil {
+			a.testTaking.errorMsg = err.Error()
 		}
 	case "esc":
 		// Exit review mode
 		a.testTaking.reviewMode = false
 		a.testTaking.reviewQuestion = 0
+		a.testTaking.showHistory = false
 	}
 	return a, nil
 }
 
+// loadReviewHistory loads the answer history of the question under review
+func (a *App) loadReviewHistory() {
+	if !a.testTaking.showHistory {
+		return
+	}
+
+	currentQ := a.currentQuestions[a.testTaking.reviewQuestion]
+	history, err := a.db.GetAnswerHistory(currentQ.ID)
+	if err != nil {
+		a.testTaking.errorMsg = fmt.Sprintf("Failed to load answer history: %v", err)
+		a.testTaking.history = nil
+		return
+	}
+	a.testTaking.history = history
+}
+
+// renderAnswerTimeline renders a compact oldest-to-newest timeline of answers
+func (a *App) renderAnswerTimeline(history []*database.QuestionAnswer) string {
+	if len(history) == 0 {
+		return "  No previous attempts recorded\n"
+	}
+
+	correct := 0
+	markers := ""
+	for _, answer := range history {
+		if answer.IsCorrect {
+			correct++
+			markers += successStyle.Render("✓")
+		} else {
+			markers += errorStyle.Render("✗")
+		}
+	}
+
+	first := history[0].AnsweredAt.Format("Jan 2")
+	last := history[len(history)-1].AnsweredAt.Format("Jan 2")
+	s := fmt.Sprintf("  %s  %s → %s\n", markers, first, last)
+	s += fmt.Sprintf("  %d/%d correct across %d attempt(s)\n", correct, len(history), len(history))
+	return s
+}
+
 // saveTestResults saves the test results to database
 func (a *App) saveTestResults() (tea.Model, tea.Cmd) {
+	if err := a.recordTestResults(); err != nil {
+		a.testTaking.errorMsg = err.Error()
+		return a, nil
+	}
+
+	a.endTest()
+	return a, nil
+}
+
+// recordTestResults saves the finished attempt's result and answers, and
+// adds it to the session summary
+func (a *App) recordTestResults() error {
 	correct, score := a.calculateScore(a.currentQuestions, a.userAnswers)
-	total := len(a.currentQuestions)
-	timeTaken := int(time.Since(a.testStartTime).Seconds())
+	elapsed := time.Since(a.testStartTime)
+	timeTaken := int(elapsed.Seconds())
 
-	// Save test result
-	result, err := a.db.SaveTestResult(a.currentTest.ID, score, total, correct, timeTaken)
-	if err != nil {
-		a.testTaking.errorMsg = fmt.Sprintf("Failed to save results: %v", err)
-		return a, nil
+	// Practice sessions are not backed by a saved test, but their answered
+	// questions are still rescheduled so they drop out of the review queue
+	if a.currentTest.ID == 0 {
+		for _, q := range a.currentQuestions {
+			if userAnswer, ok := a.userAnswers[q.ID]; ok {
+				if err := a.db.RecordReview(q.ID, a.isAnswerCorrect(q, userAnswer)); err != nil {
+					return err
+				}
+			}
+		}
+		a.recordSessionTest(score, elapsed)
+		return nil
+	}
+
+	// Save the result with its answers, in option letters as stored rather
+	// than as shuffled for this attempt, and finish the autosaved attempt
+	answers := make([]database.AttemptAnswer, len(a.currentQuestions))
+	for i, q := range a.currentQuestions {
+		userAnswer, answered := a.userAnswers[q.ID]
+		answers[i] = database.AttemptAnswer{
+			QuestionID: q.ID,
+			UserAnswer: a.storedAnswer(q.ID, userAnswer),
+			IsCorrect:  a.isAnswerCorrect(q, userAnswer),
+			Answered:   answered,
+			TimeSpent:  int(a.testTaking.timeSpent[q.ID].Round(time.Second).Seconds()),
+		}
+	}
+	if _, err := a.db.RecordAttempt(a.currentTest.ID, score, correct, timeTaken, answers); err != nil {
+		return fmt.Errorf("Failed to save results: %v", err)
 	}
 
-	// Save individual question answers (simplified - not implementing detailed answer tracking for now)
-	_ = result // Use result if needed for detailed tracking
+	a.recordSessionTest(score, elapsed)
+	return nil
+}
 
-	// Reset state and return to main menu
+// endTest resets test taking state and returns to the main menu
+func (a *App) endTest() {
 	a.testTaking = NewTestTakingModel()
 	a.currentTest = nil
 	a.currentQuestions = nil
 	a.userAnswers = make(map[int]string)
 	a.currentView = MainMenuView
-
-	return a, nil
 }
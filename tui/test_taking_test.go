@@ -0,0 +1,551 @@
+package tui
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"pdf-test-generator/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// choiceQuestions returns a multiple choice and a select all question, with
+// the option texts that answer them correctly
+func choiceQuestions() ([]*database.Question, map[int][]string) {
+	questions := []*database.Question{
+		{ID: 1, QuestionText: "Largest planet?", QuestionType: "multiple_choice", Options: []string{"Mars", "Jupiter", "Venus", "Mercury"}, CorrectAnswer: "B"},
+		{ID: 2, QuestionText: "Gas giants?", QuestionType: "multi_select", Options: []string{"Saturn", "Earth", "Jupiter", "Mars", "Venus"}, CorrectAnswer: "A,C"},
+	}
+	correct := map[int][]string{1: {"Jupiter"}, 2: {"Saturn", "Jupiter"}}
+	return questions, correct
+}
+
+// lettersOf returns the letters the given option texts have in options
+func lettersOf(options, texts []string) string {
+	var letters []string
+	for i, option := range options {
+		for _, text := range texts {
+			if option == text {
+				letters = append(letters, string(rune('A'+i)))
+			}
+		}
+	}
+	return strings.Join(letters, ",")
+}
+
+func TestScoringInvariantUnderOptionShuffle(t *testing.T) {
+	a := newTestApp(t)
+	a.currentTest = &database.Test{}
+	for seed := int64(1); seed <= 20; seed++ {
+		questions, correct := choiceQuestions()
+		a.rng = rand.New(rand.NewSource(seed))
+		a.startTest(a.currentTest, questions)
+
+		for _, q := range a.currentQuestions {
+			a.userAnswers[q.ID] = lettersOf(q.Options, correct[q.ID])
+		}
+		if n, score := a.calculateScore(a.currentQuestions, a.userAnswers); n != 2 || score != 100 {
+			t.Errorf("seed %d: %d correct, %.0f%%, want every shuffled answer correct", seed, n, score)
+		}
+		for _, q := range questions {
+			if stored := a.storedAnswer(q.ID, a.userAnswers[q.ID]); stored != q.CorrectAnswer {
+				t.Errorf("seed %d: question %d answer stored as %q, want %q", seed, q.ID, stored, q.CorrectAnswer)
+			}
+		}
+
+		// Answering the slot the correct option is stored in is only right
+		// when the shuffle left it there
+		for _, q := range a.currentQuestions {
+			a.userAnswers[q.ID] = "B"
+		}
+		n, _ := a.calculateScore(a.currentQuestions, a.userAnswers)
+		want := 0
+		if a.currentQuestions[0].Options[1] == "Jupiter" {
+			want = 1
+		}
+		if n != want {
+			t.Errorf("seed %d: answering B scored %d correct, want %d with options %q", seed, n, want, a.currentQuestions[0].Options)
+		}
+	}
+}
+
+func TestShuffleOptionsKnownOrder(t *testing.T) {
+	a := newTestApp(t)
+	questions, _ := choiceQuestions()
+	a.rng = rand.New(rand.NewSource(7))
+	order := rand.New(rand.NewSource(7)).Perm(len(questions[0].Options))
+
+	shuffled, got := a.shuffleOptions(questions[0])
+	for i, index := range order {
+		if got[i] != index || shuffled.Options[i] != questions[0].Options[index] {
+			t.Fatalf("order %v with options %q, want the rng's permutation %v", got, shuffled.Options, order)
+		}
+	}
+	if shuffled.Options[shuffled.CorrectAnswer[0]-'A'] != "Jupiter" {
+		t.Errorf("correct answer %s is %q, want Jupiter", shuffled.CorrectAnswer, shuffled.Options[shuffled.CorrectAnswer[0]-'A'])
+	}
+	if questions[0].Options[1] != "Jupiter" || questions[0].CorrectAnswer != "B" {
+		t.Error("shuffling changed the stored question")
+	}
+}
+
+func TestGoBackAndChangeAnswer(t *testing.T) {
+	a := newTestApp(t)
+	questions := []*database.Question{
+		{QuestionText: "The sky is blue.", QuestionType: "true_false", CorrectAnswer: "true"},
+		{QuestionText: "Capital of France?", QuestionType: "short_answer", CorrectAnswer: "Paris"},
+		{QuestionText: "Water is dry.", QuestionType: "true_false", CorrectAnswer: "false"},
+	}
+	test := createTest(t, a, "Mixed", questions...)
+	a.startTest(test, questions)
+
+	press(a, "enter") // Q1: True
+	typeText(a, "paris")
+	press(a, "enter") // Q2
+	if a.testTaking.currentQuestion != 2 {
+		t.Fatalf("on question %d after answering two, want 3", a.testTaking.currentQuestion+1)
+	}
+
+	press(a, "left")
+	if a.testTaking.currentQuestion != 1 || a.testTaking.input != "paris" {
+		t.Fatalf("back on question %d with input %q, want 2 with paris", a.testTaking.currentQuestion+1, a.testTaking.input)
+	}
+	if view := a.viewTestTaking(); !strings.Contains(view, "Question 2 of 3") {
+		t.Errorf("progress does not read Question 2 of 3:\n%s", view)
+	}
+
+	// h is typed into a short answer rather than going back
+	press(a, "h")
+	if a.testTaking.currentQuestion != 1 || a.testTaking.input != "parish" {
+		t.Fatalf("h on a short answer: question %d, input %q", a.testTaking.currentQuestion+1, a.testTaking.input)
+	}
+	press(a, "backspace")
+
+	press(a, "left")
+	if a.testTaking.currentQuestion != 0 || a.testTaking.cursor != 0 {
+		t.Fatalf("back on question %d with cursor %d, want 1 on True", a.testTaking.currentQuestion+1, a.testTaking.cursor)
+	}
+	press(a, "left") // Nothing before the first question
+	if a.testTaking.currentQuestion != 0 {
+		t.Fatalf("went back past the first question")
+	}
+	press(a, "down")
+	press(a, "enter") // Q1: False
+	if a.testTaking.currentQuestion != 1 || a.testTaking.input != "paris" {
+		t.Errorf("after changing Q1, on question %d with input %q", a.testTaking.currentQuestion+1, a.testTaking.input)
+	}
+	if got := a.userAnswers[questions[0].ID]; got != "false" {
+		t.Errorf("Q1 answer = %q, want the changed answer false", got)
+	}
+	if got := a.userAnswers[questions[1].ID]; got != "paris" {
+		t.Errorf("Q2 answer = %q, want paris kept", got)
+	}
+}
+
+func TestTimeLimitExpires(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2")
+	test, err := a.db.CreateTest("Timed", "", "", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, q := range questions {
+		if questions[i], err = a.db.CreateQuestion(test.ID, q.QuestionText, q.QuestionType, q.CorrectAnswer, "", nil, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cmd := a.startTest(test, questions); cmd == nil {
+		t.Fatal("a timed test does not tick")
+	}
+	typeText(a, "answer")
+	press(a, "enter")
+
+	if view := a.viewTestTaking(); !strings.Contains(view, "Time left: ") {
+		t.Errorf("the countdown is not shown:\n%s", view)
+	}
+	a.Update(testTickMsg{startedAt: a.testStartTime})
+	if a.testTaking.showResult {
+		t.Fatal("the test ended with time left")
+	}
+
+	// Run out the clock
+	a.testStartTime = a.testStartTime.Add(-61 * time.Second)
+	_, cmd := a.Update(testTickMsg{startedAt: a.testStartTime})
+	if !a.testTaking.showResult || cmd != nil {
+		t.Fatalf("time ran out: showResult %v, still ticking %v", a.testTaking.showResult, cmd != nil)
+	}
+	if correct, score := a.calculateScore(a.currentQuestions, a.userAnswers); correct != 1 || score != 50 {
+		t.Errorf("scored %d correct at %.0f%%, want the unanswered question wrong", correct, score)
+	}
+}
+
+func TestNoTimeLimit(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1")
+	test := createTest(t, a, "Untimed", questions...)
+	a.startTest(test, questions)
+
+	a.testStartTime = a.testStartTime.Add(-10 * time.Hour)
+	if _, timed := a.timeRemaining(); timed {
+		t.Error("a test without a limit is timed")
+	}
+	if _, cmd := a.Update(testTickMsg{startedAt: a.testStartTime}); a.testTaking.showResult || cmd == nil {
+		t.Errorf("an untimed test ended after 10 hours: showResult %v", a.testTaking.showResult)
+	}
+	if view := a.viewTestTaking(); strings.Contains(view, "Time left") || !strings.Contains(view, "Time: ") {
+		t.Errorf("an untimed test shows a countdown:\n%s", view)
+	}
+}
+
+func TestRetryIncorrectOnly(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2", "Q3", "Q4", "Q5")
+	test := createTest(t, a, "Biology", questions...)
+	a.startTest(test, questions)
+	for i, q := range questions {
+		if i == 0 || i == 2 {
+			a.userAnswers[q.ID] = "answer"
+		} else {
+			a.userAnswers[q.ID] = "wrong"
+		}
+	}
+	a.testTaking.showResult = true
+
+	press(a, "w")
+	want := fmt.Sprint([]int{questions[1].ID, questions[3].ID, questions[4].ID})
+	if got := fmt.Sprint(questionIDs(a.currentQuestions)); got != want {
+		t.Fatalf("retrying questions %s, want the three wrong ones %s", got, want)
+	}
+	if a.currentTest.ID != 0 || a.testTaking.showResult || a.testTaking.currentQuestion != 0 || len(a.userAnswers) != 0 {
+		t.Errorf("retry did not start a fresh practice session: test %+v, state %+v", a.currentTest, a.testTaking)
+	}
+	if results, _ := a.db.GetTestResults(test.ID); len(results) != 1 || results[0].CorrectAnswers != 2 {
+		t.Errorf("the finished attempt was saved as %+v, want one result with 2 correct", results)
+	}
+	if tests, _ := a.db.GetAllTests(); len(tests) != 1 {
+		t.Errorf("retrying created a test, %d saved", len(tests))
+	}
+
+	// A retry with everything right has nothing left to retry
+	for _, q := range a.currentQuestions {
+		a.userAnswers[q.ID] = "answer"
+	}
+	a.testTaking.showResult = true
+	press(a, "w")
+	if !a.testTaking.showResult || !strings.Contains(a.testTaking.resultMsg, "No incorrect answers") {
+		t.Errorf("a perfect retry shows %q", a.testTaking.resultMsg)
+	}
+}
+
+func TestScrollLongQuestion(t *testing.T) {
+	a := newTestApp(t)
+	lines := make([]string, 60)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Step %d of the Krebs cycle", i+1)
+	}
+	questions := shortAnswers(strings.Join(lines, "\n"))
+	test := createTest(t, a, "Biology", questions...)
+	a.startTest(test, questions)
+	a.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+
+	view := a.viewTestTaking()
+	if lineCount(view) > a.height {
+		t.Fatalf("view is %d lines on a %d line terminal:\n%s", lineCount(view), a.height, view)
+	}
+	if !strings.Contains(view, "Step 1 of") || strings.Contains(view, "Step 60 of") {
+		t.Fatalf("view does not start at the top:\n%s", view)
+	}
+
+	vp := &a.testTaking.viewport
+	press(a, "pgdown")
+	if vp.YOffset != vp.Height {
+		t.Errorf("pgdown scrolled to line %d, want %d", vp.YOffset, vp.Height)
+	}
+
+	bottom := vp.TotalLineCount() - vp.Height
+	for range 20 {
+		press(a, "pgdown")
+	}
+	if vp.YOffset != bottom {
+		t.Errorf("scrolled to line %d, want it clamped at %d", vp.YOffset, bottom)
+	}
+	view = a.viewTestTaking()
+	if !strings.Contains(view, "Step 60 of") || strings.Contains(view, "Step 1 of") {
+		t.Errorf("last line not shown at the bottom:\n%s", view)
+	}
+	if !strings.Contains(view, "Taking Test: Biology") {
+		t.Errorf("header scrolled away:\n%s", view)
+	}
+
+	press(a, "pgup")
+	if vp.YOffset != bottom-vp.Height {
+		t.Errorf("pgup scrolled to line %d, want %d", vp.YOffset, bottom-vp.Height)
+	}
+}
+
+func TestEscAsksBeforeQuittingTest(t *testing.T) {
+	a := newTestApp(t)
+	questions := shortAnswers("Q1", "Q2")
+	test := createTest(t, a, "Biology", questions...)
+	a.startTest(test, questions)
+	a.userAnswers[questions[0].ID] = "answer"
+	a.saveAnsweredProgress()
+
+	for _, cancel := range []string{"n", "esc"} {
+		press(a, "esc")
+		if a.currentView != TestTakingView || !a.testTaking.confirmQuit {
+			t.Fatalf("esc moved to view %s, confirming %v; want the quit prompt", a.currentView, a.testTaking.confirmQuit)
+		}
+		if view := a.viewTestTaking(); !strings.Contains(view, "Quit test? Progress will be lost (y/n)") {
+			t.Fatalf("no quit prompt:\n%s", view)
+		}
+		press(a, cancel)
+		if a.currentView != TestTakingView || a.testTaking.confirmQuit {
+			t.Fatalf("%s moved to view %s, confirming %v; want the question back", cancel, a.currentView, a.testTaking.confirmQuit)
+		}
+		if a.userAnswers[questions[0].ID] != "answer" {
+			t.Fatalf("%s lost the answers: %v", cancel, a.userAnswers)
+		}
+	}
+
+	press(a, "esc")
+	press(a, "y")
+	if a.currentView != MainMenuView {
+		t.Errorf("y moved to view %s, want the main menu", a.currentView)
+	}
+	if attempt, err := a.db.GetTestAttempt(test.ID); err != nil || attempt != nil {
+		t.Errorf("attempt after quitting = %+v, %v; want it discarded", attempt, err)
+	}
+}
+
+// trueFalseQuestions returns n true/false questions, all true
+func trueFalseQuestions(n int) []*database.Question {
+	questions := make([]*database.Question, n)
+	for i := range questions {
+		questions[i] = &database.Question{QuestionText: fmt.Sprintf("Statement %d is true.", i+1), QuestionType: "true_false", CorrectAnswer: "true"}
+	}
+	return questions
+}
+
+func TestNavigatorJumpsToQuestion(t *testing.T) {
+	a := newTestApp(t)
+	questions := trueFalseQuestions(12)
+	test := createTest(t, a, "Statements", questions...)
+	a.startTest(test, questions)
+	a.userAnswers[questions[0].ID] = "true"
+	a.userAnswers[questions[6].ID] = "false"
+
+	press(a, "g")
+	if !a.testTaking.navigator {
+		t.Fatal("'g' did not open the navigator")
+	}
+	view := a.viewTestTaking()
+	if !strings.Contains(view, "7 ●") || !strings.Contains(view, "2 ○") {
+		t.Errorf("navigator does not tell answered from unanswered questions:\n%s", view)
+	}
+	if !strings.Contains(view, "● answered (2)  ○ unanswered (10)") {
+		t.Errorf("navigator does not count the answers:\n%s", view)
+	}
+
+	for range 6 {
+		press(a, "right")
+	}
+	press(a, "enter")
+	if a.testTaking.navigator || a.testTaking.currentQuestion != 6 {
+		t.Fatalf("selecting question 7: navigator %v, on index %d, want 6", a.testTaking.navigator, a.testTaking.currentQuestion)
+	}
+	if a.testTaking.cursor != 1 {
+		t.Errorf("cursor %d on question 7, want its stored answer False", a.testTaking.cursor)
+	}
+
+	// Esc closes the navigator where it was
+	press(a, "g")
+	press(a, "left")
+	press(a, "esc")
+	if a.testTaking.navigator || a.testTaking.confirmQuit || a.testTaking.currentQuestion != 6 {
+		t.Errorf("esc in the navigator: navigator %v, quitting %v, on index %d", a.testTaking.navigator, a.testTaking.confirmQuit, a.testTaking.currentQuestion)
+	}
+}
+
+func TestTimeSpentAccumulatesOverVisits(t *testing.T) {
+	a := newTestApp(t)
+	questions := trueFalseQuestions(2)
+	test := createTest(t, a, "Statements", questions...)
+	a.startTest(test, questions)
+
+	// Each visit is made to look as long as the given number of seconds
+	visit := func(seconds int, key string) {
+		a.testTaking.shownAt = time.Now().Add(-time.Duration(seconds) * time.Second)
+		press(a, key)
+	}
+	visit(10, "enter") // Q1, answered
+	visit(5, "left")   // Q2, back to Q1
+	visit(3, "enter")  // Q1 again, answered again
+	visit(4, "enter")  // Q2, answered and submitted
+	if !a.testTaking.showResult {
+		t.Fatalf("test not submitted, on question %d", a.testTaking.currentQuestion+1)
+	}
+
+	spent := func(q *database.Question) int {
+		return int(a.testTaking.timeSpent[q.ID].Round(time.Second).Seconds())
+	}
+	if got := spent(questions[0]); got != 13 {
+		t.Errorf("Q1 took %ds over two visits, want 13s", got)
+	}
+	if got := spent(questions[1]); got != 9 {
+		t.Errorf("Q2 took %ds over two visits, want 9s", got)
+	}
+
+	if err := a.recordTestResults(); err != nil {
+		t.Fatalf("recordTestResults: %v", err)
+	}
+	results, err := a.db.GetTestResults(test.ID)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("%d results saved (err %v), want 1", len(results), err)
+	}
+	answers, err := a.db.GetTestResultAnswers(results[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 2 || answers[0].TimeSpent != 13 || answers[1].TimeSpent != 9 {
+		t.Fatalf("saved answers %+v, want 13s and 9s", answers)
+	}
+
+	a.currentView = TestResultsView
+	a.loadTestResults()
+	a.testResults.selectedResult = &a.testResults.results[0]
+	a.loadResultDetails(a.testResults.selectedResult)
+	if detail := a.viewResultsDetail(); !strings.Contains(detail, "Time Spent: 0:13") || !strings.Contains(detail, "Time Spent: 0:09") {
+		t.Errorf("details do not show the time per question:\n%s", detail)
+	}
+}
+
+func TestMarkForReview(t *testing.T) {
+	a := newTestApp(t)
+	questions := trueFalseQuestions(3)
+	test := createTest(t, a, "Statements", questions...)
+
+	// Without marks the last answer submits at once
+	a.startTest(test, questions)
+	for range questions {
+		press(a, "enter")
+	}
+	if !a.testTaking.showResult || a.testTaking.confirmSubmit {
+		t.Fatalf("unmarked test: submitted %v, confirming %v", a.testTaking.showResult, a.testTaking.confirmSubmit)
+	}
+
+	a.startTest(test, questions)
+	press(a, "enter")
+	press(a, "m") // Q2
+	if !a.testTaking.marked[questions[1].ID] {
+		t.Fatal("'m' did not mark question 2")
+	}
+	if view := a.viewTestTaking(); !strings.Contains(view, "Question 2 of 3 | Answered: 1/3 ⚑ | Marked: 1") {
+		t.Errorf("progress line does not show the mark:\n%s", view)
+	}
+	press(a, "m")
+	if len(a.testTaking.marked) != 0 {
+		t.Fatal("a second 'm' did not clear the mark")
+	}
+	press(a, "m")
+	press(a, "enter")
+	press(a, "g")
+	if view := a.viewTestTaking(); !strings.Contains(view, "2 ●⚑") {
+		t.Errorf("navigator does not flag question 2:\n%s", view)
+	}
+	press(a, "esc")
+
+	press(a, "enter")
+	if a.testTaking.showResult || !a.testTaking.confirmSubmit {
+		t.Fatalf("marked test: submitted %v, confirming %v; want the warning", a.testTaking.showResult, a.testTaking.confirmSubmit)
+	}
+	if view := a.viewTestTaking(); !strings.Contains(view, "1 question(s) still marked for review: 2") {
+		t.Errorf("warning does not name the marked question:\n%s", view)
+	}
+
+	// 'n' goes back to the marked question; 'y' submits anyway
+	press(a, "n")
+	if !a.testTaking.navigator || a.testTaking.navigatorCursor != 1 {
+		t.Fatalf("'n' opened navigator %v on index %d, want the marked question", a.testTaking.navigator, a.testTaking.navigatorCursor)
+	}
+	press(a, "esc")
+	press(a, "enter")
+	press(a, "y")
+	if !a.testTaking.showResult {
+		t.Error("'y' did not submit the test")
+	}
+}
+
+func TestAnsweredCount(t *testing.T) {
+	a := newTestApp(t)
+	questions := trueFalseQuestions(5)
+	test := createTest(t, a, "Statements", questions...)
+	a.startTest(test, questions)
+
+	answered := func() string {
+		view := a.viewTestTaking()
+		at := strings.Index(view, "Answered: ")
+		if at < 0 {
+			t.Fatalf("progress line has no answered count:\n%s", view)
+		}
+		return strings.Fields(view[at:])[1]
+	}
+	if got := answered(); got != "0/5" {
+		t.Errorf("answered %s before starting, want 0/5", got)
+	}
+
+	press(a, "enter")
+	press(a, "enter")
+	if got := answered(); got != "2/5" {
+		t.Errorf("answered %s after two answers, want 2/5", got)
+	}
+
+	// Changing an answer does not count it again
+	press(a, "left")
+	press(a, "down")
+	press(a, "enter")
+	if got := answered(); got != "2/5" {
+		t.Errorf("answered %s after changing an answer, want 2/5", got)
+	}
+}
+
+func TestReviewAnswerHistory(t *testing.T) {
+	a := newTestApp(t)
+	questions := trueFalseQuestions(2)
+	test := createTest(t, a, "Statements", questions...)
+	for i, answer := range []string{"false", "true", "true"} {
+		a.startTest(test, questions)
+		a.userAnswers[questions[0].ID] = answer
+		if err := a.recordTestResults(); err != nil {
+			t.Fatalf("recordTestResults: %v", err)
+		}
+		if _, err := a.db.Exec(`UPDATE test_results SET completed_at = ? WHERE id = (SELECT MAX(id) FROM test_results)`,
+			time.Date(2026, 3, i+1, 10, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.testTaking.showResult = true
+	a.testTaking.reviewMode = true
+
+	if view := a.viewTestTaking(); strings.Contains(view, "History across attempts") {
+		t.Fatalf("history shown before 't':\n%s", view)
+	}
+	press(a, "t")
+	view := a.viewTestTaking()
+	if !strings.Contains(view, "✗✓✓  Mar 1 → Mar 3") || !strings.Contains(view, "2/3 correct across 3 attempt(s)") {
+		t.Errorf("timeline of question 1 not shown:\n%s", view)
+	}
+
+	// Question 2 was left unanswered, which counts against it
+	press(a, "right")
+	if view := a.viewTestTaking(); !strings.Contains(view, "✗✗✗  Mar 1 → Mar 3") || !strings.Contains(view, "0/3 correct") {
+		t.Errorf("timeline of question 2 not shown:\n%s", view)
+	}
+	press(a, "t")
+	if view := a.viewTestTaking(); strings.Contains(view, "History across attempts") {
+		t.Errorf("history still shown after a second 't':\n%s", view)
+	}
+}